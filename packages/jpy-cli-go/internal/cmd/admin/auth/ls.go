@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"jpy-cli/pkg/admin-middleware/model"
+	"jpy-cli/pkg/admin-middleware/service"
+	"jpy-cli/pkg/output"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func NewLsCmd() *cobra.Command {
+	var (
+		field      string
+		query      string
+		sortOrder  string
+		page       int
+		pageSize   int
+		all        bool
+		csvOut     string
+		jsonOutput bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "ls",
+		Short: "列出授权码",
+		Long: `按条件列出授权码。默认显示第一页，--all 遍历全部分页。
+
+--csv 导出为 CSV ('-' 为标准输出)，导出的文件可直接用于 extend / set-limit / disable 的 --from-csv。`,
+		Example: `  jpy admin auth ls --field name -q CS-JPY-23
+  jpy admin auth ls --all --csv auth.csv`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch field {
+			case "name", "title", "serial_number":
+			default:
+				return fmt.Errorf("无效查询字段: %s (name/title/serial_number)", field)
+			}
+
+			adminCfg, err := service.EnsureAuthLoggedIn()
+			if err != nil {
+				return err
+			}
+			client := service.NewClient(adminCfg)
+
+			q := model.AuthCodeQuery{QueryField: field, Query: query, SortOrder: sortOrder, PageSize: pageSize}
+
+			var items []model.AuthCodeItem
+			total := 0
+			if all {
+				it := client.IterAuthCodes(q)
+				for it.Next() {
+					items = append(items, it.Item())
+				}
+				if err := it.Err(); err != nil {
+					return err
+				}
+				total = it.Total()
+			} else {
+				res, err := client.ListAuthCodes(q, page)
+				if err != nil {
+					return err
+				}
+				items, total = res.Data.DataList, res.Data.Total
+			}
+
+			switch {
+			case output.Structured():
+				return output.Print("AuthCodeList", service.AuthCodeRecords(items))
+			case csvOut == "-":
+				return service.WriteAuthCSV(os.Stdout, items)
+			case csvOut != "":
+				f, err := os.Create(csvOut)
+				if err != nil {
+					return fmt.Errorf("创建文件失败: %v", err)
+				}
+				if err := service.WriteAuthCSV(f, items); err != nil {
+					f.Close()
+					return fmt.Errorf("写入 CSV 失败: %v", err)
+				}
+				if err := f.Close(); err != nil {
+					return err
+				}
+				fmt.Printf("已导出 %d 条授权码到: %s\n", len(items), csvOut)
+				return nil
+			case jsonOutput:
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(items)
+			}
+
+			printAuthCodes(items)
+			if all {
+				fmt.Printf("\n共 %d 条\n", len(items))
+			} else {
+				fmt.Printf("\n第 %d 页，本页 %d 条，共 %d 条\n", page, len(items), total)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&field, "field", "name", "查询字段: name/title/serial_number")
+	cmd.Flags().StringVarP(&query, "query", "q", "", "查询内容")
+	cmd.Flags().StringVar(&sortOrder, "sort", "id desc", "排序")
+	cmd.Flags().IntVar(&page, "page", 1, "页码")
+	cmd.Flags().IntVar(&pageSize, "page-size", 20, "每页数量")
+	cmd.Flags().BoolVar(&all, "all", false, "遍历全部分页")
+	cmd.Flags().StringVar(&csvOut, "csv", "", "导出为 CSV 文件 ('-' 为标准输出)")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "以 JSON 格式输出")
+
+	return cmd
+}
+
+func printAuthCodes(items []model.AuthCodeItem) {
+	fmt.Printf("%-6s %-20s %-36s %-6s %-6s %-6s %-6s %s\n", "ID", "名称", "序列号", "上限", "已用", "天数", "在线", "集控地址")
+	fmt.Println(strings.Repeat("-", 120))
+	for _, it := range items {
+		online := "否"
+		if it.Online {
+			online = "是"
+		}
+		fmt.Printf("%-6d %-20s %-36s %-6d %-6d %-6d %-6s %s\n", it.ID, it.Name, it.SerialNumber, it.Limit, it.Used, it.Day, online, it.MgtCenter)
+	}
+}
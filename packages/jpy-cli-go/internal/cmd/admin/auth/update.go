@@ -0,0 +1,272 @@
+package auth
+
+import (
+	"bufio"
+	"fmt"
+	"jpy-cli/pkg/admin-middleware/model"
+	"jpy-cli/pkg/admin-middleware/service"
+	"jpy-cli/pkg/tui"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// updateFlags are shared by extend, set-limit and disable.
+type updateFlags struct {
+	FromCSV string
+	Yes     bool
+	DryRun  bool
+}
+
+func addUpdateFlags(cmd *cobra.Command, f *updateFlags) {
+	cmd.Flags().StringVar(&f.FromCSV, "from-csv", "", "从 CSV 读取序列号 (serial_number 列)")
+	cmd.Flags().BoolVarP(&f.Yes, "yes", "y", false, "跳过确认")
+	cmd.Flags().BoolVar(&f.DryRun, "dry-run", false, "仅显示变更，不提交")
+}
+
+func NewExtendCmd() *cobra.Command {
+	var (
+		flags updateFlags
+		days  int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "extend [SN...]",
+		Short: "延长授权码有效期",
+		Long: `为授权码的有效天数增加 --days 天。
+
+使用 --from-csv 时，CSV 中的 days 列可为每行指定不同天数 (未填写时使用 --days)。`,
+		Example: `  jpy admin auth extend ABCD-1234 EFGH-5678 --days 30
+  jpy admin auth extend --from-csv renew.csv --days 365 --dry-run`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runUpdate(args, flags, func(rows map[string]service.AuthCSVRow) (service.AuthCodeMutator, error) {
+				if days == 0 && !hasColumn(rows, "days") {
+					return nil, fmt.Errorf("请指定 --days")
+				}
+				return func(item model.AuthCodeItem, p *model.AuthCodePayload) (bool, error) {
+					d := days
+					if row, ok := rows[item.SerialNumber]; ok {
+						if v, set, err := row.Int("days"); err != nil {
+							return false, err
+						} else if set {
+							d = v
+						}
+					}
+					return service.ExtendDays(d)(item, p)
+				}, nil
+			})
+		},
+	}
+
+	cmd.Flags().IntVar(&days, "days", 0, "延长天数")
+	addUpdateFlags(cmd, &flags)
+	return cmd
+}
+
+func NewSetLimitCmd() *cobra.Command {
+	var (
+		flags updateFlags
+		limit int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "set-limit [SN...]",
+		Short: "修改授权码设备上限",
+		Long: `将授权码的设备上限设置为 --limit。
+
+使用 --from-csv 时，CSV 中的 limit 列可为每行指定不同上限 (未填写时使用 --limit)，
+因此 'ls --csv' 导出的文件修改 limit 列后可直接导入。`,
+		Example: `  jpy admin auth set-limit ABCD-1234 --limit 40
+  jpy admin auth set-limit --from-csv auth.csv`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			limitSet := cmd.Flags().Changed("limit")
+			return runUpdate(args, flags, func(rows map[string]service.AuthCSVRow) (service.AuthCodeMutator, error) {
+				if !limitSet && !hasColumn(rows, "limit") {
+					return nil, fmt.Errorf("请指定 --limit")
+				}
+				return func(item model.AuthCodeItem, p *model.AuthCodePayload) (bool, error) {
+					l, ok := limit, limitSet
+					if row, found := rows[item.SerialNumber]; found {
+						if v, set, err := row.Int("limit"); err != nil {
+							return false, err
+						} else if set {
+							l, ok = v, true
+						}
+					}
+					if !ok {
+						return false, fmt.Errorf("未指定设备上限")
+					}
+					return service.SetLimit(l)(item, p)
+				}, nil
+			})
+		},
+	}
+
+	cmd.Flags().IntVar(&limit, "limit", 0, "设备上限")
+	addUpdateFlags(cmd, &flags)
+	return cmd
+}
+
+func NewDisableCmd() *cobra.Command {
+	var flags updateFlags
+
+	cmd := &cobra.Command{
+		Use:   "disable [SN...]",
+		Short: "停用授权码",
+		Long: `将授权码的设备上限设为 0，使其无法再授权新设备。
+
+后台没有独立的停用开关；可通过 'set-limit' 恢复上限重新启用。`,
+		Example: `  jpy admin auth disable ABCD-1234
+  jpy admin auth disable --from-csv revoked.csv --yes`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runUpdate(args, flags, func(map[string]service.AuthCSVRow) (service.AuthCodeMutator, error) {
+				return service.SetLimit(0), nil
+			})
+		},
+	}
+
+	addUpdateFlags(cmd, &flags)
+	return cmd
+}
+
+// runUpdate resolves the target codes, shows the planned changes and applies them after confirmation.
+func runUpdate(args []string, flags updateFlags, build func(rows map[string]service.AuthCSVRow) (service.AuthCodeMutator, error)) error {
+	serials, rows, err := resolveTargets(args, flags.FromCSV)
+	if err != nil {
+		return err
+	}
+	mutate, err := build(rows)
+	if err != nil {
+		return err
+	}
+
+	adminCfg, err := service.EnsureAuthLoggedIn()
+	if err != nil {
+		return err
+	}
+	client := service.NewClient(adminCfg)
+
+	fmt.Printf("正在查询 %d 个授权码...\n", len(serials))
+	items, lookupErrs := service.FetchAuthCodes(client, serials)
+	missing := make([]string, 0, len(lookupErrs))
+	for sn := range lookupErrs {
+		missing = append(missing, sn)
+	}
+	sort.Strings(missing)
+	for _, sn := range missing {
+		fmt.Printf("❌ %s: %v\n", sn, lookupErrs[sn])
+	}
+
+	changes, err := service.PlanAuthCodeChanges(items, mutate)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		fmt.Println("没有需要修改的授权码。")
+		return lookupError(len(missing))
+	}
+
+	fmt.Printf("\n%-36s %-20s %s\n", "序列号", "名称", "变更")
+	fmt.Println(strings.Repeat("-", 90))
+	for _, c := range changes {
+		fmt.Printf("%-36s %-20s %s\n", c.Item.SerialNumber, c.Item.Name, describeChange(c))
+	}
+	fmt.Printf("\n共 %d 个授权码将被修改\n", len(changes))
+
+	if flags.DryRun {
+		return lookupError(len(missing))
+	}
+	if !flags.Yes {
+		if err := tui.RequirePrompt("请使用 --yes 跳过确认"); err != nil {
+			return err
+		}
+		fmt.Print("是否继续? [y/N]: ")
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer != "y" && answer != "yes" {
+			fmt.Println("操作已取消。")
+			return nil
+		}
+	}
+
+	failed := service.ApplyAuthCodeChanges(client, changes)
+	for _, c := range changes {
+		if c.Err != nil {
+			fmt.Printf("❌ %s: %v\n", c.Item.SerialNumber, c.Err)
+		}
+	}
+	fmt.Printf("完成。成功: %d, 失败: %d\n", len(changes)-failed, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d 个授权码修改失败", failed)
+	}
+	return lookupError(len(missing))
+}
+
+func lookupError(missing int) error {
+	if missing > 0 {
+		return fmt.Errorf("%d 个授权码查询失败", missing)
+	}
+	return nil
+}
+
+// resolveTargets merges serial numbers from args and the CSV file, keeping the first occurrence.
+func resolveTargets(args []string, csvPath string) ([]string, map[string]service.AuthCSVRow, error) {
+	rows := make(map[string]service.AuthCSVRow)
+	var serials []string
+	seen := make(map[string]bool)
+	add := func(sn string) {
+		if !seen[sn] {
+			seen[sn] = true
+			serials = append(serials, sn)
+		}
+	}
+
+	for _, sn := range args {
+		add(strings.TrimSpace(sn))
+	}
+
+	if csvPath != "" {
+		f, err := os.Open(csvPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("打开 CSV 失败: %v", err)
+		}
+		defer f.Close()
+		csvRows, err := service.ReadAuthCSV(f)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, r := range csvRows {
+			if _, ok := rows[r.SerialNumber]; !ok {
+				rows[r.SerialNumber] = r
+			}
+			add(r.SerialNumber)
+		}
+	}
+
+	if len(serials) == 0 {
+		return nil, nil, fmt.Errorf("请指定授权码序列号或 --from-csv")
+	}
+	return serials, rows, nil
+}
+
+func hasColumn(rows map[string]service.AuthCSVRow, column string) bool {
+	for _, r := range rows {
+		if strings.TrimSpace(r.Values[column]) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func describeChange(c service.AuthCodeChange) string {
+	var parts []string
+	if c.Item.Day != c.Payload.Day {
+		parts = append(parts, fmt.Sprintf("有效期 %d -> %d 天", c.Item.Day, c.Payload.Day))
+	}
+	if c.Item.Limit != c.Payload.Limit {
+		parts = append(parts, fmt.Sprintf("上限 %d -> %d", c.Item.Limit, c.Payload.Limit))
+	}
+	return strings.Join(parts, ", ")
+}
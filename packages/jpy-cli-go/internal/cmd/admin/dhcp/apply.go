@@ -0,0 +1,102 @@
+package dhcp
+
+import (
+	"fmt"
+	"jpy-cli/pkg/admin-dhcp/model"
+	"jpy-cli/pkg/admin-dhcp/service"
+	"jpy-cli/pkg/tui"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func NewApplyCmd() *cobra.Command {
+	var (
+		baseURL string
+		prune   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "apply <export-file>",
+		Short: "按设备导出文件核对 DHCP 租约",
+		Long: `读取 'jpy middleware device export --export-auto' 的输出 (ID\tUUID\tIP\t机位)，
+按 SN = UUID 与现有租约比对并显示差异：
+
+  +  设备尚无租约 (DHCP 服务在设备下次请求时分配)
+  -  租约 IP 与导出文件不一致，或同一 SN 的重复租约
+
+DHCP 服务只支持查询与删除租约。--prune 删除以上 "-" 租约，使设备重新获取；
+只会删除导出文件中设备的租约，且必须在终端中确认。`,
+		Example: `  jpy middleware device export devices.txt --export-auto -g rack1
+  jpy admin dhcp apply devices.txt
+  jpy admin dhcp apply devices.txt --prune`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return fmt.Errorf("打开导出文件失败: %v", err)
+			}
+			entries, err := service.ParseExport(f)
+			f.Close()
+			if err != nil {
+				return err
+			}
+			if len(entries) == 0 {
+				return fmt.Errorf("导出文件中没有设备")
+			}
+
+			client, err := service.EnsureLoggedIn(baseURL)
+			if err != nil {
+				return err
+			}
+
+			leases, err := client.ListAllLeases(model.LeaseListParams{})
+			if err != nil {
+				return err
+			}
+
+			plan, err := service.BuildPlan(leases, entries)
+			if err != nil {
+				return err
+			}
+
+			missing, stale := plan.Counts()
+			if len(plan.Changes) == 0 {
+				fmt.Printf("租约已是最新 (%d 条无变化)\n", plan.Unchanged)
+				return nil
+			}
+
+			for _, c := range plan.Changes {
+				fmt.Println(service.FormatChange(c))
+			}
+			fmt.Printf("\n尚无租约 %d, 过期租约 %d, 无变化 %d\n", missing, stale, plan.Unchanged)
+
+			if !prune || stale == 0 {
+				return nil
+			}
+			if err := tui.RequirePrompt("--prune 需要在终端中确认"); err != nil {
+				return err
+			}
+			ok, err := confirm(fmt.Sprintf("\n确认删除 %d 条过期租约?", stale))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				fmt.Println("操作已取消。")
+				return nil
+			}
+
+			deleted, err := service.Prune(client, plan)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("已删除 %d 条租约\n", deleted)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&baseURL, "url", "", "DHCP 服务地址 (默认使用上次登录的地址)")
+	cmd.Flags().BoolVar(&prune, "prune", false, "删除导出设备的过期租约 (需确认)")
+
+	return cmd
+}
@@ -0,0 +1,99 @@
+package dhcp
+
+import (
+	"fmt"
+	"jpy-cli/pkg/admin-dhcp/api"
+	"jpy-cli/pkg/admin-dhcp/model"
+	"jpy-cli/pkg/admin-dhcp/service"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+func NewDeleteCmd() *cobra.Command {
+	var (
+		baseURL string
+		bySN    bool
+		yes     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "delete <id|SN>...",
+		Short: "删除 DHCP 租约",
+		Long: `按租约 ID 删除 DHCP 租约；使用 --sn 时参数视为设备序列号。
+
+删除前会列出将要删除的租约并要求确认，--yes 跳过确认。`,
+		Example: `  jpy admin dhcp delete 12 13
+  jpy admin dhcp delete --sn ABC123 DEF456 --yes`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := service.EnsureLoggedIn(baseURL)
+			if err != nil {
+				return err
+			}
+
+			leases, err := client.ListAllLeases(model.LeaseListParams{})
+			if err != nil {
+				return err
+			}
+
+			byID := make(map[int]model.DHCPLease)
+			bySNMap := make(map[string][]model.DHCPLease)
+			for _, l := range leases {
+				byID[l.ID] = l
+				bySNMap[l.SN] = append(bySNMap[l.SN], l)
+			}
+
+			var targets []model.DHCPLease
+			for _, arg := range args {
+				if bySN {
+					found, ok := bySNMap[arg]
+					if !ok {
+						return fmt.Errorf("未找到 SN 为 %s 的租约", arg)
+					}
+					targets = append(targets, found...)
+					continue
+				}
+				id, err := strconv.Atoi(arg)
+				if err != nil {
+					return fmt.Errorf("无效的租约 ID: %s", arg)
+				}
+				l, ok := byID[id]
+				if !ok {
+					return fmt.Errorf("未找到 ID 为 %d 的租约", id)
+				}
+				targets = append(targets, l)
+			}
+
+			fmt.Println("即将删除以下租约:")
+			ids := make([]int, 0, len(targets))
+			for _, l := range targets {
+				ids = append(ids, l.ID)
+				fmt.Printf("- %-6d %-32s %-15s %s\n", l.ID, l.SN, api.IPToString(l.IP), api.MACToString(l.MAC))
+			}
+
+			if !yes {
+				ok, err := confirm(fmt.Sprintf("\n确认删除 %d 条租约?", len(ids)))
+				if err != nil {
+					return err
+				}
+				if !ok {
+					fmt.Println("操作已取消。")
+					return nil
+				}
+			}
+
+			if err := client.DeleteLeases(ids); err != nil {
+				return fmt.Errorf("删除失败: %v", err)
+			}
+			fmt.Printf("已删除 %d 条租约\n", len(ids))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&baseURL, "url", "", "DHCP 服务地址 (默认使用上次登录的地址)")
+	cmd.Flags().BoolVar(&bySN, "sn", false, "参数为设备序列号")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "跳过确认")
+
+	return cmd
+}
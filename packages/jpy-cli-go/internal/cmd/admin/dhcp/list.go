@@ -0,0 +1,120 @@
+package dhcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"jpy-cli/pkg/admin-dhcp/api"
+	"jpy-cli/pkg/admin-dhcp/model"
+	"jpy-cli/pkg/admin-dhcp/service"
+	"jpy-cli/pkg/tui"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func NewListCmd() *cobra.Command {
+	var (
+		baseURL    string
+		queryField string
+		query      string
+		mode       int
+		page       int
+		pageSize   int
+		all        bool
+		jsonOutput bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "查看 DHCP 租约列表",
+		Example: `  jpy admin dhcp list
+  jpy admin dhcp list --field SN --query ABC123
+  jpy admin dhcp list --all --json > leases.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := service.EnsureLoggedIn(baseURL)
+			if err != nil {
+				return err
+			}
+
+			params := model.LeaseListParams{
+				QueryField: queryField,
+				Query:      query,
+				PageNum:    page,
+				PageSize:   pageSize,
+			}
+			if cmd.Flags().Changed("mode") {
+				params.Mode = &mode
+			}
+
+			var leases []model.DHCPLease
+			total := 0
+			if all {
+				leases, err = client.ListAllLeases(params)
+				total = len(leases)
+			} else {
+				var result *model.LeasePage
+				result, err = client.ListLeases(params)
+				if result != nil {
+					leases, total = result.DataList, result.Total
+				}
+			}
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(leases)
+			}
+
+			printLeases(leases)
+			if all {
+				fmt.Printf("\n共 %d 条租约\n", total)
+			} else {
+				fmt.Printf("\n第 %d 页，本页 %d 条，共 %d 条\n", page, len(leases), total)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&baseURL, "url", "", "DHCP 服务地址 (默认使用上次登录的地址)")
+	cmd.Flags().StringVar(&queryField, "field", "", "查询字段 (例如: SN, ip, mac)")
+	cmd.Flags().StringVarP(&query, "query", "q", "", "查询内容")
+	cmd.Flags().IntVar(&mode, "mode", 0, "按模式过滤")
+	cmd.Flags().IntVar(&page, "page", 1, "页码")
+	cmd.Flags().IntVar(&pageSize, "page-size", 50, "每页数量")
+	cmd.Flags().BoolVar(&all, "all", false, "获取全部页")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "以 JSON 格式输出")
+
+	return cmd
+}
+
+func printLeases(leases []model.DHCPLease) {
+	fmt.Printf("%-6s %-4s %-32s %-15s %-17s %-15s %-15s %s\n", "ID", "模式", "SN", "IP", "MAC", "掩码", "网关", "DNS")
+	fmt.Println(strings.Repeat("-", 120))
+	for _, l := range leases {
+		dns := api.IPToString(l.DNS1)
+		if l.DNS2 != 0 {
+			dns += "," + api.IPToString(l.DNS2)
+		}
+		fmt.Printf("%-6d %-4d %-32s %-15s %-17s %-15s %-15s %s\n",
+			l.ID, l.Mode, l.SN, api.IPToString(l.IP), api.MACToString(l.MAC),
+			api.IPToString(l.Mask), api.IPToString(l.Gateway), dns)
+	}
+}
+
+// confirm asks a yes/no question on stdin. Anything but y/yes is a no.
+// Without a terminal it fails instead of waiting for input.
+func confirm(prompt string) (bool, error) {
+	if err := tui.RequirePrompt("请使用 --yes 跳过确认"); err != nil {
+		return false, err
+	}
+	fmt.Print(prompt + " [y/N]: ")
+	reader := bufio.NewReader(os.Stdin)
+	answer, _ := reader.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
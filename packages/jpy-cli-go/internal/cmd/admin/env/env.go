@@ -0,0 +1,123 @@
+package env
+
+import (
+	"fmt"
+	"jpy-cli/pkg/admin-middleware/service"
+	"jpy-cli/pkg/config"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func NewAddCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "add <name> <base-url>",
+		Short:   "添加或更新管理后台环境",
+		Example: `  jpy admin env add staging https://admin-staging.example.com/api/v1`,
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Load()
+			if err != nil {
+				return err
+			}
+			if err := service.AddEnv(cfg, args[0], args[1]); err != nil {
+				return err
+			}
+			if err := config.Save(cfg); err != nil {
+				return fmt.Errorf("保存配置失败: %v", err)
+			}
+			fmt.Printf("已保存环境 %s: %s\n", args[0], cfg.AdminEnvs[args[0]])
+			return nil
+		},
+	}
+	return cmd
+}
+
+func NewUseCmd() *cobra.Command {
+	var role string
+
+	cmd := &cobra.Command{
+		Use:   "use <name>",
+		Short: "切换管理后台环境",
+		Long: `将授权 (auth) 和/或运维 (operation) 账号切换到指定环境。
+
+内置环境 'production' 为正式后台。切换到不同地址后原 Token 失效，需要重新登录。`,
+		Example: `  jpy admin env use staging
+  jpy admin env use staging --role auth
+  jpy admin env use production`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var roles []service.AdminRole
+			switch role {
+			case "all":
+				roles = []service.AdminRole{service.RoleAuth, service.RoleOperation}
+			case string(service.RoleAuth), string(service.RoleOperation):
+				roles = []service.AdminRole{service.AdminRole(role)}
+			default:
+				return fmt.Errorf("无效角色: %s (auth/operation/all)", role)
+			}
+
+			cfg, err := config.Load()
+			if err != nil {
+				return err
+			}
+			relogin, err := service.UseEnv(cfg, args[0], roles)
+			if err != nil {
+				return err
+			}
+			// A context that pins an environment applies it on every load, so it has to follow
+			name, ctx, _ := config.CurrentContext()
+			pinned := ctx != nil && ctx.AdminEnv != ""
+			if pinned && role != "all" {
+				return fmt.Errorf("当前上下文 %s 固定了后台环境 %s，请使用 --role all 或 jpy config set-context --admin-env 修改", name, ctx.AdminEnv)
+			}
+			if err := config.Save(cfg); err != nil {
+				return fmt.Errorf("保存配置失败: %v", err)
+			}
+			if pinned {
+				// After Save: cfg may be config.json itself and would overwrite the change
+				if err := config.UpdateContext(name, func(c *config.Context) { c.AdminEnv = args[0] }); err != nil {
+					return fmt.Errorf("保存配置失败: %v", err)
+				}
+			}
+
+			fmt.Printf("已切换到环境 %s (%s)\n", args[0], role)
+			for _, r := range relogin {
+				fmt.Printf("%s 账号的 Token 已清除，下次使用时需重新登录\n", r)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&role, "role", "all", "切换的账号: auth/operation/all")
+	return cmd
+}
+
+func NewListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "查看管理后台环境",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Load()
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("%-16s %-20s %s\n", "名称", "使用中", "地址")
+			fmt.Println(strings.Repeat("-", 80))
+			for _, e := range service.ListEnvs(cfg) {
+				roles := make([]string, 0, len(e.Roles))
+				for _, r := range e.Roles {
+					roles = append(roles, string(r))
+				}
+				mark := " "
+				if len(roles) > 0 {
+					mark = "*"
+				}
+				fmt.Printf("%s %-14s %-20s %s\n", mark, e.Name, strings.Join(roles, ","), e.BaseURL)
+			}
+			return nil
+		},
+	}
+	return cmd
+}
@@ -0,0 +1,78 @@
+package login
+
+import (
+	"fmt"
+	"jpy-cli/pkg/admin-middleware/service"
+	"jpy-cli/pkg/config"
+
+	"github.com/spf13/cobra"
+)
+
+// AddLoginFlags adds the admin login flags to commands that may need to log in.
+// Values are written to service.LoginDefaults.
+func AddLoginFlags(cmd *cobra.Command) {
+	opts := &service.LoginDefaults
+	cmd.Flags().StringVar(&opts.Username, "admin-user", "", "管理后台用户名 (或 JPY_ADMIN_USERNAME)")
+	cmd.Flags().StringVar(&opts.Password, "admin-password", "", "管理后台密码 (建议使用 JPY_ADMIN_PASSWORD)")
+	cmd.Flags().StringVar(&opts.CaptchaPath, "captcha-path", "", "验证码图片保存路径 (或 JPY_ADMIN_CAPTCHA_PATH)")
+	cmd.Flags().StringVar(&opts.CaptchaAnswer, "captcha-answer", "", "从文件或命名管道读取验证码答案 (或 JPY_ADMIN_CAPTCHA_ANSWER)")
+	cmd.Flags().StringVar(&opts.CaptchaSolver, "captcha-solver", "", "验证码识别命令，图片路径作为最后一个参数，标准输出为答案 (或 JPY_ADMIN_CAPTCHA_SOLVER)")
+	cmd.Flags().DurationVar(&opts.Timeout, "captcha-timeout", 0, "等待验证码答案的超时时间 (默认 2m)")
+	cmd.Flags().BoolVar(&opts.NonInteractive, "non-interactive", false, "禁止交互输入，缺少登录信息时直接失败 (或 JPY_ADMIN_NON_INTERACTIVE=1)")
+}
+
+func NewLoginCmd() *cobra.Command {
+	var (
+		role  string
+		force bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "login",
+		Short: "登录管理后台",
+		Long: `登录管理后台并保存 Token。已有有效 Token 时不会重复登录 (--force 强制重新登录)。
+
+非交互登录 (CI):
+  账号通过 --admin-user/--admin-password 或环境变量提供，
+  角色专用变量 JPY_ADMIN_AUTH_* / JPY_ADMIN_OPERATION_* 优先于 JPY_ADMIN_*。
+  验证码图片写入 --captcha-path，答案来自:
+    --captcha-answer <文件|命名管道>  验证码保存后写入的答案 (普通文件读取后删除)
+    --captcha-solver "<命令>"         以图片路径为最后一个参数执行，读取其标准输出`,
+		Example: `  JPY_ADMIN_USERNAME=ci JPY_ADMIN_PASSWORD=*** jpy admin login --captcha-solver "python3 solve.py"
+  jpy admin login --role operation --captcha-path /tmp/c.png --captcha-answer /tmp/c.answer --non-interactive`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var r service.AdminRole
+			switch role {
+			case string(service.RoleAuth), string(service.RoleOperation):
+				r = service.AdminRole(role)
+			default:
+				return fmt.Errorf("无效角色: %s (auth/operation)", role)
+			}
+
+			if force {
+				cfg, err := config.Load()
+				if err != nil {
+					return err
+				}
+				_, err = service.PerformLogin(cfg, r)
+				return err
+			}
+
+			var err error
+			if r == service.RoleAuth {
+				_, err = service.EnsureAuthLoggedIn()
+			} else {
+				_, err = service.EnsureOperationLoggedIn()
+			}
+			if err == nil {
+				fmt.Printf("%s 账号已登录\n", r)
+			}
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&role, "role", string(service.RoleAuth), "登录的账号: auth/operation")
+	cmd.Flags().BoolVar(&force, "force", false, "忽略已保存的 Token，强制重新登录")
+	AddLoginFlags(cmd)
+	return cmd
+}
@@ -3,8 +3,9 @@ package middleware
 import (
 	"bufio"
 	"fmt"
-	"jpy-cli/pkg/admin-middleware/api"
+	"jpy-cli/pkg/admin-middleware/model"
 	"jpy-cli/pkg/admin-middleware/service"
+	"jpy-cli/pkg/tui"
 	"os"
 	"strconv"
 	"strings"
@@ -13,17 +14,29 @@ import (
 )
 
 func NewGenerateCmd() *cobra.Command {
+	defaults := model.DefaultAuthCodeOptions("")
+	var (
+		limit     int
+		days      int
+		codeType  int
+		supervise bool
+	)
+
 	cmd := &cobra.Command{
 		Use:   "generate",
 		Short: "批量生成授权码",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := tui.RequirePrompt("批量生成授权码需要在终端中交互输入"); err != nil {
+				return err
+			}
+
 			// 1. Ensure Login
 			adminCfg, err := service.EnsureLoggedIn()
 			if err != nil {
 				return err
 			}
 
-			client := api.NewClient(adminCfg.Token)
+			client := service.NewClient(adminCfg)
 			reader := bufio.NewReader(os.Stdin)
 
 			// 2. Interactive Input
@@ -74,7 +87,9 @@ func NewGenerateCmd() *cobra.Command {
 				name := fmt.Sprintf("%s%d", prefix, currentNum)
 
 				fmt.Printf("[%d/%d] 正在生成 %s... ", i+1, count, name)
-				err := client.GenerateAuthCode(name)
+				opts := model.DefaultAuthCodeOptions(name)
+				opts.Limit, opts.Day, opts.Type, opts.Supervise = limit, days, codeType, supervise
+				err := client.GenerateAuthCodeWith(opts)
 				if err != nil {
 					fmt.Printf("失败: %v\n", err)
 					failCount++
@@ -95,5 +110,10 @@ func NewGenerateCmd() *cobra.Command {
 		},
 	}
 
+	cmd.Flags().IntVar(&limit, "limit", defaults.Limit, "设备上限")
+	cmd.Flags().IntVar(&days, "days", defaults.Day, "有效天数")
+	cmd.Flags().IntVar(&codeType, "type", defaults.Type, "授权类型")
+	cmd.Flags().BoolVar(&supervise, "supervise", defaults.Supervise, "是否监管")
+
 	return cmd
 }
@@ -5,7 +5,10 @@ import (
 	"jpy-cli/pkg/admin-middleware/api"
 	"jpy-cli/pkg/admin-middleware/model"
 	"jpy-cli/pkg/admin-middleware/service"
+	"jpy-cli/pkg/output"
+	"jpy-cli/pkg/tui"
 	"os"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
@@ -159,9 +162,13 @@ func (m listModel) View() string {
 }
 
 func NewListCmd() *cobra.Command {
+	var page int
+	var all bool
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "查看授权码列表 (TUI)",
+		Long: `查看授权码列表。默认进入交互式 TUI；
+指定 --output json/yaml/csv/tsv/template 或在非终端环境 (--no-tui) 下直接输出第 --page 页 (或 --all 全部)。`,
 		Run: func(cmd *cobra.Command, args []string) {
 			// 1. Ensure Login
 			adminCfg, err := service.EnsureLoggedIn()
@@ -170,7 +177,15 @@ func NewListCmd() *cobra.Command {
 				return
 			}
 
-			client := api.NewClient(adminCfg.Token)
+			client := service.NewClient(adminCfg)
+
+			if output.Structured() || !tui.Interactive() {
+				if err := printAuthList(client, page, all); err != nil {
+					fmt.Fprintf(os.Stderr, "获取授权码列表失败: %v\n", err)
+					os.Exit(1)
+				}
+				return
+			}
 
 			p := tea.NewProgram(initialModel(client))
 			if _, err := p.Run(); err != nil {
@@ -179,5 +194,39 @@ func NewListCmd() *cobra.Command {
 			}
 		},
 	}
+	cmd.Flags().IntVar(&page, "page", 1, "页码 (仅用于非交互输出)")
+	cmd.Flags().BoolVar(&all, "all", false, "输出全部分页 (仅用于非交互输出)")
 	return cmd
 }
+
+func printAuthList(client *api.Client, page int, all bool) error {
+	var items []model.AuthCodeItem
+	if all {
+		it := client.IterAuthCodes(model.AuthCodeQuery{})
+		for it.Next() {
+			items = append(items, it.Item())
+		}
+		if err := it.Err(); err != nil {
+			return err
+		}
+	} else {
+		res, err := client.GetAuthList(page)
+		if err != nil {
+			return err
+		}
+		items = res.Data.DataList
+	}
+	if !output.Structured() {
+		printAuthTable(items)
+		return nil
+	}
+	return output.Print("AuthCodeList", service.AuthCodeRecords(items))
+}
+
+func printAuthTable(items []model.AuthCodeItem) {
+	fmt.Printf("%-6s %-20s %-36s %-6s %-6s %-6s %s\n", "ID", "名称", "序列号", "上限", "已用", "天数", "集控地址")
+	fmt.Println(strings.Repeat("-", 110))
+	for _, it := range items {
+		fmt.Printf("%-6d %-20s %-36s %-6d %-6d %-6d %s\n", it.ID, it.Name, it.SerialNumber, it.Limit, it.Used, it.Day, it.MgtCenter)
+	}
+}
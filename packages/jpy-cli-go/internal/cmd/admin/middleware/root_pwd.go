@@ -2,7 +2,6 @@ package middleware
 
 import (
 	"fmt"
-	"jpy-cli/pkg/admin-middleware/api"
 	"jpy-cli/pkg/admin-middleware/service"
 	"strings"
 
@@ -21,7 +20,7 @@ func NewGetRootPasswordCmd() *cobra.Command {
 				return err
 			}
 
-			client := api.NewClient(adminCfg.Token)
+			client := service.NewClient(adminCfg)
 			key := strings.TrimSpace(args[0])
 
 			// 2. Call API
@@ -0,0 +1,309 @@
+package config_cmd
+
+import (
+	"fmt"
+	"jpy-cli/pkg/config"
+	"jpy-cli/pkg/output"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+const contextHelp = `上下文将分组、管理后台环境、默认设备筛选、并发与超时组合在一起，便于在客户之间切换。
+
+选择顺序: --context 参数 > 环境变量 JPY_CONTEXT > jpy config use-context 保存的当前上下文。
+使用 --separate-file 时该上下文的服务器与令牌保存在独立文件 (contexts/<名称>.json)，
+不同终端可通过 JPY_CONTEXT 同时安全地操作不同客户。
+未使用独立文件时，上下文的分组与后台环境只在运行时生效，不会写回 config.json；
+后台环境与保存的不同时，该环境的登录令牌也不会保存。`
+
+func newSetContextCmd() *cobra.Command {
+	var (
+		group        string
+		adminEnv     string
+		concurrency  int
+		timeout      int
+		where        string
+		server       string
+		labelFilters []string
+		separateFile bool
+	)
+	cmd := &cobra.Command{
+		Use:   "set-context NAME",
+		Short: "创建或修改上下文",
+		Long:  contextHelp + "\n\n修改已有上下文时只更新指定的参数；指定任一筛选参数时替换整个默认筛选。",
+		Example: `  jpy config set-context acme --group acme --admin-env production --max-concurrency 10
+  jpy config set-context beta --group beta --where 'online' --separate-file
+  JPY_CONTEXT=beta jpy middleware device list`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			if strings.ContainsAny(name, `/\`) || strings.TrimSpace(name) == "" {
+				return fmt.Errorf("无效的上下文名称: %q", name)
+			}
+
+			root, err := config.LoadRoot()
+			if err != nil {
+				return err
+			}
+			ctx, exists := root.Contexts[name]
+
+			flags := cmd.Flags()
+			if flags.Changed("group") {
+				ctx.Group = group
+			}
+			if flags.Changed("admin-env") {
+				if adminEnv != "" && adminEnv != "production" {
+					if _, ok := root.AdminEnvs[adminEnv]; !ok {
+						return fmt.Errorf("后台环境 '%s' 不存在，请先使用 'jpy admin env add' 添加", adminEnv)
+					}
+				}
+				ctx.AdminEnv = adminEnv
+			}
+			if flags.Changed("max-concurrency") {
+				ctx.MaxConcurrency = concurrency
+			}
+			if flags.Changed("connect-timeout") {
+				ctx.ConnectTimeout = timeout
+			}
+			if flags.Changed("where") || flags.Changed("server") || flags.Changed("label") {
+				ctx.Filter = &config.DeviceFilter{Where: where, Server: server, Labels: labelFilters}
+				if where == "" && server == "" && len(labelFilters) == 0 {
+					ctx.Filter = nil
+				}
+			}
+
+			created := false
+			if separateFile && ctx.File == "" {
+				ctx.File = config.ContextFile(name)
+				if created, err = config.SeedContextFile(root, &ctx); err != nil {
+					return fmt.Errorf("创建上下文配置文件失败: %v", err)
+				}
+			}
+
+			if root.Contexts == nil {
+				root.Contexts = make(map[string]config.Context)
+			}
+			root.Contexts[name] = ctx
+			if err := config.Save(root); err != nil {
+				return fmt.Errorf("保存配置失败: %v", err)
+			}
+
+			if exists {
+				fmt.Printf("已更新上下文 %s\n", name)
+			} else {
+				fmt.Printf("已创建上下文 %s\n", name)
+			}
+			if created {
+				fmt.Printf("独立配置文件: %s\n", ctx.FilePath())
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&group, "group", "", "服务器分组")
+	cmd.Flags().StringVar(&adminEnv, "admin-env", "", "管理后台环境 (见 jpy admin env list)")
+	cmd.Flags().IntVar(&concurrency, "max-concurrency", 0, "最大并发数 (0 使用全局设置)")
+	cmd.Flags().IntVar(&timeout, "connect-timeout", 0, "连接握手超时秒数 (0 使用全局设置)")
+	cmd.Flags().StringVar(&where, "where", "", "默认设备查询表达式")
+	cmd.Flags().StringVar(&server, "server", "", "默认服务器地址匹配模式")
+	cmd.Flags().StringArrayVar(&labelFilters, "label", nil, "默认标签筛选 (可重复)")
+	cmd.Flags().BoolVar(&separateFile, "separate-file", false, "将该上下文的服务器与令牌保存到独立文件 (复制当前分组的服务器)")
+	return cmd
+}
+
+func newUseContextCmd() *cobra.Command {
+	var unset bool
+	cmd := &cobra.Command{
+		Use:   "use-context [NAME]",
+		Short: "设置当前上下文",
+		Long:  contextHelp,
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if unset == (len(args) == 1) {
+				return fmt.Errorf("请指定上下文名称，或使用 --unset 取消当前上下文")
+			}
+			root, err := config.LoadRoot()
+			if err != nil {
+				return err
+			}
+
+			if unset {
+				root.ActiveContext = ""
+			} else {
+				if _, ok := root.Contexts[args[0]]; !ok {
+					return fmt.Errorf("上下文 '%s' 不存在", args[0])
+				}
+				root.ActiveContext = args[0]
+			}
+			if err := config.Save(root); err != nil {
+				return fmt.Errorf("保存配置失败: %v", err)
+			}
+
+			if unset {
+				fmt.Println("已取消当前上下文")
+			} else {
+				fmt.Printf("当前上下文: %s\n", args[0])
+			}
+			if env := os.Getenv(config.ContextEnv); env != "" {
+				fmt.Printf("注意: 环境变量 %s=%s 优先于保存的当前上下文\n", config.ContextEnv, env)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&unset, "unset", false, "取消当前上下文")
+	return cmd
+}
+
+func newCurrentContextCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "current-context",
+		Short: "显示当前上下文",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name, _, err := config.CurrentContext()
+			if err != nil {
+				return err
+			}
+			if name == "" {
+				return fmt.Errorf("未设置当前上下文")
+			}
+			fmt.Println(name)
+			return nil
+		},
+	}
+}
+
+func newDeleteContextCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete-context NAME",
+		Short: "删除上下文 (独立配置文件会保留)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root, err := config.LoadRoot()
+			if err != nil {
+				return err
+			}
+			ctx, ok := root.Contexts[args[0]]
+			if !ok {
+				return fmt.Errorf("上下文 '%s' 不存在", args[0])
+			}
+			delete(root.Contexts, args[0])
+			if root.ActiveContext == args[0] {
+				root.ActiveContext = ""
+			}
+			if err := config.Save(root); err != nil {
+				return fmt.Errorf("保存配置失败: %v", err)
+			}
+
+			fmt.Printf("已删除上下文 %s\n", args[0])
+			if ctx.File != "" {
+				fmt.Printf("独立配置文件未删除: %s\n", ctx.FilePath())
+			}
+			return nil
+		},
+	}
+}
+
+// ContextRecord is the ContextList item of structured output.
+type ContextRecord struct {
+	Name           string `json:"name"`
+	Current        bool   `json:"current"`
+	Group          string `json:"group"`
+	AdminEnv       string `json:"admin_env"`
+	MaxConcurrency int    `json:"max_concurrency"`
+	ConnectTimeout int    `json:"connect_timeout"`
+	File           string `json:"file"`
+	Filter         string `json:"filter"`
+}
+
+func newGetContextsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get-contexts",
+		Short: "列出上下文",
+		Long:  contextHelp,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root, err := config.LoadRoot()
+			if err != nil {
+				return err
+			}
+			current, _, _ := config.CurrentContext()
+
+			names := make([]string, 0, len(root.Contexts))
+			for name := range root.Contexts {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			records := make([]ContextRecord, 0, len(names))
+			for _, name := range names {
+				ctx := root.Contexts[name]
+				r := ContextRecord{
+					Name:           name,
+					Current:        name == current,
+					Group:          ctx.Group,
+					AdminEnv:       ctx.AdminEnv,
+					MaxConcurrency: ctx.MaxConcurrency,
+					ConnectTimeout: ctx.ConnectTimeout,
+					Filter:         describeFilter(ctx.Filter),
+				}
+				if ctx.File != "" {
+					r.File = ctx.FilePath()
+				}
+				records = append(records, r)
+			}
+
+			if output.Structured() {
+				return output.Print("ContextList", records)
+			}
+			if len(records) == 0 {
+				fmt.Println("尚未创建上下文 (使用 jpy config set-context 创建)")
+				return nil
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "当前\t名称\t分组\t后台环境\t并发\t超时\t默认筛选\t配置文件")
+			for _, r := range records {
+				mark := ""
+				if r.Current {
+					mark = "*"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", mark, r.Name, dash(r.Group), dash(r.AdminEnv),
+					dashInt(r.MaxConcurrency), dashInt(r.ConnectTimeout), dash(r.Filter), dash(r.File))
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func describeFilter(f *config.DeviceFilter) string {
+	if f == nil {
+		return ""
+	}
+	var parts []string
+	if f.Server != "" {
+		parts = append(parts, "server="+f.Server)
+	}
+	for _, l := range f.Labels {
+		parts = append(parts, "label="+l)
+	}
+	if f.Where != "" {
+		parts = append(parts, "where="+f.Where)
+	}
+	return strings.Join(parts, " ")
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func dashInt(n int) string {
+	if n == 0 {
+		return "-"
+	}
+	return strconv.Itoa(n)
+}
@@ -0,0 +1,170 @@
+package config_cmd
+
+import (
+	"bytes"
+	"fmt"
+	"jpy-cli/pkg/config"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+const keySourcesHelp = `密码来源 (按优先级):
+  1. 环境变量 JPY_CONFIG_PASSPHRASE
+  2. 密钥文件: JPY_CONFIG_KEY_FILE 或加密时记录的 --key-file
+  3. 终端输入`
+
+func newEncryptCmd() *cobra.Command {
+	var keyFile string
+	cmd := &cobra.Command{
+		Use:   "encrypt",
+		Short: "加密保存 config.json 中的密码与令牌",
+		Long: `使用 AES-256-GCM 加密 config.json 中的服务器密码、令牌及后台/DHCP 令牌，密钥由 scrypt 从密码派生。
+
+` + keySourcesHelp,
+		Example: `  jpy config encrypt
+  jpy config encrypt --key-file /secure/jpy.key`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Load()
+			if err != nil {
+				return err
+			}
+			if cfg.Encryption != nil {
+				return fmt.Errorf("配置已加密，如需更换密码请使用 jpy config rekey")
+			}
+
+			if keyFile, err = absKeyFile(keyFile); err != nil {
+				return err
+			}
+			passphrase, err := newPassphrase(keyFile)
+			if err != nil {
+				return err
+			}
+			if cfg.Encryption, err = config.NewEncryption(passphrase, keyFile); err != nil {
+				return err
+			}
+			if err := config.Save(cfg); err != nil {
+				return err
+			}
+			fmt.Printf("配置已加密: %s (未加密的历史备份已清除)\n", config.GetConfigPath())
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&keyFile, "key-file", "", "从文件读取密码，并记录该路径供后续自动读取")
+	return cmd
+}
+
+func newDecryptCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "decrypt",
+		Short: "解除 config.json 加密，以明文保存",
+		Long:  "解密 config.json 中的密码与令牌并以明文保存 (文件权限保持 0600)。\n\n" + keySourcesHelp,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Load()
+			if err != nil {
+				return err
+			}
+			if cfg.Encryption == nil {
+				return fmt.Errorf("配置未加密")
+			}
+
+			cfg.Encryption = nil
+			if err := config.Save(cfg); err != nil {
+				return err
+			}
+			fmt.Printf("配置已解密: %s (加密的历史备份已清除)\n", config.GetConfigPath())
+			return nil
+		},
+	}
+}
+
+func newRekeyCmd() *cobra.Command {
+	var keyFile string
+	cmd := &cobra.Command{
+		Use:   "rekey",
+		Short: "更换配置加密密码",
+		Long: `使用当前密码解密后，以新密码和新的盐值重新加密。
+
+当前密码的来源同 encrypt；新密码依次取自 --key-file、JPY_CONFIG_NEW_PASSPHRASE 或终端输入。`,
+		Example: `  jpy config rekey
+  JPY_CONFIG_PASSPHRASE=old JPY_CONFIG_NEW_PASSPHRASE=new jpy config rekey`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Load()
+			if err != nil {
+				return err
+			}
+			if cfg.Encryption == nil {
+				return fmt.Errorf("配置未加密，请使用 jpy config encrypt")
+			}
+
+			if keyFile, err = absKeyFile(keyFile); err != nil {
+				return err
+			}
+			var passphrase []byte
+			switch {
+			case keyFile != "":
+				passphrase, err = config.ReadKeyFile(keyFile)
+			case os.Getenv("JPY_CONFIG_NEW_PASSPHRASE") != "":
+				passphrase = []byte(os.Getenv("JPY_CONFIG_NEW_PASSPHRASE"))
+			default:
+				passphrase, err = promptNewPassphrase()
+			}
+			if err != nil {
+				return err
+			}
+
+			if cfg.Encryption, err = config.NewEncryption(passphrase, keyFile); err != nil {
+				return err
+			}
+			if err := config.Save(cfg); err != nil {
+				return err
+			}
+			fmt.Println("配置已使用新密码重新加密，旧密码加密的历史备份已清除。")
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&keyFile, "key-file", "", "从文件读取新密码，并记录该路径供后续自动读取")
+	return cmd
+}
+
+// newPassphrase returns the passphrase for a fresh encryption: the key file,
+// JPY_CONFIG_PASSPHRASE, or a confirmed terminal entry.
+func newPassphrase(keyFile string) ([]byte, error) {
+	if keyFile != "" {
+		return config.ReadKeyFile(keyFile)
+	}
+	if p := os.Getenv("JPY_CONFIG_PASSPHRASE"); p != "" {
+		return []byte(p), nil
+	}
+	return promptNewPassphrase()
+}
+
+// absKeyFile makes the recorded key file path independent of the working directory.
+func absKeyFile(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	return filepath.Abs(path)
+}
+
+func promptNewPassphrase() ([]byte, error) {
+	if config.PromptPassphrase == nil {
+		return nil, config.ErrNoPassphrase
+	}
+	first, err := config.PromptPassphrase("新密码: ")
+	if err != nil {
+		return nil, err
+	}
+	second, err := config.PromptPassphrase("确认新密码: ")
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(first, second) {
+		return nil, fmt.Errorf("两次输入的密码不一致")
+	}
+	if len(first) == 0 {
+		return nil, fmt.Errorf("密码不能为空")
+	}
+	return first, nil
+}
@@ -0,0 +1,54 @@
+package config_cmd
+
+import (
+	"fmt"
+	"jpy-cli/pkg/config"
+	"jpy-cli/pkg/output"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+func newRestoreCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "restore [BACKUP]",
+		Short: "列出或恢复配置文件的历史版本",
+		Long: `每次保存配置前会将旧版本备份到配置目录下的 backups/ 目录，默认保留最近 10 个版本
+(可通过 jpy config set config_backups N 修改，负数表示不备份)。
+
+不带参数时列出当前上下文配置文件的备份；指定备份名称时恢复该版本，恢复前的配置同样会被备份。`,
+		Example: `  jpy config restore
+  jpy config restore config-20260101-120000.000.json`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := config.GetConfigPath()
+			if len(args) == 1 {
+				if err := config.RestoreBackup(path, args[0]); err != nil {
+					return err
+				}
+				fmt.Printf("已从 %s 恢复 %s\n", args[0], path)
+				return nil
+			}
+
+			backups, err := config.ListBackups(path)
+			if err != nil {
+				return err
+			}
+			if output.Structured() {
+				return output.Print("BackupList", backups)
+			}
+			if len(backups) == 0 {
+				fmt.Printf("%s 暂无备份\n", path)
+				return nil
+			}
+			fmt.Printf("%s 的备份 (%s):\n", path, config.BackupDir(path))
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "名称\t时间\t大小")
+			for _, b := range backups {
+				fmt.Fprintf(w, "%s\t%s\t%d\n", b.Name, b.Time.Format("2006-01-02 15:04:05"), b.Size)
+			}
+			return w.Flush()
+		},
+	}
+}
@@ -3,8 +3,6 @@ package config_cmd
 import (
 	"fmt"
 	"jpy-cli/pkg/config"
-	"reflect"
-	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -14,25 +12,43 @@ import (
 func NewConfigCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "config",
-		Short: "管理 jpy-config.yaml 配置参数",
+		Short: "管理 jpy-config.yaml 配置参数、上下文及凭据加密",
 	}
 
 	cmd.AddCommand(newGetCmd())
 	cmd.AddCommand(newSetCmd())
 	cmd.AddCommand(newListCmd())
+	cmd.AddCommand(newEncryptCmd())
+	cmd.AddCommand(newDecryptCmd())
+	cmd.AddCommand(newRekeyCmd())
+	cmd.AddCommand(newSetContextCmd())
+	cmd.AddCommand(newUseContextCmd())
+	cmd.AddCommand(newCurrentContextCmd())
+	cmd.AddCommand(newGetContextsCmd())
+	cmd.AddCommand(newDeleteContextCmd())
+	cmd.AddCommand(newRestoreCmd())
+	cmd.AddCommand(newValidateCmd())
 
 	return cmd
 }
 
 func newListCmd() *cobra.Command {
-	return &cobra.Command{
+	var effective bool
+	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "列出所有配置参数",
+		Long:  "列出 ~/.jpy/config.yaml 中保存的配置参数。\n\n" + overridesHelp(),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if effective {
+				data, _ := yaml.Marshal(config.GlobalSettings)
+				fmt.Println(string(data))
+				return nil
+			}
+
 			// Reload from file to show persisted config
-			cfg := loadRawConfig()
-			if cfg == nil {
-				return fmt.Errorf("无法加载配置")
+			cfg, err := config.ReadSettingsFile()
+			if err != nil {
+				return err
 			}
 			
 			data, _ := yaml.Marshal(cfg)
@@ -40,6 +56,18 @@ func newListCmd() *cobra.Command {
 			return nil
 		},
 	}
+	cmd.Flags().BoolVar(&effective, "effective", false, "显示合并默认值、上下文、环境变量与命令行参数后的生效值")
+	return cmd
+}
+
+// overridesHelp describes the precedence of settings and their environment variables.
+func overridesHelp() string {
+	var b strings.Builder
+	b.WriteString("优先级: 默认值 < config.yaml < 当前上下文 < 环境变量 < 全局参数 (--log-level, --concurrency, --timeout, --request-timeout)。\n环境变量:\n")
+	for _, key := range config.SettingKeys() {
+		fmt.Fprintf(&b, "  %-24s %s\n", config.SettingEnv(key), key)
+	}
+	return strings.TrimRight(b.String(), "\n")
 }
 
 func newGetCmd() *cobra.Command {
@@ -49,12 +77,12 @@ func newGetCmd() *cobra.Command {
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			key := args[0]
-			cfg := loadRawConfig()
-			if cfg == nil {
-				return fmt.Errorf("无法加载配置")
+			cfg, err := config.ReadSettingsFile()
+			if err != nil {
+				return err
 			}
 
-			val, found := getField(cfg, key)
+			val, found := config.GetSetting(cfg, key)
 			if !found {
 				return fmt.Errorf("配置项不存在: %s", key)
 			}
@@ -73,12 +101,12 @@ func newSetCmd() *cobra.Command {
 			key := args[0]
 			valStr := args[1]
 
-			cfg := loadRawConfig()
-			if cfg == nil {
-				return fmt.Errorf("无法加载配置")
+			cfg, err := config.ReadSettingsFile()
+			if err != nil {
+				return err
 			}
 
-			if err := setField(cfg, key, valStr); err != nil {
+			if err := config.SetSetting(cfg, key, valStr); err != nil {
 				return err
 			}
 
@@ -86,65 +114,3 @@ func newSetCmd() *cobra.Command {
 		},
 	}
 }
-
-// Helper to interact with Settings struct dynamically
-func getField(s *config.Settings, key string) (interface{}, bool) {
-	v := reflect.ValueOf(s).Elem()
-	t := v.Type()
-
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		tag := field.Tag.Get("yaml")
-		// remove options like ",omitempty"
-		tagName := strings.Split(tag, ",")[0]
-		if tagName == key {
-			return v.Field(i).Interface(), true
-		}
-	}
-	return nil, false
-}
-
-func setField(s *config.Settings, key string, valStr string) error {
-	v := reflect.ValueOf(s).Elem()
-	t := v.Type()
-
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		tag := field.Tag.Get("yaml")
-		tagName := strings.Split(tag, ",")[0]
-		if tagName == key {
-			f := v.Field(i)
-			switch f.Kind() {
-			case reflect.String:
-				f.SetString(valStr)
-			case reflect.Int:
-				intVal, err := strconv.Atoi(valStr)
-				if err != nil {
-					return fmt.Errorf("无效的整数值: %s", valStr)
-				}
-				f.SetInt(int64(intVal))
-			case reflect.Bool:
-				boolVal, err := strconv.ParseBool(valStr)
-				if err != nil {
-					return fmt.Errorf("无效的布尔值: %s", valStr)
-				}
-				f.SetBool(boolVal)
-			default:
-				return fmt.Errorf("不支持的类型: %s", f.Kind())
-			}
-			return nil
-		}
-	}
-	return fmt.Errorf("配置项不存在: %s", key)
-}
-
-func loadRawConfig() *config.Settings {
-	// Re-implement load just for settings to avoid circular deps or complex logic
-	// But ideally we should use pkg/config helpers.
-	// pkg/config/store.go handles 'Config' struct (servers), but root.go handles 'Settings' (jpy-config.yaml).
-	// This separation is a bit messy. 
-	// The user wants to manage jpy-config.yaml params.
-	// We need a helper in pkg/config to load/save Settings specifically.
-	
-	return config.LoadSettings()
-}
@@ -0,0 +1,77 @@
+package config_cmd
+
+import (
+	"fmt"
+	"jpy-cli/pkg/config"
+	"jpy-cli/pkg/output"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func newValidateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate",
+		Short: "检查配置文件中的未知配置项与无效值",
+		Long: `检查以下内容并报告问题，存在错误时以非零状态退出 (警告不影响退出状态):
+  - ~/.jpy/config.yaml: 语法、未知配置项、无效的值
+  - JPY_* 环境变量覆盖的配置项
+  - config.json 及各上下文的独立配置文件: 语法、未知字段、版本、分组与上下文引用
+
+不会解密配置，无需提供加密密码。`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var issues []config.Issue
+			if path, err := config.SettingsPath(); err == nil {
+				issues = append(issues, config.ValidateSettingsFile(path)...)
+			}
+			issues = append(issues, config.ValidateSettingsEnv()...)
+
+			for _, path := range configFiles() {
+				issues = append(issues, config.ValidateConfigFile(path)...)
+			}
+
+			errCount := 0
+			for _, issue := range issues {
+				if !issue.Warning {
+					errCount++
+				}
+			}
+
+			if output.Structured() {
+				if err := output.Print("ValidationIssueList", issues); err != nil {
+					return err
+				}
+			} else if len(issues) == 0 {
+				fmt.Println("配置有效")
+			} else {
+				for _, issue := range issues {
+					level := "错误"
+					if issue.Warning {
+						level = "警告"
+					}
+					key := ""
+					if issue.Key != "" {
+						key = " " + issue.Key
+					}
+					fmt.Printf("%s %s%s: %s\n", level, issue.File, key, issue.Message)
+				}
+			}
+
+			if errCount > 0 {
+				return fmt.Errorf("发现 %d 个错误", errCount)
+			}
+			return nil
+		},
+	}
+}
+
+// configFiles returns config.json and the existing separate files of contexts.
+func configFiles() []string {
+	files := []string{config.RootConfigPath()}
+	for _, path := range config.ContextFiles() {
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, path)
+		}
+	}
+	return files
+}
@@ -2,19 +2,18 @@ package admin
 
 import (
 	"fmt"
+	"os"
 	"sort"
 	"strings"
-	"sync"
-	"time"
 
+	"jpy-cli/internal/cmd/admin/login"
 	"jpy-cli/pkg/admin-middleware/api"
-	adminModel "jpy-cli/pkg/admin-middleware/model"
 	"jpy-cli/pkg/admin-middleware/service"
-	httpclient "jpy-cli/pkg/client/http"
 	"jpy-cli/pkg/config"
-	"jpy-cli/pkg/logger"
 	"jpy-cli/pkg/middleware/device/selector"
+	"jpy-cli/pkg/middleware/license"
 	deviceModel "jpy-cli/pkg/middleware/model"
+	"jpy-cli/pkg/tui"
 
 	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/viewport"
@@ -62,72 +61,53 @@ func NewUpdateClusterCmd() *cobra.Command {
 
 			// 2. Scan and Filter Servers
 			fmt.Println("正在扫描服务器状态...")
+			pattern, err := selector.ParseServerPattern(opts.ServerPattern)
+			if err != nil {
+				return err
+			}
 			servers := config.GetGroupServers(cfg, targetGroup)
-			var candidates []candidateServer
-			var mu sync.Mutex
-			var wg sync.WaitGroup
-
-			sem := make(chan struct{}, 10) // Concurrency 10
-
+			var targets []config.LocalServerConfig
 			for _, s := range servers {
 				if s.Disabled {
 					continue
 				}
-				if !selector.MatchServerPattern(s.URL, opts.ServerPattern) {
+				if !pattern.Match(s.URL) {
+					continue
+				}
+				targets = append(targets, s)
+			}
+
+			svc := license.NewService(cfg)
+			var candidates []candidateServer
+			for _, c := range svc.Check(targets) {
+				if c.Error != nil {
+					continue // Skip offline servers
+				}
+
+				// Filter by Authorized flag
+				isAuthorized := c.Status == license.StatusAuthorized
+				if opts.Authorized == "true" && !isAuthorized {
+					continue
+				}
+				if opts.Authorized == "false" && isAuthorized {
+					continue
+				}
+
+				// Check if update is needed
+				if c.License.C == targetAddr && !opts.Force {
+					continue // Already matches
+				}
+
+				// Check SN validity for admin search
+				if c.License.Sn == "" {
 					continue
 				}
 
-				wg.Add(1)
-				go func(server config.LocalServerConfig) {
-					defer wg.Done()
-					sem <- struct{}{}
-					defer func() { <-sem }()
-
-					client := httpclient.NewClient(server.URL, server.Token)
-					lic, err := client.GetLicense()
-					if err != nil {
-						// Try relogin once
-						newToken, loginErr := client.Login(server.Username, server.Password)
-						if loginErr == nil {
-							server.Token = newToken
-							config.UpdateServer(cfg, server)
-							client.Token = newToken
-							lic, err = client.GetLicense()
-						}
-					}
-
-					if err != nil {
-						return // Skip offline servers
-					}
-
-					// Filter by Authorized flag
-					isAuthorized := lic.Status == 1 || lic.S
-					if opts.Authorized == "true" && !isAuthorized {
-						return
-					}
-					if opts.Authorized == "false" && isAuthorized {
-						return
-					}
-
-					// Check if update is needed
-					if lic.C == targetAddr && !opts.Force {
-						return // Already matches
-					}
-
-					// Check SN validity for admin search
-					if lic.Sn == "" {
-						return
-					}
-
-					mu.Lock()
-					candidates = append(candidates, candidateServer{
-						Server:  server,
-						License: lic,
-					})
-					mu.Unlock()
-				}(s)
+				candidates = append(candidates, candidateServer{
+					Server:  c.Server,
+					License: c.License,
+				})
 			}
-			wg.Wait()
 
 			if len(candidates) == 0 {
 				fmt.Println("没有发现需要更新的服务器。")
@@ -146,10 +126,14 @@ func NewUpdateClusterCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			adminClient := api.NewClient(adminCfg.Token)
+			adminClient := service.NewClient(adminCfg)
 
-			// 4. Start TUI
-			p := tea.NewProgram(newModel(candidates, targetAddr, adminClient, opts.Force))
+			// 4. Start TUI, or log plain lines without a terminal
+			if !tui.Interactive() {
+				runUpdatePlain(svc, candidates, targetAddr, adminClient, opts.Force)
+				return nil
+			}
+			p := tea.NewProgram(newModel(svc, candidates, targetAddr, adminClient, opts.Force))
 			if _, err := p.Run(); err != nil {
 				return err
 			}
@@ -159,9 +143,10 @@ func NewUpdateClusterCmd() *cobra.Command {
 	}
 
 	cmd.Flags().StringVar(&opts.Group, "group", "", "指定服务器分组")
-	cmd.Flags().StringVar(&opts.ServerPattern, "server", "", "筛选服务器地址 (支持正则/模糊匹配，多条件用|分隔)")
+	cmd.Flags().StringVar(&opts.ServerPattern, "server", "", "筛选服务器地址 (子串/通配符/re:正则/CIDR/端口范围，多条件用|分隔，!前缀排除)")
 	cmd.Flags().StringVar(&opts.Authorized, "authorized", "", "筛选授权状态 (true/false)")
 	cmd.Flags().BoolVar(&opts.Force, "force", false, "强制更新（即使地址一致也重新提交）")
+	login.AddLoginFlags(cmd)
 
 	return cmd
 }
@@ -178,6 +163,7 @@ type failureDetail struct {
 
 // TUI Model
 type updateClusterModel struct {
+	svc          *license.Service
 	candidates   []candidateServer
 	targetAddr   string
 	adminClient  *api.Client
@@ -190,7 +176,6 @@ type updateClusterModel struct {
 	height       int
 	done         bool
 	successCount int
-	failureCount int
 	failures     []failureDetail
 }
 
@@ -211,9 +196,10 @@ type updateNextMsg struct {
 	step  int
 }
 
-func newModel(candidates []candidateServer, targetAddr string, adminClient *api.Client, force bool) updateClusterModel {
+func newModel(svc *license.Service, candidates []candidateServer, targetAddr string, adminClient *api.Client, force bool) updateClusterModel {
 	p := progress.New(progress.WithDefaultGradient())
 	return updateClusterModel{
+		svc:         svc,
 		candidates:  candidates,
 		targetAddr:  targetAddr,
 		adminClient: adminClient,
@@ -224,8 +210,7 @@ func newModel(candidates []candidateServer, targetAddr string, adminClient *api.
 }
 
 func (m updateClusterModel) Init() tea.Cmd {
-	// Start with single worker to avoid concurrency issues with middleware reauthorization
-	return processNext(m.candidates, m.targetAddr, m.adminClient, 0, 1, m.force)
+	return processNext(m.svc, m.candidates, m.targetAddr, m.adminClient, 0, 1, m.force)
 }
 
 func (m updateClusterModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -260,12 +245,11 @@ func (m updateClusterModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.done = true
 		return m, tea.Quit
 	case updateNextMsg:
-		return m, processNext(m.candidates, m.targetAddr, m.adminClient, msg.index, msg.step, m.force)
+		return m, processNext(m.svc, m.candidates, m.targetAddr, m.adminClient, msg.index, msg.step, m.force)
 	case stepResultMsg:
 		if msg.success {
 			m.successCount++
 		} else {
-			m.failureCount++
 			m.failures = append(m.failures, failureDetail{Address: msg.failedServer, Reason: msg.errorMsg})
 		}
 
@@ -292,17 +276,7 @@ func (m updateClusterModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m updateClusterModel) View() string {
 	if m.done {
-		summary := fmt.Sprintf("\n更新完成！共处理 %d 台服务器。\n", len(m.candidates))
-		summary += fmt.Sprintf("✅ 成功: %d\n", m.successCount)
-		summary += fmt.Sprintf("❌ 失败: %d\n", m.failureCount)
-
-		if len(m.failures) > 0 {
-			summary += "\n失败详情:\n"
-			for _, f := range m.failures {
-				summary += fmt.Sprintf("- %s: %s\n", f.Address, f.Reason)
-			}
-		}
-		return summary
+		return updateSummary(len(m.candidates), m.successCount, m.failures)
 	}
 
 	pad := strings.Repeat(" ", padding)
@@ -317,113 +291,62 @@ var (
 	helpStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#626262")).Render
 )
 
-func processNext(candidates []candidateServer, targetAddr string, adminClient *api.Client, index int, step int, force bool) tea.Cmd {
+func processNext(svc *license.Service, candidates []candidateServer, targetAddr string, adminClient *api.Client, index int, step int, force bool) tea.Cmd {
 	return func() tea.Msg {
 		if index >= len(candidates) {
 			return doneMsg{}
 		}
 
 		c := candidates[index]
-		server := c.Server
-		sn := c.License.Sn
-
-		var logs []string
-		// Compact log: single line per server
-		// logs = append(logs, fmt.Sprintf("正在处理: %s (SN: %s)", server.URL, sn))
-
-		var err error
-		var actionStatus string // "updated", "skipped", "failed"
-		maxRetries := 3
-
-		for attempt := 1; attempt <= maxRetries; attempt++ {
-			err = func() error {
-				// Always check Admin Auth first because candidates are here implies lic.C != targetAddr or Force is true
-				logger.Info(fmt.Sprintf("[AUDIT] Checking Admin Auth for SN: %s (Server: %s)", sn, server.URL))
-
-				// 1. Get Auth Info from Admin
-				authItem, err := adminClient.GetAuthBySN(sn)
-				if err != nil {
-					return fmt.Errorf("获取授权信息失败: %v", err)
-				}
+		line, errorMsg := updateOne(svc, c, targetAddr, adminClient, force)
 
-				// Optimization: Check if Admin Backend is already correct
-				if authItem.MgtCenter == targetAddr && !force {
-					logger.Info(fmt.Sprintf("[AUDIT] SN: %s - Admin MgtCenter already matches target (%s). Skipping Admin Update.", sn, targetAddr))
-					// We skip Admin Update, but MUST continue to Reauthorize Middleware because candidates here have mismatched C.
-					actionStatus = "skipped"
-				} else {
-					// 2. Update Auth Info
-					payload := adminModel.AuthCodePayload{
-						ID:           authItem.ID,
-						Supervise:    authItem.Supervise,
-						Type:         authItem.Type,
-						Name:         authItem.Name,
-						SerialNumber: authItem.SerialNumber,
-						Title:        authItem.Title,
-						MgtCenter:    targetAddr, // Update target
-						Limit:        authItem.Limit,
-						Day:          authItem.Day,
-						Desc:         authItem.Desc,
-					}
-
-					if force && authItem.MgtCenter == targetAddr {
-						logger.Info(fmt.Sprintf("[AUDIT] SN: %s - Admin MgtCenter matches target (%s) but FORCE is enabled. Updating anyway.", sn, targetAddr))
-					}
-
-					logger.Info(fmt.Sprintf("[AUDIT] SN: %s - Updating Admin MgtCenter to %s (Old: %s)", sn, targetAddr, authItem.MgtCenter))
-
-					if err := adminClient.UpdateAuth(payload); err != nil {
-						return fmt.Errorf("更新授权失败: %v", err)
-					}
-					actionStatus = "updated"
-				}
-
-				// 3. Reauthorize Middleware (ALWAYS perform this step if we are here)
-				// We are here because lic.C != targetAddr or Force is true, so we must force the middleware to refresh.
-				logger.Info(fmt.Sprintf("[AUDIT] SN: %s - Reauthorizing Middleware at %s", sn, server.URL))
-				middlewareClient := httpclient.NewClient(server.URL, server.Token)
-				if err := middlewareClient.Reauthorize(sn); err != nil {
-					return fmt.Errorf("中间件重新授权失败: %v", err)
-				}
-
-				return nil
-			}()
-
-			if err == nil {
-				break
-			}
-
-			if attempt < maxRetries {
-				// logs = append(logs, fmt.Sprintf("⚠️ %s 操作失败，正在重试 (%d/%d): %v", server.URL, attempt, maxRetries, err))
-				time.Sleep(2 * time.Second)
-			}
+		return stepResultMsg{
+			logs:         []string{line},
+			nextIndex:    index + step,
+			step:         step,
+			success:      errorMsg == "",
+			failedServer: c.Server.URL,
+			errorMsg:     errorMsg,
 		}
+	}
+}
 
-		success := err == nil
-		var errorMsg string
-		if err != nil {
-			errorMsg = err.Error()
-			logs = append(logs, fmt.Sprintf("❌ %s 失败: %v", server.URL, err))
-			logger.Info(fmt.Sprintf("[AUDIT] SN: %s - Update failed: %v", sn, err))
-		} else {
-			if actionStatus == "skipped" {
-				logs = append(logs, fmt.Sprintf("✅ %s 重新授权成功 (后台地址已一致)", server.URL))
-			} else {
-				logs = append(logs, fmt.Sprintf("✅ %s 重新授权成功 (后台地址已更新)", server.URL))
-			}
+// updateOne re-authorizes one server and returns its log line and, on failure, the reason.
+func updateOne(svc *license.Service, c candidateServer, targetAddr string, adminClient *api.Client, force bool) (string, string) {
+	res := svc.UpdateCluster(adminClient, c.Server, c.License.Sn, targetAddr, force)
+	switch {
+	case res.Error != nil:
+		return fmt.Sprintf("❌ %s 失败: %v", c.Server.URL, res.Error), res.Error.Error()
+	case res.AdminUpdated:
+		return fmt.Sprintf("✅ %s 重新授权成功 (后台地址已更新)", c.Server.URL), ""
+	default:
+		return fmt.Sprintf("✅ %s 重新授权成功 (后台地址已一致)", c.Server.URL), ""
+	}
+}
+
+// runUpdatePlain is the non-TTY counterpart of the TUI: progress goes to stderr, the summary to stdout.
+func runUpdatePlain(svc *license.Service, candidates []candidateServer, targetAddr string, adminClient *api.Client, force bool) {
+	var failures []failureDetail
+	for i, c := range candidates {
+		line, errorMsg := updateOne(svc, c, targetAddr, adminClient, force)
+		fmt.Fprintf(os.Stderr, "[%d/%d] %s\n", i+1, len(candidates), line)
+		if errorMsg != "" {
+			failures = append(failures, failureDetail{Address: c.Server.URL, Reason: errorMsg})
 		}
+	}
+	fmt.Print(updateSummary(len(candidates), len(candidates)-len(failures), failures))
+}
 
-		// Calculate progress - no longer needed here as tracked in model
-		prog := 0.0
+func updateSummary(total, successCount int, failures []failureDetail) string {
+	summary := fmt.Sprintf("\n更新完成！共处理 %d 台服务器。\n", total)
+	summary += fmt.Sprintf("✅ 成功: %d\n", successCount)
+	summary += fmt.Sprintf("❌ 失败: %d\n", len(failures))
 
-		return stepResultMsg{
-			logs:         logs,
-			progress:     prog,
-			nextIndex:    index + step,
-			step:         step,
-			success:      success,
-			failedServer: server.URL,
-			errorMsg:     errorMsg,
+	if len(failures) > 0 {
+		summary += "\n失败详情:\n"
+		for _, f := range failures {
+			summary += fmt.Sprintf("- %s: %s\n", f.Address, f.Reason)
 		}
 	}
+	return summary
 }
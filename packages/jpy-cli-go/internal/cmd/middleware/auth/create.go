@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"fmt"
 	"jpy-cli/pkg/config"
+	"jpy-cli/pkg/tui"
 	"os"
 	"strconv"
 	"strings"
@@ -65,6 +66,10 @@ func runCreateBatch(ipInput, portStr, username, password string) error {
 }
 
 func runCreateInteractive() error {
+	if err := tui.RequirePrompt("请使用 --ip 指定 IP 范围"); err != nil {
+		return err
+	}
+
 	// Load config first to get current group
 	cfg, err := config.Load()
 	if err != nil {
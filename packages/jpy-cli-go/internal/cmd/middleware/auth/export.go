@@ -14,17 +14,22 @@ func NewExportCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "export",
 		Short: "导出当前分组的服务器配置",
+		Long: `导出当前分组的服务器配置，可用 import 重新导入。
+
+默认不导出密码和令牌 (导入时保留已有密码)；需要完整迁移时使用 --include-secrets，文件权限为 0600。`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			outputFile, _ := cmd.Flags().GetString("output")
-			return runExport(outputFile)
+			outputFile, _ := cmd.Flags().GetString("file")
+			includeSecrets, _ := cmd.Flags().GetBool("include-secrets")
+			return runExport(outputFile, includeSecrets)
 		},
 	}
 
-	cmd.Flags().StringP("output", "o", "servers_export.json", "导出文件路径")
+	cmd.Flags().StringP("file", "f", "servers_export.json", "导出文件路径")
+	cmd.Flags().Bool("include-secrets", false, "导出密码和令牌 (明文)")
 	return cmd
 }
 
-func runExport(outputFile string) error {
+func runExport(outputFile string, includeSecrets bool) error {
 	cfg, err := config.Load()
 	if err != nil {
 		return fmt.Errorf("加载配置失败: %v", err)
@@ -54,15 +59,37 @@ func runExport(outputFile string) error {
 	// For "template" purposes (import.go compatible), we need URL, Group, Username, Password.
 	// We can just marshal the struct as it has json tags.
 
+	perm := os.FileMode(0644)
+	if includeSecrets {
+		perm = 0600
+	} else {
+		servers = redactSecrets(servers)
+	}
+
 	jsonData, err := json.MarshalIndent(servers, "", "  ")
 	if err != nil {
 		return fmt.Errorf("序列化 JSON 失败: %v", err)
 	}
 
-	if err := os.WriteFile(absPath, jsonData, 0644); err != nil {
+	// Atomic write so perm also applies when overwriting an existing file
+	if err := config.WriteFileAtomic(absPath, jsonData, perm); err != nil {
 		return fmt.Errorf("写入文件失败: %v", err)
 	}
 
 	fmt.Printf("成功导出 %d 台服务器配置到: %s\n", len(servers), absPath)
+	if !includeSecrets {
+		fmt.Println("已隐藏密码和令牌，如需导出请使用 --include-secrets")
+	}
 	return nil
 }
+
+// redactSecrets returns a copy of servers without passwords and tokens.
+func redactSecrets(servers []config.LocalServerConfig) []config.LocalServerConfig {
+	redacted := make([]config.LocalServerConfig, len(servers))
+	for i, s := range servers {
+		s.Password = ""
+		s.Token = ""
+		redacted[i] = s
+	}
+	return redacted
+}
@@ -4,7 +4,11 @@ import (
 	"fmt"
 	httpclient "jpy-cli/pkg/client/http"
 	"jpy-cli/pkg/config"
+	"jpy-cli/pkg/output"
+	"jpy-cli/pkg/tui"
 	"os"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -42,6 +46,12 @@ func NewListCmd() *cobra.Command {
 
 			if detailsGroup != "" {
 				showGroupDetails(cfg, detailsGroup, concurrency)
+			} else if output.Structured() {
+				if err := output.Print("GroupList", groupRecords(cfg)); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+				}
+			} else if !tui.Interactive() {
+				printGroups(groupRecords(cfg))
 			} else {
 				runTUI(cfg)
 			}
@@ -60,11 +70,11 @@ func showGroupDetails(cfg *config.Config, groupName string, concurrency int) {
 	servers := config.GetGroupServers(cfg, groupName)
 
 	if len(servers) == 0 {
-		fmt.Printf("在分组 '%s' 中未找到服务器\n", groupName)
+		output.Notef("在分组 '%s' 中未找到服务器\n", groupName)
 		return
 	}
 
-	fmt.Printf("正在检查分组 '%s' 中 %d 台服务器的状态 (并发: %d)...\n", groupName, len(servers), concurrency)
+	output.Notef("正在检查分组 '%s' 中 %d 台服务器的状态 (并发: %d)...\n", groupName, len(servers), concurrency)
 
 	results := make(chan config.LocalServerConfig, len(servers))
 	sem := make(chan struct{}, concurrency)
@@ -97,17 +107,42 @@ func showGroupDetails(cfg *config.Config, groupName string, concurrency int) {
 	}
 
 	// Output table header
-	fmt.Printf("%-30s %-15s %-20s %-30s\n", "URL", "用户名", "状态", "最后错误")
-	fmt.Println("----------------------------------------------------------------------------------------------------")
+	structured := output.Structured()
+	if !structured {
+		fmt.Printf("%-30s %-15s %-20s %-30s\n", "URL", "用户名", "状态", "最后错误")
+		fmt.Println("----------------------------------------------------------------------------------------------------")
+	}
 
+	var checked []LoginRecord
 	for s := range results {
 		status := "正常"
 		if s.LastLoginError != "" {
 			status = "失败"
 		}
-		fmt.Printf("%-30s %-15s %-20s %-30s\n", s.URL, s.Username, status, truncate(s.LastLoginError, 30))
+		if structured {
+			checked = append(checked, LoginRecord{
+				URL:       s.URL,
+				Username:  s.Username,
+				OK:        s.LastLoginError == "",
+				Error:     s.LastLoginError,
+				CheckedAt: s.LastLoginTime,
+			})
+		} else {
+			fmt.Printf("%-30s %-15s %-20s %-30s\n", s.URL, s.Username, status, truncate(s.LastLoginError, 30))
+		}
 		serverMap[s.URL] = s
 	}
+	if structured {
+		// Results arrive in completion order; keep the group order instead
+		order := make(map[string]int, len(servers))
+		for i, s := range servers {
+			order[s.URL] = i
+		}
+		sort.Slice(checked, func(i, j int) bool { return order[checked[i].URL] < order[checked[j].URL] })
+		if err := output.Print("LoginCheckList", checked); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
 
 	// Reconstruct config servers list
 	var newServers []config.LocalServerConfig
@@ -122,6 +157,56 @@ func showGroupDetails(cfg *config.Config, groupName string, concurrency int) {
 	config.Save(cfg)
 }
 
+// LoginRecord is the LoginCheckList item of structured output.
+type LoginRecord struct {
+	URL       string `json:"url"`
+	Username  string `json:"username"`
+	OK        bool   `json:"ok"`
+	Error     string `json:"error"`
+	CheckedAt string `json:"checked_at"`
+}
+
+// GroupRecord is the GroupList item of structured output.
+type GroupRecord struct {
+	Name     string `json:"name"`
+	Servers  int    `json:"servers"`
+	Disabled int    `json:"disabled"`
+	Active   bool   `json:"active"`
+}
+
+func groupRecords(cfg *config.Config) []GroupRecord {
+	names := make([]string, 0, len(cfg.Groups))
+	for name := range cfg.Groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	records := make([]GroupRecord, 0, len(names))
+	for _, name := range names {
+		r := GroupRecord{Name: name, Servers: len(cfg.Groups[name]), Active: name == cfg.ActiveGroup}
+		for _, s := range cfg.Groups[name] {
+			if s.Disabled {
+				r.Disabled++
+			}
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+// printGroups is the plain listing used instead of the TUI without a terminal.
+func printGroups(records []GroupRecord) {
+	fmt.Printf("%-20s %-8s %-8s %s\n", "分组", "服务器", "已移除", "当前")
+	fmt.Println(strings.Repeat("-", 50))
+	for _, r := range records {
+		active := ""
+		if r.Active {
+			active = "*"
+		}
+		fmt.Printf("%-20s %-8d %-8d %s\n", r.Name, r.Servers, r.Disabled, active)
+	}
+}
+
 func truncate(s string, max int) string {
 	if len(s) > max {
 		return s[:max-3] + "..."
@@ -12,7 +12,7 @@ func NewSelectCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "select [group]",
 		Short: "选择活动分组",
-		Long:  "选择后续操作的活动分组。如果未指定分组，则列出可用分组。",
+		Long:  "选择后续操作的活动分组。如果未指定分组，则列出可用分组。\n当前上下文指定了分组时，修改的是该上下文的分组。",
 		Args:  cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			cfg, err := config.Load()
@@ -41,6 +41,16 @@ func NewSelectCmd() *cobra.Command {
 				fmt.Printf("警告: 分组 '%s' 尚不存在。添加服务器后将创建该分组。\n", targetGroup)
 			}
 
+			// A context that pins a group would override ActiveGroup, so update the context instead
+			if name, ctx, _ := config.CurrentContext(); ctx != nil && ctx.Group != "" {
+				if err := config.UpdateContext(name, func(c *config.Context) { c.Group = targetGroup }); err != nil {
+					fmt.Printf("保存配置失败: %v\n", err)
+					os.Exit(1)
+				}
+				fmt.Printf("上下文 %s 的活动分组已设置为: %s\n", name, targetGroup)
+				return
+			}
+
 			cfg.ActiveGroup = targetGroup
 			if err := config.Save(cfg); err != nil {
 				fmt.Printf("保存配置失败: %v\n", err)
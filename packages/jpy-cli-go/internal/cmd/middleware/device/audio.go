@@ -0,0 +1,301 @@
+package device
+
+import (
+	"fmt"
+	"jpy-cli/pkg/config"
+	"jpy-cli/pkg/logger"
+	"jpy-cli/pkg/middleware/connector"
+	"jpy-cli/pkg/middleware/device/mirror"
+	"jpy-cli/pkg/middleware/device/selector"
+	"jpy-cli/pkg/middleware/model"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func NewAudioCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audio",
+		Short: "设备音频流",
+	}
+	cmd.AddCommand(newAudioRecordCmd())
+	return cmd
+}
+
+type audioRecordOptions struct {
+	Seconds  int
+	Out      string
+	Codec    string
+	Channels int
+	Audio    mirror.AudioOptions
+}
+
+type audioRecordResult struct {
+	Device model.DeviceInfo
+	Files  []string
+	Bytes  int
+	Codec  string
+	Error  error
+}
+
+func newAudioRecordCmd() *cobra.Command {
+	opts := CommonFlags{}
+	recOpts := audioRecordOptions{Audio: mirror.DefaultAudioOptions()}
+
+	cmd := &cobra.Command{
+		Use:   "record",
+		Short: "录制设备音频",
+		Long: `开启设备音频流 (f=253)，录制指定秒数后关闭 (f=254)。
+
+每台设备都会写入原始音频流 (包含编码头帧)；编码允许时额外写入容器文件：
+  pcm   -> .raw + .wav
+  opus  -> .raw + .ogg
+  aac   -> .aac (ADTS 可直接播放)
+
+--codec auto 识别 AAC 与 Opus 头帧，其余按 PCM 处理；裸 Opus 流需指定 --codec opus。
+多台设备时文件名自动追加 _<服务器>_<机位> 后缀。`,
+		Example: `  jpy middleware device audio record -s 192.168.1.10 --seat 3 --seconds 10 --out ring
+  jpy middleware device audio record -g qa --all --seconds 5 --out tts/check --codec pcm`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if recOpts.Seconds <= 0 {
+				return fmt.Errorf("--seconds 必须大于 0")
+			}
+			if recOpts.Out == "" {
+				return fmt.Errorf("请指定 --out")
+			}
+			switch recOpts.Codec {
+			case "auto", mirror.CodecPCM, mirror.CodecAAC, mirror.CodecOpus:
+			default:
+				return fmt.Errorf("无效编码: %s (auto/pcm/aac/opus)", recOpts.Codec)
+			}
+
+			opts.Interactive = shouldEnterInteractive(cmd, &opts)
+			selOpts, err := opts.ToSelectorOptions()
+			if err != nil {
+				return err
+			}
+			devices, err := selector.SelectDevices(selOpts)
+			if err != nil {
+				return err
+			}
+			if len(devices) == 0 {
+				logger.Warn("没有找到符合条件的设备。")
+				return nil
+			}
+
+			cfg, err := config.Load()
+			if err != nil {
+				return err
+			}
+
+			return runAudioRecord(cfg, devices, recOpts)
+		},
+	}
+
+	AddCommonFlags(cmd, &opts)
+	cmd.Flags().IntVar(&recOpts.Seconds, "seconds", 10, "录制时长 (秒)")
+	cmd.Flags().StringVarP(&recOpts.Out, "out", "o", "", "输出文件路径 (扩展名会被忽略)")
+	cmd.Flags().StringVar(&recOpts.Codec, "codec", "auto", "音频编码: auto/pcm/aac/opus")
+	cmd.Flags().IntVar(&recOpts.Channels, "channels", 2, "声道数 (用于 WAV/OGG 头)")
+	cmd.Flags().IntVar(&recOpts.Audio.SampleRate, "sample-rate", recOpts.Audio.SampleRate, "采样率")
+	cmd.Flags().IntVar(&recOpts.Audio.Bitrate, "bitrate", recOpts.Audio.Bitrate, "码率 (bps)")
+
+	return cmd
+}
+
+func runAudioRecord(cfg *config.Config, devices []model.DeviceInfo, opts audioRecordOptions) error {
+	servers := make(map[string]config.LocalServerConfig)
+	for _, s := range config.GetAllServers(cfg) {
+		servers[s.URL] = s
+	}
+
+	base := strings.TrimSuffix(opts.Out, filepath.Ext(opts.Out))
+	if dir := filepath.Dir(base); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("创建输出目录失败: %v", err)
+		}
+	}
+
+	concurrency := config.GlobalSettings.MaxConcurrency
+	if concurrency <= 0 {
+		concurrency = 5
+	}
+	if concurrency < len(devices) {
+		logger.Infof("设备数 %d 超过并发数 %d，将分批录制", len(devices), concurrency)
+	}
+
+	connService := connector.NewConnectorService(cfg)
+	results := make([]audioRecordResult, len(devices))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	fmt.Printf("正在录制 %d 台设备的音频 (%d 秒)...\n", len(devices), opts.Seconds)
+
+	for i, d := range devices {
+		wg.Add(1)
+		go func(i int, d model.DeviceInfo) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			name := base
+			if len(devices) > 1 {
+				name = fmt.Sprintf("%s_%s_%d", base, audioFileHost(d.ServerURL), d.Seat)
+			}
+
+			res := audioRecordResult{Device: d}
+			server, ok := servers[d.ServerURL]
+			if !ok {
+				res.Error = fmt.Errorf("未找到服务器配置: %s", d.ServerURL)
+			} else {
+				res.Files, res.Bytes, res.Codec, res.Error = recordDeviceAudio(connService, server, d.Seat, name, opts)
+			}
+			results[i] = res
+		}(i, d)
+	}
+	wg.Wait()
+
+	successCount, failCount := 0, 0
+	for _, r := range results {
+		if r.Error != nil {
+			failCount++
+			fmt.Printf("❌ %s 机位 %d: %v\n", r.Device.ServerURL, r.Device.Seat, r.Error)
+			continue
+		}
+		successCount++
+		fmt.Printf("✅ %s 机位 %d: %s, %d 字节 -> %s\n", r.Device.ServerURL, r.Device.Seat, r.Codec, r.Bytes, strings.Join(r.Files, ", "))
+	}
+
+	fmt.Printf("\n录制完成: 成功 %d, 失败 %d\n", successCount, failCount)
+	if failCount > 0 {
+		return fmt.Errorf("%d 台设备录制失败", failCount)
+	}
+	return nil
+}
+
+// recordDeviceAudio records one seat and writes the raw stream plus a container when possible.
+func recordDeviceAudio(connService *connector.ConnectorService, server config.LocalServerConfig, seat int, name string, opts audioRecordOptions) ([]string, int, string, error) {
+	ws, err := connService.ConnectMirror(server, seat)
+	if err != nil {
+		return nil, 0, "", fmt.Errorf("连接镜像通道失败: %v", err)
+	}
+	session := mirror.NewMirrorSession(ws, seat)
+	defer session.Close()
+
+	if err := session.StartAudio(opts.Audio); err != nil {
+		return nil, 0, "", fmt.Errorf("开启音频流失败: %v", err)
+	}
+
+	var frames [][]byte
+	timeout := time.After(time.Duration(opts.Seconds) * time.Second)
+Loop:
+	for {
+		select {
+		case frame := <-session.Audio:
+			frames = append(frames, frame)
+		case <-session.Closed:
+			break Loop
+		case <-timeout:
+			break Loop
+		}
+	}
+
+	if err := session.StopAudio(); err != nil {
+		logger.Warnf("[%s] 机位 %d 关闭音频流失败: %v", server.URL, seat, err)
+	}
+
+	if len(frames) == 0 {
+		return nil, 0, "", fmt.Errorf("未收到音频数据")
+	}
+
+	codec := opts.Codec
+	if codec == "auto" {
+		codec = mirror.DetectAudioCodec(frames[0])
+	}
+
+	rawExt := ".raw"
+	if codec == mirror.CodecAAC {
+		rawExt = ".aac"
+	}
+
+	total := 0
+	files := []string{name + rawExt}
+	if err := writeAudioFile(files[0], func(w *os.File) error {
+		for _, f := range frames {
+			n, err := w.Write(f)
+			total += n
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, 0, codec, err
+	}
+
+	switch codec {
+	case mirror.CodecPCM:
+		path := name + ".wav"
+		err = writeAudioFile(path, func(w *os.File) error {
+			wav, err := mirror.NewWAVWriter(w, opts.Audio.SampleRate, opts.Channels)
+			if err != nil {
+				return err
+			}
+			for _, f := range frames {
+				if _, err := wav.Write(f); err != nil {
+					return err
+				}
+			}
+			return wav.Close()
+		})
+		files = append(files, path)
+	case mirror.CodecOpus:
+		path := name + ".ogg"
+		err = writeAudioFile(path, func(w *os.File) error {
+			ogg, err := mirror.NewOggOpusWriter(w, opts.Audio.SampleRate, opts.Channels)
+			if err != nil {
+				return err
+			}
+			for _, f := range frames {
+				// In-band header packets are regenerated by the Ogg writer; the raw file keeps them
+				if len(f) >= 8 && (string(f[:8]) == "OpusHead" || string(f[:8]) == "OpusTags") {
+					continue
+				}
+				if _, err := ogg.Write(f); err != nil {
+					return err
+				}
+			}
+			return ogg.Close()
+		})
+		files = append(files, path)
+	case mirror.CodecUnknown:
+		codec = "unknown"
+	}
+	return files, total, codec, err
+}
+
+func writeAudioFile(path string, write func(f *os.File) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("创建文件失败: %v", err)
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return fmt.Errorf("写入 %s 失败: %v", path, err)
+	}
+	return f.Close()
+}
+
+// audioFileHost turns a server URL into a file-name friendly host.
+func audioFileHost(serverURL string) string {
+	host := serverURL
+	if u, err := url.Parse(serverURL); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	return strings.ReplaceAll(host, ":", "-")
+}
@@ -40,6 +40,9 @@ func NewUSBCmd() *cobra.Command {
 		Short: "切换USB模式 (host/device)",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if !cmd.Flags().Changed("mode") {
+				if err := tui.RequirePrompt("请使用 --mode host|device 指定 USB 模式"); err != nil {
+					return err
+				}
 				options := []tui.Option{
 					{Label: "Device (USB)", Value: "device"},
 					{Label: "Host (OTG)", Value: "host"},
@@ -85,6 +88,9 @@ func NewADBCmd() *cobra.Command {
 		Short: "控制ADB状态 (开启/关闭)",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if !cmd.Flags().Changed("set") {
+				if err := tui.RequirePrompt("请使用 --set on|off 指定 ADB 状态"); err != nil {
+					return err
+				}
 				options := []tui.Option{
 					{Label: "开启 (ON)", Value: "on"},
 					{Label: "关闭 (OFF)", Value: "off"},
@@ -117,8 +123,8 @@ func NewADBCmd() *cobra.Command {
 			})
 		},
 	}
-	AddCommonFlags(cmd, &opts)
 	cmd.Flags().StringVar(&state, "set", "off", "ADB状态: 'on' 或 'off'")
+	AddCommonFlags(cmd, &opts)
 	return cmd
 }
 
@@ -159,19 +165,54 @@ func shouldEnterInteractive(cmd *cobra.Command, opts *CommonFlags) bool {
 	}
 
 	// 3. 检查是否有任何筛选参数被修改
-	filterFlags := []string{
-		"group", "server", "uuid", "seat",
-		"filter-adb", "filter-usb", "filter-online", "filter-has-ip",
-		"authorized",
+	if HasSelector(cmd) {
+		// 如果提供了筛选条件，则不强制进入交互模式
+		return false
+	}
+
+	// 4. 如果没有筛选条件且没有指定 --all，强制进入交互模式
+	return true
+}
+
+// selectSingleDevice resolves the filters to exactly one device and its server config.
+func selectSingleDevice(opts CommonFlags) (model.DeviceInfo, config.LocalServerConfig, *config.Config, error) {
+	var target model.DeviceInfo
+	var server config.LocalServerConfig
+
+	selOpts, err := opts.ToSelectorOptions()
+	if err != nil {
+		return target, server, nil, err
 	}
 
-	for _, name := range filterFlags {
-		if cmd.Flags().Changed(name) {
-			// 如果提供了筛选条件，则不强制进入交互模式
-			return false
+	devices, err := selector.SelectDevices(selOpts)
+	if err != nil {
+		return target, server, nil, err
+	}
+	if len(devices) == 0 {
+		return target, server, nil, fmt.Errorf("未找到匹配设备")
+	}
+
+	if len(devices) > 1 {
+		selected, err := selector.RunInteractiveSelection(devices)
+		if err != nil {
+			return target, server, nil, err
 		}
+		if len(selected) != 1 {
+			return target, server, nil, fmt.Errorf("只能选择一台设备")
+		}
+		devices = selected
 	}
+	target = devices[0]
 
-	// 4. 如果没有筛选条件且没有指定 --all，强制进入交互模式
-	return true
+	cfg, err := config.Load()
+	if err != nil {
+		return target, server, nil, err
+	}
+
+	for _, s := range config.GetAllServers(cfg) {
+		if s.URL == target.ServerURL {
+			return target, s, cfg, nil
+		}
+	}
+	return target, server, nil, fmt.Errorf("未找到服务器配置: %s", target.ServerURL)
 }
@@ -17,6 +17,13 @@ func NewDeviceCmd() *cobra.Command {
 	cmd.AddCommand(NewUSBCmd())
 	cmd.AddCommand(NewADBCmd())
 	cmd.AddCommand(NewLogCmd())
+	cmd.AddCommand(NewStreamCmd())
+	cmd.AddCommand(NewAudioCmd())
+	cmd.AddCommand(NewScriptCmd())
+	cmd.AddCommand(NewNotifyCmd())
+	cmd.AddCommand(NewIOSCmd())
+	cmd.AddCommand(NewLabelCmd())
+	cmd.AddCommand(NewSetCmd())
 
 	return cmd
 }
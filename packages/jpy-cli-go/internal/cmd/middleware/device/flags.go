@@ -1,7 +1,13 @@
 package device
 
 import (
+	"fmt"
+	"jpy-cli/pkg/config"
+	"jpy-cli/pkg/middleware/device/labels"
+	"jpy-cli/pkg/middleware/device/query"
 	"jpy-cli/pkg/middleware/device/selector"
+	"jpy-cli/pkg/middleware/license"
+	"jpy-cli/pkg/middleware/model"
 
 	"github.com/spf13/cobra"
 )
@@ -19,9 +25,28 @@ type CommonFlags struct {
 	FilterOnline string // "true"/"false"
 	FilterHasIP  string // "true"/"false"
 	FilterUUID   string // "true"/"false"
+	Platform     string // "android"/"ios"
 
 	AuthorizedOnly bool // 仅筛选已授权服务器
 
+	// 授权筛选 (按服务器)
+	LicenseStatus      string // "authorized,unauthorized,unreachable"
+	ClusterContains    string
+	ClusterNotContains string
+	SNGT               string
+	SNLT               string
+	ExpiringWithin     int // 天
+
+	Where string // 查询表达式，见 query 包
+
+	Labels []string // key=value / key!=value / key / !key
+	Set    string   // 命名设备集合
+
+	pinned          []string // 固定集合的设备键，由 --set 解析得到
+	pinnedServers   []string // 固定集合的服务器地址
+	setResolved     bool
+	contextResolved bool
+
 	Interactive bool
 	All         bool // 跳过交互模式并处理所有匹配设备
 }
@@ -29,7 +54,7 @@ type CommonFlags struct {
 // AddCommonFlags 为命令添加统一的筛选参数
 func AddCommonFlags(cmd *cobra.Command, opts *CommonFlags) {
 	cmd.Flags().StringVarP(&opts.Group, "group", "g", "", "目标服务器分组")
-	cmd.Flags().StringVarP(&opts.ServerPattern, "server", "s", "", "服务器地址匹配模式 (例如: 192.168.1)")
+	cmd.Flags().StringVarP(&opts.ServerPattern, "server", "s", "", "服务器地址匹配模式 (例如: 192.168.1、192.168.1[0-2].*、10.0.3.0/24、:8000-8100、re:正则，|分隔，!前缀排除)")
 	cmd.Flags().StringVarP(&opts.UUID, "uuid", "u", "", "设备UUID (模糊匹配)")
 	cmd.Flags().IntVar(&opts.Seat, "seat", -1, "机位号")
 
@@ -38,14 +63,42 @@ func AddCommonFlags(cmd *cobra.Command, opts *CommonFlags) {
 	cmd.Flags().StringVar(&opts.FilterOnline, "filter-online", "", "筛选在线状态 (true/false)")
 	cmd.Flags().StringVar(&opts.FilterHasIP, "filter-has-ip", "", "筛选IP存在状态 (true/false)")
 	cmd.Flags().StringVar(&opts.FilterUUID, "filter-uuid", "", "筛选UUID存在状态 (true/false)")
+	cmd.Flags().StringVar(&opts.Platform, "platform", "", "筛选设备平台 (android/ios)")
 
 	cmd.Flags().BoolVar(&opts.AuthorizedOnly, "authorized", false, "仅筛选已授权服务器")
+	cmd.Flags().StringVar(&opts.LicenseStatus, "license-status", "", "筛选授权状态 (authorized/unauthorized/unreachable，逗号分隔)")
+	cmd.Flags().StringVar(&opts.ClusterContains, "cluster-contains", "", "筛选集控平台地址包含指定字符串的服务器")
+	cmd.Flags().StringVar(&opts.ClusterNotContains, "cluster-not-contains", "", "筛选集控平台地址不包含指定字符串的服务器")
+	cmd.Flags().StringVar(&opts.SNGT, "sn-gt", "", "筛选序列号大于指定值的服务器")
+	cmd.Flags().StringVar(&opts.SNLT, "sn-lt", "", "筛选序列号小于指定值的服务器")
+	cmd.Flags().IntVar(&opts.ExpiringWithin, "expiring-within", 0, "筛选授权已过期或在指定天数内到期的服务器")
+	cmd.Flags().StringVar(&opts.Where, "where", "", `设备查询表达式，例如 'online && !adb && seat between 10 and 40 && (model ~ "SM-" || ip == "")'`)
+	cmd.Flags().StringArrayVar(&opts.Labels, "label", nil, "按标签筛选 (key=value、key!=value、key、!key，可重复)")
+	// adb 命令的 --set 已用于指定开关状态，此时集合参数改名为 --device-set
+	setFlag := "set"
+	if cmd.Flags().Lookup(setFlag) != nil {
+		setFlag = "device-set"
+	}
+	cmd.Flags().StringVar(&opts.Set, setFlag, "", "使用命名设备集合 (见 device set)")
 	cmd.Flags().BoolVarP(&opts.Interactive, "interactive", "i", false, "交互式选择模式")
 	cmd.Flags().BoolVar(&opts.All, "all", false, "跳过交互模式并处理所有匹配设备")
+
+	for _, name := range append(selectorFlagNames, setFlag) {
+		cmd.Flags().SetAnnotation(name, selectorAnnotation, []string{"true"})
+	}
 }
 
 // ToSelectorOptions 将通用Flag转换为Selector选项
 func (opts *CommonFlags) ToSelectorOptions() (selector.SelectionOptions, error) {
+	if err := opts.resolveSet(); err != nil {
+		return selector.SelectionOptions{}, err
+	}
+	if err := opts.resolveContext(); err != nil {
+		return selector.SelectionOptions{}, err
+	}
+	if _, err := selector.ParseServerPattern(opts.ServerPattern); err != nil {
+		return selector.SelectionOptions{}, err
+	}
 	res := selector.SelectionOptions{
 		Group:          opts.Group,
 		ServerPattern:  opts.ServerPattern,
@@ -53,7 +106,32 @@ func (opts *CommonFlags) ToSelectorOptions() (selector.SelectionOptions, error)
 		Seat:           opts.Seat,
 		Interactive:    opts.Interactive,
 		AuthorizedOnly: opts.AuthorizedOnly,
+		License: license.Filter{
+			ClusterContains:    opts.ClusterContains,
+			ClusterNotContains: opts.ClusterNotContains,
+			SNAfter:            opts.SNGT,
+			SNBefore:           opts.SNLT,
+			ExpiringWithin:     opts.ExpiringWithin,
+		},
+	}
+	if opts.LicenseStatus != "" {
+		statuses, err := license.ParseStatuses(opts.LicenseStatus)
+		if err != nil {
+			return res, err
+		}
+		res.License.Statuses = statuses
 	}
+	where, err := query.Parse(opts.Where)
+	if err != nil {
+		return res, err
+	}
+	res.Where = where
+	res.Labels, err = labels.ParseSelectors(opts.Labels)
+	if err != nil {
+		return res, err
+	}
+	res.Pinned = opts.pinned
+	res.Servers = opts.pinnedServers
 
 	if opts.FilterADB != "" {
 		val := opts.FilterADB == "true"
@@ -75,5 +153,149 @@ func (opts *CommonFlags) ToSelectorOptions() (selector.SelectionOptions, error)
 		val := opts.FilterUUID == "true"
 		res.HasUUID = &val
 	}
+	if opts.Platform != "" {
+		p, ok := model.ParsePlatform(opts.Platform)
+		if !ok {
+			return res, fmt.Errorf("无效平台: %s (请使用 'android' 或 'ios')", opts.Platform)
+		}
+		res.Platform = p
+	}
 	return res, nil
 }
+
+// resolveSet 展开 --set: 过滤型集合的条件补充到未指定的参数中 (命令行优先)，
+// 固定集合则限定为其设备列表
+func (opts *CommonFlags) resolveSet() error {
+	if opts.Set == "" || opts.setResolved {
+		return nil
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("无法加载配置: %v", err)
+	}
+	set, ok := cfg.DeviceSets[opts.Set]
+	if !ok {
+		return fmt.Errorf("设备集合 '%s' 不存在", opts.Set)
+	}
+	opts.setResolved = true
+	if set.Filter == nil {
+		opts.pinned = append([]string{}, set.Devices...)
+		if set.Servers != nil {
+			opts.pinnedServers = append([]string{}, set.Servers...)
+		}
+		if opts.Group == "" {
+			opts.Group = set.Group
+		}
+		return nil
+	}
+	opts.Merge(*set.Filter)
+	return nil
+}
+
+// resolveContext 将当前上下文的默认筛选补充到未指定的参数中 (命令行优先)
+func (opts *CommonFlags) resolveContext() error {
+	if opts.contextResolved {
+		return nil
+	}
+	_, ctx, err := config.CurrentContext()
+	if err != nil {
+		return err
+	}
+	opts.contextResolved = true
+	if ctx != nil && ctx.Filter != nil {
+		opts.Merge(*ctx.Filter)
+	}
+	return nil
+}
+
+// Merge 用保存的筛选条件补充未指定的参数
+func (opts *CommonFlags) Merge(f config.DeviceFilter) {
+	fill := func(dst *string, src string) {
+		if *dst == "" {
+			*dst = src
+		}
+	}
+	fill(&opts.Group, f.Group)
+	fill(&opts.ServerPattern, f.Server)
+	fill(&opts.UUID, f.UUID)
+	fill(&opts.FilterADB, f.ADB)
+	fill(&opts.FilterUSB, f.USB)
+	fill(&opts.FilterOnline, f.Online)
+	fill(&opts.FilterHasIP, f.HasIP)
+	fill(&opts.FilterUUID, f.HasUUID)
+	fill(&opts.Platform, f.Platform)
+	fill(&opts.LicenseStatus, f.LicenseStatus)
+	fill(&opts.ClusterContains, f.ClusterContains)
+	fill(&opts.ClusterNotContains, f.ClusterNotContains)
+	fill(&opts.SNGT, f.SNGT)
+	fill(&opts.SNLT, f.SNLT)
+	if opts.Seat < 0 && f.Seat != nil {
+		opts.Seat = *f.Seat
+	}
+	if !opts.AuthorizedOnly {
+		opts.AuthorizedOnly = f.Authorized
+	}
+	if opts.ExpiringWithin <= 0 {
+		opts.ExpiringWithin = f.ExpiringWithin
+	}
+	switch {
+	case opts.Where == "":
+		opts.Where = f.Where
+	case f.Where != "":
+		opts.Where = "(" + f.Where + ") && (" + opts.Where + ")"
+	}
+	opts.Labels = append(append([]string{}, f.Labels...), opts.Labels...)
+}
+
+// Filter 将当前参数保存为可复用的筛选条件
+func (opts *CommonFlags) Filter() *config.DeviceFilter {
+	f := &config.DeviceFilter{
+		Group:              opts.Group,
+		Server:             opts.ServerPattern,
+		UUID:               opts.UUID,
+		ADB:                opts.FilterADB,
+		USB:                opts.FilterUSB,
+		Online:             opts.FilterOnline,
+		HasIP:              opts.FilterHasIP,
+		HasUUID:            opts.FilterUUID,
+		Platform:           opts.Platform,
+		Authorized:         opts.AuthorizedOnly,
+		LicenseStatus:      opts.LicenseStatus,
+		ClusterContains:    opts.ClusterContains,
+		ClusterNotContains: opts.ClusterNotContains,
+		SNGT:               opts.SNGT,
+		SNLT:               opts.SNLT,
+		ExpiringWithin:     opts.ExpiringWithin,
+		Where:              opts.Where,
+		Labels:             opts.Labels,
+	}
+	if opts.Seat > -1 {
+		seat := opts.Seat
+		f.Seat = &seat
+	}
+	return f
+}
+
+const selectorAnnotation = "jpy_selector"
+
+// selectorFlagNames 列出会缩小选择范围的参数 (不含集合参数)，用于判断是否需要进入交互模式
+var selectorFlagNames = []string{
+	"group", "server", "uuid", "seat",
+	"filter-adb", "filter-usb", "filter-online", "filter-has-ip", "filter-uuid",
+	"platform", "authorized", "license-status", "cluster-contains", "cluster-not-contains",
+	"sn-gt", "sn-lt", "expiring-within", "where", "label",
+}
+
+// HasSelector 判断是否指定了任何筛选参数
+func HasSelector(cmd *cobra.Command) bool {
+	for _, name := range append(selectorFlagNames, "set", "device-set") {
+		f := cmd.Flags().Lookup(name)
+		if f == nil || !f.Changed {
+			continue
+		}
+		if _, ok := f.Annotations[selectorAnnotation]; ok {
+			return true
+		}
+	}
+	return false
+}
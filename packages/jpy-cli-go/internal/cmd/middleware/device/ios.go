@@ -0,0 +1,132 @@
+package device
+
+import (
+	"fmt"
+	"jpy-cli/pkg/middleware/device/api"
+	"jpy-cli/pkg/middleware/model"
+
+	"github.com/spf13/cobra"
+)
+
+func NewIOSCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ios",
+		Short: "iOS 设备专用命令",
+		Long: `仅适用于 iOS 设备的命令 (App 管理、语言地区、相册等)。
+
+未指定 --platform 时自动筛选 iOS 设备。`,
+	}
+
+	cmd.AddCommand(newIOSAppsCmd())
+	cmd.AddCommand(newIOSBundleCmd("launch", "启动 App", "启动 App", (*api.IOSDevice).StartApp))
+	cmd.AddCommand(newIOSBundleCmd("kill", "结束 App", "结束 App", (*api.IOSDevice).KillApp))
+	cmd.AddCommand(newIOSBundleCmd("uninstall", "卸载 App", "卸载 App", (*api.IOSDevice).UninstallApp))
+	cmd.AddCommand(newIOSLocaleCmd())
+	cmd.AddCommand(newIOSCopyToPhotosCmd())
+
+	return cmd
+}
+
+// runIOSAction runs fn on the selected iOS devices. Devices of other platforms fail clearly.
+func runIOSAction(cmd *cobra.Command, opts *CommonFlags, jsonOutput bool, op string, fn func(d *api.IOSDevice) (interface{}, error)) error {
+	if opts.Platform == "" {
+		opts.Platform = string(model.PlatformIOS)
+	}
+	return runMirrorAction(cmd, opts, 0, jsonOutput, func(dev api.Device) (interface{}, error) {
+		d, err := api.AsIOS(dev, op)
+		if err != nil {
+			return nil, err
+		}
+		return fn(d)
+	})
+}
+
+func newIOSAppsCmd() *cobra.Command {
+	opts := CommonFlags{}
+	var (
+		appType    string
+		jsonOutput bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "apps",
+		Short: "获取 App 列表",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch appType {
+			case api.AppListAny, api.AppListSystem, api.AppListInternal, api.AppListUser:
+			default:
+				return fmt.Errorf("无效类型: %s (any/system/internal/user)", appType)
+			}
+			return runIOSAction(cmd, &opts, jsonOutput, "App 列表", func(d *api.IOSDevice) (interface{}, error) {
+				return d.ListAppsOfType(appType)
+			})
+		},
+	}
+
+	AddCommonFlags(cmd, &opts)
+	cmd.Flags().StringVar(&appType, "type", api.AppListUser, "App 类型: any/system/internal/user")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "以 JSON 格式输出结果")
+	return cmd
+}
+
+func newIOSBundleCmd(use, short, op string, fn func(d *api.IOSDevice, bundleID string) error) *cobra.Command {
+	opts := CommonFlags{}
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:     use + " <bundleId>",
+		Short:   short,
+		Example: fmt.Sprintf("  jpy middleware device ios %s com.apple.mobilesafari -s 192.168.1.10 --seat 3", use),
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runIOSAction(cmd, &opts, jsonOutput, op, func(d *api.IOSDevice) (interface{}, error) {
+				return nil, fn(d, args[0])
+			})
+		},
+	}
+
+	AddCommonFlags(cmd, &opts)
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "以 JSON 格式输出结果")
+	return cmd
+}
+
+func newIOSLocaleCmd() *cobra.Command {
+	opts := CommonFlags{}
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:     "locale <language> <locale>",
+		Short:   "设置系统语言和地区",
+		Example: `  jpy middleware device ios locale zh-Hans zh_CN -g rack1 --all`,
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runIOSAction(cmd, &opts, jsonOutput, "设置语言地区", func(d *api.IOSDevice) (interface{}, error) {
+				return nil, d.SetLanguageAndLocale(args[0], args[1])
+			})
+		},
+	}
+
+	AddCommonFlags(cmd, &opts)
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "以 JSON 格式输出结果")
+	return cmd
+}
+
+func newIOSCopyToPhotosCmd() *cobra.Command {
+	opts := CommonFlags{}
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   "copy-to-photos <path>",
+		Short: "将设备上的文件拷贝到相册",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runIOSAction(cmd, &opts, jsonOutput, "拷贝到相册", func(d *api.IOSDevice) (interface{}, error) {
+				return nil, d.CopyToPhotos(args[0])
+			})
+		},
+	}
+
+	AddCommonFlags(cmd, &opts)
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "以 JSON 格式输出结果")
+	return cmd
+}
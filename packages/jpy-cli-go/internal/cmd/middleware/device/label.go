@@ -0,0 +1,219 @@
+package device
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"jpy-cli/pkg/config"
+	"jpy-cli/pkg/middleware/device/labels"
+	"jpy-cli/pkg/middleware/device/selector"
+	"jpy-cli/pkg/middleware/model"
+	"jpy-cli/pkg/output"
+
+	"github.com/spf13/cobra"
+)
+
+func NewLabelCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "label",
+		Short: "设备/服务器标签管理",
+		Long: `为设备或服务器打标签，用于按项目等维度分组设备。
+
+设备标签按 UUID 保存，没有 UUID 的设备按 服务器地址#机位 保存；
+设备的有效标签 = 服务器标签 + 设备标签 (设备标签优先)。
+在任意设备命令中使用 --label key=value 按标签筛选。`,
+	}
+	cmd.AddCommand(newLabelAddCmd())
+	cmd.AddCommand(newLabelRmCmd())
+	cmd.AddCommand(newLabelLsCmd())
+	return cmd
+}
+
+func newLabelAddCmd() *cobra.Command {
+	opts := CommonFlags{}
+	var servers bool
+	cmd := &cobra.Command{
+		Use:   "add key=value [key=value...]",
+		Short: "为筛选出的设备 (或 --servers 服务器) 添加标签",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kv, err := labels.ParseAssignments(args)
+			if err != nil {
+				return err
+			}
+			return updateLabels(cmd, &opts, servers, func(cfg *config.Config, target labelTarget) int {
+				if target.server != "" {
+					labels.SetServer(cfg, target.server, kv)
+				} else {
+					labels.SetDevice(cfg, target.device, kv)
+				}
+				return len(kv)
+			})
+		},
+	}
+	AddCommonFlags(cmd, &opts)
+	cmd.Flags().BoolVar(&servers, "servers", false, "为匹配的服务器打标签 (仅使用 -g/-s 筛选)")
+	return cmd
+}
+
+func newLabelRmCmd() *cobra.Command {
+	opts := CommonFlags{}
+	var servers bool
+	cmd := &cobra.Command{
+		Use:   "rm key [key...]",
+		Short: "移除筛选出的设备 (或 --servers 服务器) 的标签",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return updateLabels(cmd, &opts, servers, func(cfg *config.Config, target labelTarget) int {
+				if target.server != "" {
+					return labels.RemoveServer(cfg, target.server, args)
+				}
+				return labels.RemoveDevice(cfg, target.device, args)
+			})
+		},
+	}
+	AddCommonFlags(cmd, &opts)
+	cmd.Flags().BoolVar(&servers, "servers", false, "移除匹配服务器的标签 (仅使用 -g/-s 筛选)")
+	return cmd
+}
+
+type labelTarget struct {
+	server string
+	device model.DeviceInfo
+}
+
+// updateLabels 选出目标设备或服务器，逐个应用 apply 并保存配置
+func updateLabels(cmd *cobra.Command, opts *CommonFlags, servers bool, apply func(*config.Config, labelTarget) int) error {
+	var targets []labelTarget
+	if servers {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("无法加载配置: %v", err)
+		}
+		group := opts.Group
+		if group == "" {
+			group = cfg.ActiveGroup
+		}
+		if group == "" {
+			group = "default"
+		}
+		pattern, err := selector.ParseServerPattern(opts.ServerPattern)
+		if err != nil {
+			return err
+		}
+		for _, s := range config.GetGroupServers(cfg, group) {
+			if !s.Disabled && pattern.Match(s.URL) {
+				targets = append(targets, labelTarget{server: s.URL})
+			}
+		}
+		if len(targets) == 0 {
+			return fmt.Errorf("分组 '%s' 中未找到匹配的服务器", group)
+		}
+	} else {
+		opts.Interactive = shouldEnterInteractive(cmd, opts)
+		selOpts, err := opts.ToSelectorOptions()
+		if err != nil {
+			return err
+		}
+		devices, err := selector.SelectDevices(selOpts)
+		if err != nil {
+			return err
+		}
+		for _, d := range devices {
+			targets = append(targets, labelTarget{device: d})
+		}
+	}
+
+	// 设备选择可能耗时较长，在此重新加载配置以免覆盖期间的其他修改
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("无法加载配置: %v", err)
+	}
+	changed := 0
+	for _, t := range targets {
+		changed += apply(cfg, t)
+	}
+	if err := config.Save(cfg); err != nil {
+		return fmt.Errorf("保存配置失败: %v", err)
+	}
+
+	kind := "设备"
+	if servers {
+		kind = "服务器"
+	}
+	fmt.Printf("已更新 %d 个%s的标签 (%d 项变更)\n", len(targets), kind, changed)
+	return nil
+}
+
+type labelEntry struct {
+	Kind   string            `json:"kind"`
+	Target string            `json:"target"`
+	Labels map[string]string `json:"labels"`
+}
+
+func newLabelLsCmd() *cobra.Command {
+	var asJSON bool
+	var selectors []string
+	cmd := &cobra.Command{
+		Use:   "ls",
+		Short: "列出已保存的标签",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sels, err := labels.ParseSelectors(selectors)
+			if err != nil {
+				return err
+			}
+			cfg, err := config.Load()
+			if err != nil {
+				return fmt.Errorf("无法加载配置: %v", err)
+			}
+
+			var entries []labelEntry
+			collect := func(kind string, store map[string]map[string]string) {
+				keys := make([]string, 0, len(store))
+				for k := range store {
+					keys = append(keys, k)
+				}
+				sort.Strings(keys)
+				for _, k := range keys {
+					if labels.Match(store[k], sels) {
+						entries = append(entries, labelEntry{Kind: kind, Target: k, Labels: store[k]})
+					}
+				}
+			}
+			collect("server", cfg.ServerLabels)
+			collect("device", cfg.DeviceLabels)
+
+			if output.Structured() {
+				return output.Print("LabelList", entries)
+			}
+			if asJSON {
+				if entries == nil {
+					entries = []labelEntry{}
+				}
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(entries)
+			}
+			if len(entries) == 0 {
+				fmt.Println("暂无标签。")
+				return nil
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "类型\t目标\t标签")
+			for _, e := range entries {
+				kind := "设备"
+				if e.Kind == "server" {
+					kind = "服务器"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\n", kind, e.Target, labels.Format(e.Labels))
+			}
+			return w.Flush()
+		},
+	}
+	cmd.Flags().StringArrayVar(&selectors, "label", nil, "按标签筛选 (key=value、key!=value、key、!key，可重复)")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "以 JSON 格式输出")
+	return cmd
+}
@@ -3,6 +3,8 @@ package device
 import (
 	"fmt"
 	"jpy-cli/pkg/middleware/device/selector"
+	"jpy-cli/pkg/middleware/model"
+	"jpy-cli/pkg/output"
 	"sort"
 	"strings"
 
@@ -44,6 +46,14 @@ func NewListCmd() *cobra.Command {
 				return devices[i].Seat < devices[j].Seat
 			})
 
+			if output.Structured() {
+				// --limit only truncates machine-readable output when given explicitly
+				if cmd.Flags().Changed("limit") && limit > 0 && len(devices) > limit {
+					devices = devices[:limit]
+				}
+				return output.Print("DeviceList", deviceRecords(devices))
+			}
+
 			// Apply limit for display only
 			displayDevices := devices
 			if limit > 0 && len(displayDevices) > limit {
@@ -64,8 +74,8 @@ func NewListCmd() *cobra.Command {
 				onlineStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))  // Green
 				offlineStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("240")) // Grey
 
-				headers = []string{"服务器", "机位", "序列号", "型号", "安卓", "状态", "业务", "IP", "ADB", "模式"}
-				widths  = []int{24, 6, 22, 10, 8, 8, 6, 16, 6, 6}
+				headers = []string{"服务器", "机位", "序列号", "型号", "系统", "状态", "业务", "IP", "ADB", "模式"}
+				widths  = []int{24, 6, 22, 10, 12, 8, 6, 16, 6, 6}
 			)
 
 			// Helper to clean URL
@@ -138,7 +148,7 @@ func NewListCmd() *cobra.Command {
 					cellStyle.Width(widths[1]).Render(fmt.Sprintf("%d", d.Seat)),
 					cellStyle.Width(widths[2]).Render(d.UUID),
 					cellStyle.Width(widths[3]).Render(d.Model),
-					cellStyle.Width(widths[4]).Render(d.Android),
+					cellStyle.Width(widths[4]).Render(d.OSLabel()),
 					lipgloss.NewStyle().Width(widths[5]).Align(lipgloss.Center).Render(stStatus),
 					lipgloss.NewStyle().Width(widths[6]).Align(lipgloss.Center).Render(stBiz),
 					cellStyle.Width(widths[7]).Render(d.IP),
@@ -175,3 +185,45 @@ func NewListCmd() *cobra.Command {
 
 	return cmd
 }
+
+// DeviceRecord is the DeviceList item of structured output. Field names match
+// the --where query fields.
+type DeviceRecord struct {
+	Server    string `json:"server"`
+	Seat      int    `json:"seat"`
+	UUID      string `json:"uuid"`
+	Model     string `json:"model"`
+	Platform  string `json:"platform"`
+	OSVersion string `json:"os_version"`
+	Android   string `json:"android"`
+	Online    bool   `json:"online"`
+	BizOnline bool   `json:"biz_online"`
+	IP        string `json:"ip"`
+	ADB       bool   `json:"adb"`
+	Mode      string `json:"mode"` // usb / otg
+}
+
+func deviceRecords(devices []model.DeviceInfo) []DeviceRecord {
+	records := make([]DeviceRecord, 0, len(devices))
+	for _, d := range devices {
+		mode := "otg"
+		if d.USBMode {
+			mode = "usb"
+		}
+		records = append(records, DeviceRecord{
+			Server:    d.ServerURL,
+			Seat:      d.Seat,
+			UUID:      d.UUID,
+			Model:     d.Model,
+			Platform:  string(d.Platform),
+			OSVersion: d.OSVersion,
+			Android:   d.Android,
+			Online:    d.IsOnline,
+			BizOnline: d.BizOnline,
+			IP:        d.IP,
+			ADB:       d.ADBEnabled,
+			Mode:      mode,
+		})
+	}
+	return records
+}
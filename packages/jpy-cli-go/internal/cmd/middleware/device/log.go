@@ -81,6 +81,9 @@ func NewLogCmd() *cobra.Command {
 			}
 
 			target := devices[0]
+			if !target.Platform.IsAndroid() {
+				return fmt.Errorf("设备日志依赖 USB/ADB 终端，%s 设备不支持此命令", target.Platform)
+			}
 
 			// Show detailed info before confirmation
 			status := "Offline"
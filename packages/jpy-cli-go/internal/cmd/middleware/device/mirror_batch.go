@@ -0,0 +1,169 @@
+package device
+
+import (
+	"encoding/json"
+	"fmt"
+	"jpy-cli/pkg/config"
+	"jpy-cli/pkg/logger"
+	"jpy-cli/pkg/middleware/connector"
+	"jpy-cli/pkg/middleware/device/api"
+	"jpy-cli/pkg/middleware/device/selector"
+	"jpy-cli/pkg/middleware/model"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// mirrorFunc runs a request on one device through its mirror channel.
+type mirrorFunc func(dev api.Device) (interface{}, error)
+
+// runMirrorAction selects devices, runs fn on each of them and prints the results.
+// With jsonOutput the results are written to stdout as a JSON array and progress goes to stderr.
+func runMirrorAction(cmd *cobra.Command, opts *CommonFlags, timeout time.Duration, jsonOutput bool, fn mirrorFunc) error {
+	opts.Interactive = shouldEnterInteractive(cmd, opts)
+	selOpts, err := opts.ToSelectorOptions()
+	if err != nil {
+		return err
+	}
+	devices, err := selector.SelectDevices(selOpts)
+	if err != nil {
+		return err
+	}
+	if len(devices) == 0 {
+		logger.Warn("没有找到符合条件的设备。")
+		return nil
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	results := runMirrorBatch(cfg, devices, timeout, fn, func(r MirrorResult) {
+		printMirrorResult(r, jsonOutput)
+	})
+
+	failCount := 0
+	for _, r := range results {
+		if !r.Success {
+			failCount++
+		}
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(os.Stderr, "\n执行完成: 成功 %d, 失败 %d\n", len(results)-failCount, failCount)
+
+	if failCount > 0 {
+		return fmt.Errorf("%d 台设备执行失败", failCount)
+	}
+	return nil
+}
+
+// MirrorResult is the per-device result of a mirror channel command.
+type MirrorResult struct {
+	Server     string      `json:"server"`
+	Seat       int         `json:"seat"`
+	UUID       string      `json:"uuid,omitempty"`
+	Success    bool        `json:"success"`
+	Result     interface{} `json:"result,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	DurationMs int64       `json:"duration_ms"`
+}
+
+// runMirrorBatch runs fn on every device through its mirror channel and reports
+// each result as soon as it completes. Results are returned in device order.
+func runMirrorBatch(cfg *config.Config, devices []model.DeviceInfo, timeout time.Duration, fn mirrorFunc, onResult func(MirrorResult)) []MirrorResult {
+	servers := make(map[string]config.LocalServerConfig)
+	for _, s := range config.GetAllServers(cfg) {
+		servers[s.URL] = s
+	}
+
+	concurrency := config.GlobalSettings.MaxConcurrency
+	if concurrency <= 0 {
+		concurrency = 5
+	}
+
+	connService := connector.NewConnectorService(cfg)
+	results := make([]MirrorResult, len(devices))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	for i, d := range devices {
+		wg.Add(1)
+		go func(i int, d model.DeviceInfo) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			start := time.Now()
+			res := MirrorResult{Server: d.ServerURL, Seat: d.Seat, UUID: d.UUID}
+			data, err := runOnMirror(connService, servers, d, timeout, fn)
+			res.DurationMs = time.Since(start).Milliseconds()
+			if err != nil {
+				res.Error = err.Error()
+			} else {
+				res.Success = true
+				res.Result = data
+			}
+			results[i] = res
+
+			mu.Lock()
+			onResult(res)
+			mu.Unlock()
+		}(i, d)
+	}
+	wg.Wait()
+
+	return results
+}
+
+func runOnMirror(connService *connector.ConnectorService, servers map[string]config.LocalServerConfig, d model.DeviceInfo, timeout time.Duration, fn mirrorFunc) (interface{}, error) {
+	server, ok := servers[d.ServerURL]
+	if !ok {
+		return nil, fmt.Errorf("未找到服务器配置: %s", d.ServerURL)
+	}
+
+	ws, err := connService.ConnectMirror(server, d.Seat)
+	if err != nil {
+		return nil, fmt.Errorf("连接镜像通道失败: %v", err)
+	}
+	defer ws.Close()
+
+	// Scripts can run far longer than the request timeout
+	if timeout > 0 {
+		ws.RequestTimeout = timeout
+	}
+
+	return fn(api.NewDevice(api.NewDeviceAPI(ws, server.URL, server.Token), d))
+}
+
+func printMirrorResult(r MirrorResult, jsonOutput bool) {
+	out := os.Stdout
+	if jsonOutput {
+		out = os.Stderr
+	}
+
+	if !r.Success {
+		fmt.Fprintf(out, "❌ %s 机位 %d: %s\n", r.Server, r.Seat, r.Error)
+		return
+	}
+
+	result := ""
+	if r.Result != nil {
+		if b, err := json.Marshal(r.Result); err == nil {
+			result = string(b)
+		} else {
+			result = fmt.Sprintf("%v", r.Result)
+		}
+	}
+	fmt.Fprintf(out, "✅ %s 机位 %d (%dms): %s\n", r.Server, r.Seat, r.DurationMs, result)
+}
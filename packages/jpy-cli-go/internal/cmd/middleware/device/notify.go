@@ -0,0 +1,96 @@
+package device
+
+import (
+	"fmt"
+	"jpy-cli/pkg/middleware/device/api"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func NewNotifyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "notify",
+		Short: "设备提示与定位 (Toast/TTS/打开链接)",
+		Long: `在所选设备上弹出提示、朗读文本或打开链接，便于在机架中定位设备。
+
+iOS 设备上 toast 为系统通知，speak 为 Siri 指令。`,
+	}
+
+	cmd.AddCommand(newNotifyTextCmd("toast", "弹出 Toast 提示 (默认显示机位号)", api.Device.Toast))
+	cmd.AddCommand(newNotifyTextCmd("speak", "朗读文本 (TTS，默认朗读机位号)", api.Device.Speak))
+	cmd.AddCommand(newNotifyOpenURLCmd())
+	cmd.AddCommand(newNotifyWebviewURLCmd())
+
+	return cmd
+}
+
+// newNotifyTextCmd builds a command that sends text to each device. Without
+// text, each device gets its own seat number so it can be located.
+func newNotifyTextCmd(use, short string, send func(dev api.Device, text string) error) *cobra.Command {
+	opts := CommonFlags{}
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   use + " [text]",
+		Short: short,
+		Example: fmt.Sprintf(`  jpy middleware device notify %s -s 192.168.1.10 --seat 37
+  jpy middleware device notify %s "请取走此设备" -g rack1 --all`, use, use),
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			text := strings.Join(args, " ")
+			return runMirrorAction(cmd, &opts, 0, jsonOutput, func(dev api.Device) (interface{}, error) {
+				msg := text
+				if msg == "" {
+					msg = fmt.Sprintf("机位 %d", dev.Info().Seat)
+				}
+				return nil, send(dev, msg)
+			})
+		},
+	}
+
+	AddCommonFlags(cmd, &opts)
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "以 JSON 格式输出结果")
+	return cmd
+}
+
+func newNotifyOpenURLCmd() *cobra.Command {
+	opts := CommonFlags{}
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:     "open-url <url>",
+		Short:   "在设备上打开链接或 Deep Link",
+		Example: `  jpy middleware device notify open-url "https://example.com" -s 192.168.1.10 --seat 3`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runMirrorAction(cmd, &opts, 0, jsonOutput, func(dev api.Device) (interface{}, error) {
+				return nil, dev.OpenURL(args[0])
+			})
+		},
+	}
+
+	AddCommonFlags(cmd, &opts)
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "以 JSON 格式输出结果")
+	return cmd
+}
+
+func newNotifyWebviewURLCmd() *cobra.Command {
+	opts := CommonFlags{}
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   "webview-url",
+		Short: "读取设备当前 App 浏览框的 URL",
+		Long:  "读取设备当前 App 浏览框的 URL。等待设备响应的时间由全局参数 --request-timeout 控制。",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runMirrorAction(cmd, &opts, 0, jsonOutput, func(dev api.Device) (interface{}, error) {
+				return dev.GetWebviewURL()
+			})
+		},
+	}
+
+	AddCommonFlags(cmd, &opts)
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "以 JSON 格式输出结果")
+	return cmd
+}
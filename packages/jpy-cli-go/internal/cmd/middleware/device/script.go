@@ -0,0 +1,64 @@
+package device
+
+import (
+	"fmt"
+	"jpy-cli/pkg/middleware/device/api"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func NewScriptCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "script",
+		Short: "在设备上执行 JS 脚本",
+	}
+	cmd.AddCommand(newScriptRunCmd())
+	return cmd
+}
+
+func newScriptRunCmd() *cobra.Command {
+	opts := CommonFlags{}
+	var (
+		remote     bool
+		timeout    int
+		jsonOutput bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "run <file.js>",
+		Short: "将 JS 脚本分发到所选设备执行",
+		Long: `读取本地 JS 文件并在所选设备上执行 (f=510)，每台设备完成后立即输出结果。
+
+使用 --remote 时参数视为设备上的脚本路径 (f=511)。
+使用 --json 时结果以 JSON 数组输出到标准输出，进度输出到标准错误。`,
+		Example: `  jpy middleware device script run check.js -g rack1 --all
+  jpy middleware device script run /sdcard/task.js --remote -s 192.168.1.10 --seat 3 --json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var source string
+			if !remote {
+				b, err := os.ReadFile(args[0])
+				if err != nil {
+					return fmt.Errorf("读取脚本失败: %v", err)
+				}
+				source = string(b)
+			}
+
+			return runMirrorAction(cmd, &opts, time.Duration(timeout)*time.Second, jsonOutput, func(dev api.Device) (interface{}, error) {
+				if remote {
+					return dev.ExecuteScriptFile(args[0])
+				}
+				return dev.ExecuteScript(source)
+			})
+		},
+	}
+
+	AddCommonFlags(cmd, &opts)
+	cmd.Flags().BoolVar(&remote, "remote", false, "参数为设备上的脚本路径")
+	cmd.Flags().IntVar(&timeout, "script-timeout", 30, "单台设备脚本执行超时 (秒)")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "以 JSON 格式输出结果")
+
+	return cmd
+}
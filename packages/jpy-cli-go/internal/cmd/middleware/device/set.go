@@ -0,0 +1,210 @@
+package device
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"jpy-cli/pkg/config"
+	"jpy-cli/pkg/middleware/device/labels"
+	"jpy-cli/pkg/middleware/device/selector"
+
+	"github.com/spf13/cobra"
+)
+
+func NewSetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set",
+		Short: "命名设备集合管理",
+		Long: `保存命名的设备选择，之后在任意设备命令中通过 --set NAME 复用。
+
+集合有两种:
+- 筛选集合 (默认): 保存筛选条件，每次使用时重新计算
+- 固定集合 (--pin): 保存当前选中设备的 UUID (无 UUID 时为 服务器地址#机位)，
+  以及它们所在的分组与服务器
+
+使用 --set 时，命令行上的筛选参数优先于集合中保存的条件。`,
+	}
+	cmd.AddCommand(newSetSaveCmd())
+	cmd.AddCommand(newSetLsCmd())
+	cmd.AddCommand(newSetShowCmd())
+	cmd.AddCommand(newSetRmCmd())
+	return cmd
+}
+
+func newSetSaveCmd() *cobra.Command {
+	opts := CommonFlags{}
+	var pin, force bool
+	cmd := &cobra.Command{
+		Use:   "save NAME",
+		Short: "保存筛选条件或固定设备列表为命名集合",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			cfg, err := config.Load()
+			if err != nil {
+				return fmt.Errorf("无法加载配置: %v", err)
+			}
+			if _, exists := cfg.DeviceSets[name]; exists && !force {
+				return fmt.Errorf("设备集合 '%s' 已存在，使用 --force 覆盖", name)
+			}
+
+			set := config.DeviceSet{CreatedAt: time.Now().Format(time.RFC3339)}
+			if pin {
+				selOpts, err := opts.ToSelectorOptions()
+				if err != nil {
+					return err
+				}
+				devices, err := selector.SelectDevices(selOpts)
+				if err != nil {
+					return err
+				}
+				set.Devices = make([]string, 0, len(devices))
+				servers := make(map[string]bool)
+				for _, d := range devices {
+					set.Devices = append(set.Devices, labels.DeviceKey(d))
+					if !servers[d.ServerURL] {
+						servers[d.ServerURL] = true
+						set.Servers = append(set.Servers, d.ServerURL)
+					}
+				}
+				sort.Strings(set.Devices)
+				sort.Strings(set.Servers)
+				// 保存分组，使 --set 在其他分组激活时仍解析到同一批服务器
+				set.Group = selOpts.Group
+				if set.Group == "" {
+					set.Group = cfg.ActiveGroup
+				}
+				if set.Group == "" {
+					set.Group = "default"
+				}
+			} else {
+				if !HasSelector(cmd) {
+					return fmt.Errorf("请至少指定一个筛选条件，或使用 --pin 固定设备列表")
+				}
+				// 校验表达式等参数，同时展开 --set 引用的其他集合
+				if _, err := opts.ToSelectorOptions(); err != nil {
+					return err
+				}
+				if opts.pinned != nil {
+					return fmt.Errorf("集合 '%s' 是固定集合，只能配合 --pin 使用", opts.Set)
+				}
+				set.Filter = opts.Filter()
+			}
+
+			// 重新加载，避免覆盖设备选择期间的其他修改
+			if cfg, err = config.Load(); err != nil {
+				return fmt.Errorf("无法加载配置: %v", err)
+			}
+			if cfg.DeviceSets == nil {
+				cfg.DeviceSets = make(map[string]config.DeviceSet)
+			}
+			cfg.DeviceSets[name] = set
+			if err := config.Save(cfg); err != nil {
+				return fmt.Errorf("保存配置失败: %v", err)
+			}
+			fmt.Printf("已保存设备集合 '%s' (%s)\n", name, describeSet(set))
+			return nil
+		},
+	}
+	AddCommonFlags(cmd, &opts)
+	cmd.Flags().BoolVar(&pin, "pin", false, "固定当前选中的设备列表，而不是保存筛选条件")
+	cmd.Flags().BoolVar(&force, "force", false, "覆盖同名集合")
+	return cmd
+}
+
+func newSetLsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "ls",
+		Short: "列出命名设备集合",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Load()
+			if err != nil {
+				return fmt.Errorf("无法加载配置: %v", err)
+			}
+			if len(cfg.DeviceSets) == 0 {
+				fmt.Println("暂无设备集合。")
+				return nil
+			}
+			names := make([]string, 0, len(cfg.DeviceSets))
+			for name := range cfg.DeviceSets {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "名称\t内容\t创建时间")
+			for _, name := range names {
+				set := cfg.DeviceSets[name]
+				fmt.Fprintf(w, "%s\t%s\t%s\n", name, describeSet(set), set.CreatedAt)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func newSetShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show NAME",
+		Short: "查看设备集合详情 (JSON)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Load()
+			if err != nil {
+				return fmt.Errorf("无法加载配置: %v", err)
+			}
+			set, ok := cfg.DeviceSets[args[0]]
+			if !ok {
+				return fmt.Errorf("设备集合 '%s' 不存在", args[0])
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			enc.SetEscapeHTML(false)
+			return enc.Encode(set)
+		},
+	}
+}
+
+func newSetRmCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rm NAME [NAME...]",
+		Short: "删除设备集合",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Load()
+			if err != nil {
+				return fmt.Errorf("无法加载配置: %v", err)
+			}
+			for _, name := range args {
+				if _, ok := cfg.DeviceSets[name]; !ok {
+					return fmt.Errorf("设备集合 '%s' 不存在", name)
+				}
+				delete(cfg.DeviceSets, name)
+			}
+			if err := config.Save(cfg); err != nil {
+				return fmt.Errorf("保存配置失败: %v", err)
+			}
+			fmt.Printf("已删除 %d 个设备集合\n", len(args))
+			return nil
+		},
+	}
+}
+
+func describeSet(set config.DeviceSet) string {
+	if set.Filter == nil {
+		if set.Group == "" {
+			return fmt.Sprintf("固定 %d 台设备", len(set.Devices))
+		}
+		return fmt.Sprintf("固定 %d 台设备 (分组 %s, %d 台服务器)", len(set.Devices), set.Group, len(set.Servers))
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(set.Filter)
+	return "筛选 " + strings.TrimSpace(buf.String())
+}
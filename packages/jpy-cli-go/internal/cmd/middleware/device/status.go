@@ -2,20 +2,24 @@ package device
 
 import (
 	"fmt"
-	httpclient "jpy-cli/pkg/client/http"
 	wsclient "jpy-cli/pkg/client/ws"
 	"jpy-cli/pkg/config"
 	"jpy-cli/pkg/logger"
 	"jpy-cli/pkg/middleware/device/api"
+	"jpy-cli/pkg/middleware/device/fetcher"
+	"jpy-cli/pkg/middleware/device/labels"
+	"jpy-cli/pkg/middleware/device/query"
 	"jpy-cli/pkg/middleware/device/selector"
+	"jpy-cli/pkg/middleware/license"
 	"jpy-cli/pkg/middleware/model"
+	"jpy-cli/pkg/output"
 	"jpy-cli/pkg/tui"
+	"os"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 
-	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
 )
@@ -27,6 +31,9 @@ type ServerStatusStats struct {
 	SN              string
 	ControlAddr     string
 	LicenseName     string
+	Authorized      bool
+	LicenseMatch    bool // Result of the license filter
+	Anomalies       []license.Issue
 	FirmwareVersion string
 	NetworkSpeed    string
 	NetworkSpeedVal float64 // For filtering
@@ -45,21 +52,17 @@ func NewStatusCmd() *cobra.Command {
 	opts := CommonFlags{}
 	var detail bool
 	var (
-		bizOnlineGT        int
-		bizOnlineLT        int
-		ipCountGT          int
-		ipCountLT          int
-		uuidCountGT        int
-		uuidCountLT        int
-		snGT               string
-		snLT               string
-		authFailed         bool
-		clusterContains    string
-		clusterNotContains string
-		fwVersionHas       string
-		fwVersionNot       string
-		netSpeedGT         float64
-		netSpeedLT         float64
+		bizOnlineGT  int
+		bizOnlineLT  int
+		ipCountGT    int
+		ipCountLT    int
+		uuidCountGT  int
+		uuidCountLT  int
+		authFailed   bool
+		fwVersionHas string
+		fwVersionNot string
+		netSpeedGT   float64
+		netSpeedLT   float64
 	)
 
 	cmd := &cobra.Command{
@@ -77,11 +80,23 @@ func NewStatusCmd() *cobra.Command {
 新增高级筛选：
 - 业务在线数/IP数 (> 或 <)
 - 序列号范围 (> 或 <)
-- 授权状态非成功
+- 授权状态非成功 / 指定授权状态 (--license-status)
 - 集控平台地址包含/不包含
+- 授权已过期或即将到期 (--expiring-within)
+- 表达式查询 (--where)，例如 'online && !adb && seat between 10 and 40'
+  设备字段: seat uuid model platform os os_version android online biz_online ip adb usb otg server
+  服务器字段: devices online_count biz_online_count ip_count uuid_count adb_count usb_count otg_count
+  授权字段: license sn cluster days_left (无到期时间或无法获取授权时 days_left 的比较均不成立)
 
 筛选条件可以组合使用（AND逻辑）。`,
 		Run: func(cmd *cobra.Command, args []string) {
+			selOpts, err := opts.ToSelectorOptions()
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			pattern, _ := selector.ParseServerPattern(opts.ServerPattern) // validated by ToSelectorOptions
+
 			cfg, err := config.Load()
 			if err != nil {
 				fmt.Printf("无法加载配置: %v\n", err)
@@ -97,7 +112,7 @@ func NewStatusCmd() *cobra.Command {
 				targetGroup = "default"
 			}
 
-			fmt.Printf("当前分组: %s\n", targetGroup)
+			output.Notef("当前分组: %s\n", targetGroup)
 			logger.Infof("开始检查分组设备状态: %s", targetGroup)
 
 			// Get servers for the target group
@@ -116,13 +131,13 @@ func NewStatusCmd() *cobra.Command {
 				if s.Disabled {
 					continue
 				}
-				if selector.MatchServerPattern(s.URL, opts.ServerPattern) {
+				if pattern.Match(s.URL) && selOpts.ServerAllowed(s.URL) {
 					targets = append(targets, s)
 				}
 			}
 
 			if len(targets) == 0 {
-				fmt.Printf("分组 '%s' 中未找到匹配的服务器。\n", targetGroup)
+				output.Notef("分组 '%s' 中未找到匹配的服务器。\n", targetGroup)
 				return
 			}
 
@@ -134,6 +149,17 @@ func NewStatusCmd() *cobra.Command {
 			// Determine if we need to fetch details
 			fetchDetails := detail || fwVersionHas != "" || fwVersionNot != "" || netSpeedGT > -1 || netSpeedLT > -1
 
+			licenseSvc := license.NewService(cfg)
+			licFilter := selOpts.LicenseFilter()
+			hasDeviceSelector := selOpts.Where != nil || len(selOpts.Labels) > 0 || selOpts.Pinned != nil
+			var pinned map[string]bool
+			if selOpts.Pinned != nil {
+				pinned = make(map[string]bool)
+				for _, k := range selOpts.Pinned {
+					pinned[k] = true
+				}
+			}
+
 			// Results channel for TUI
 			resultsChan := make(chan interface{}, len(targets))
 			var wg sync.WaitGroup
@@ -154,102 +180,35 @@ func NewStatusCmd() *cobra.Command {
 						OrderIndex:    serverOrder[server.URL],
 					}
 
-					// 1. Check License (HTTP)
-					apiClient := httpclient.NewClient(server.URL, server.Token)
-					lic, err := apiClient.GetLicense()
-					if err == nil {
-						// Re-authorize if Status is success but Control Platform (C) is missing
-						if lic.StatusTxt == "成功" && lic.C == "" {
-							logger.Warnf("[%s] Authorization successful but Control Platform missing. Re-submitting authorization code...", server.URL)
-							if reauthErr := apiClient.Reauthorize(lic.Sn); reauthErr == nil {
-								logger.Infof("[%s] Re-authorization submitted successfully. Refreshing license info...", server.URL)
-								if newLic, refreshErr := apiClient.GetLicense(); refreshErr == nil {
-									lic = newLic
-								} else {
-									logger.Warnf("[%s] Failed to refresh license after re-authorization: %v", server.URL, refreshErr)
-								}
-							} else {
-								logger.Errorf("[%s] Re-authorization failed: %v", server.URL, reauthErr)
-							}
-						}
-
+					// 1. Check License (HTTP). Read-only: remediation lives in 'license heal'
+					check := licenseSvc.CheckOne(server)
+					stats.LicenseMatch = licFilter.Match(check, time.Now())
+					if lic := check.License; check.Error == nil {
+						server.Token = check.Client.Token
+						stats.Authorized = check.Status == license.StatusAuthorized
 						if lic.StatusTxt != "" {
 							stats.LicenseStatus = lic.StatusTxt
 						} else {
 							stats.LicenseStatus = "Unknown"
 						}
-						if lic.Sn != "" {
-							stats.SN = lic.Sn
-						}
-						if lic.C != "" {
-							stats.ControlAddr = lic.C
-						}
-						if lic.N != "" {
-							stats.LicenseName = lic.N
+						stats.SN = lic.Sn
+						stats.ControlAddr = lic.C
+						stats.LicenseName = lic.N
+						stats.Anomalies = license.Diagnose(lic, time.Now(), "")
+						for _, issue := range stats.Anomalies {
+							logger.Warnf("[%s] License anomaly: %s (run 'jpy middleware license heal' to fix)", server.URL, issue)
 						}
 						stats.Status = "Online"
 					} else {
-						// Attempt re-login
-						logger.Infof("[%s] License check failed, attempting re-login...", server.URL)
-						newToken, loginErr := apiClient.Login(server.Username, server.Password)
-						if loginErr == nil {
-							// Update server config
-							server.Token = newToken
-							server.LastLoginTime = time.Now().Format(time.RFC3339)
-							server.LastLoginError = ""
-							config.UpdateServer(cfg, server)
-
-							// Retry License Check
-							apiClient.Token = newToken
-							lic, err = apiClient.GetLicense()
-							if err == nil {
-								// Re-authorize if Status is success but Control Platform (C) is missing
-								if lic.StatusTxt == "成功" && lic.C == "" {
-									logger.Warnf("[%s] Authorization successful but Control Platform missing. Re-submitting authorization code...", server.URL)
-									if reauthErr := apiClient.Reauthorize(lic.Sn); reauthErr == nil {
-										logger.Infof("[%s] Re-authorization submitted successfully. Refreshing license info...", server.URL)
-										if newLic, refreshErr := apiClient.GetLicense(); refreshErr == nil {
-											lic = newLic
-										} else {
-											logger.Warnf("[%s] Failed to refresh license after re-authorization: %v", server.URL, refreshErr)
-										}
-									} else {
-										logger.Errorf("[%s] Re-authorization failed: %v", server.URL, reauthErr)
-									}
-								}
-
-								if lic.StatusTxt != "" {
-									stats.LicenseStatus = lic.StatusTxt
-								} else {
-									stats.LicenseStatus = "Unknown"
-								}
-								if lic.Sn != "" {
-									stats.SN = lic.Sn
-								}
-								if lic.C != "" {
-									stats.ControlAddr = lic.C
-								}
-								if lic.N != "" {
-									stats.LicenseName = lic.N
-								}
-								stats.Status = "Online"
-								logger.Infof("[%s] Re-login successful", server.URL)
-							} else {
-								stats.Status = "AuthFail"
-								logger.Warnf("[%s] Re-login successful but license check failed: %v", server.URL, err)
-							}
-						} else {
-							stats.Status = "AuthFail"
-							server.LastLoginError = loginErr.Error()
-							config.UpdateServer(cfg, server)
-							logger.Errorf("[%s] Re-login failed: %v", server.URL, loginErr)
-						}
+						stats.Status = "AuthFail"
+						logger.Warnf("[%s] License check failed: %v", server.URL, check.Error)
 					}
 
 					// 2. Fetch Devices (WS)
 					if stats.Status == "Online" {
 						wsClient := wsclient.NewClient(server.URL, server.Token)
-						wsClient.Timeout = time.Duration(config.GlobalSettings.ConnectTimeout) * time.Second
+						wsClient.Timeout = config.GlobalSettings.ConnectTimeoutDuration()
+						wsClient.RequestTimeout = config.GlobalSettings.RequestTimeoutDuration()
 						if err := wsClient.Connect(); err == nil {
 							deviceAPI := api.NewDeviceAPI(wsClient, server.URL, server.Token)
 
@@ -287,8 +246,16 @@ func NewStatusCmd() *cobra.Command {
 									statusMap[s.Seat] = s
 								}
 
+								// --where, --label and --set are evaluated against the merged device view
+								var infos []model.DeviceInfo
+								var serverStats *query.ServerStats
+								if hasDeviceSelector {
+									infos = fetcher.BuildDevices(fetcher.ServerResult{ServerURL: server.URL, Devices: devices, Statuses: onlineStatuses})
+									serverStats = query.Aggregate(infos)[server.URL]
+								}
+
 								// Iterate devices to calculate stats (with filtering)
-								for _, d := range devices {
+								for i, d := range devices {
 									// --- Device Filtering ---
 									// UUID Filter
 									if opts.UUID != "" && !strings.Contains(d.UUID, opts.UUID) {
@@ -355,6 +322,16 @@ func NewStatusCmd() *cobra.Command {
 										}
 									}
 
+									if selOpts.Where != nil && !selOpts.Where.Match(query.Record{Device: &infos[i], Server: serverStats, License: &check}) {
+										continue
+									}
+									if len(selOpts.Labels) > 0 && !labels.Match(labels.Lookup(cfg, infos[i]), selOpts.Labels) {
+										continue
+									}
+									if pinned != nil && !pinned[labels.SeatKey(infos[i])] && !pinned[infos[i].UUID] {
+										continue
+									}
+
 									// --- Accumulate Stats ---
 									stats.DeviceCount++
 									if d.UUID != "" {
@@ -396,7 +373,7 @@ func NewStatusCmd() *cobra.Command {
 
 			// Start TUI
 			totalDevicesStatus := 0
-			prog := tea.NewProgram(tui.NewProgressModel(len(targets), resultsChan, func(v interface{}) string {
+			rawResults, err := tui.RunProgress(len(targets), resultsChan, func(v interface{}) string {
 				stats := v.(ServerStatusStats)
 				cleanURL := strings.TrimPrefix(stats.ServerURL, "https://")
 				cleanURL = strings.TrimPrefix(cleanURL, "http://")
@@ -406,9 +383,7 @@ func NewStatusCmd() *cobra.Command {
 					return fmt.Sprintf("✅ %s: %d 台设备 (总计: %d)", cleanURL, stats.DeviceCount, totalDevicesStatus)
 				}
 				return fmt.Sprintf("❌ %s: %s", cleanURL, stats.Status)
-			}))
-
-			finalModel, err := prog.Run()
+			})
 			if err != nil {
 				fmt.Printf("TUI运行错误: %v\n", err)
 				return
@@ -416,7 +391,6 @@ func NewStatusCmd() *cobra.Command {
 
 			// Get results from model
 			var results []ServerStatusStats
-			rawResults := finalModel.(tui.ProgressModel).GetResults()
 			for _, raw := range rawResults {
 				results = append(results, raw.(ServerStatusStats))
 			}
@@ -426,13 +400,13 @@ func NewStatusCmd() *cobra.Command {
 			for _, r := range results {
 				keep := true
 
-				// AuthorizedOnly
-				if opts.AuthorizedOnly && r.LicenseStatus != "成功" {
+				// License predicates (--authorized, --license-status, --cluster-*, --sn-*, --expiring-within)
+				if licFilter.Active() && !r.LicenseMatch {
 					keep = false
 				}
 
 				// Auth Failed Filter
-				if authFailed && r.LicenseStatus == "成功" {
+				if authFailed && r.Authorized {
 					keep = false
 				}
 
@@ -460,22 +434,6 @@ func NewStatusCmd() *cobra.Command {
 					keep = false
 				}
 
-				// SN Comparison (Lexicographical)
-				if snGT != "" && r.SN <= snGT {
-					keep = false
-				}
-				if snLT != "" && r.SN >= snLT {
-					keep = false
-				}
-
-				// Cluster Address Filter
-				if clusterContains != "" && !strings.Contains(r.ControlAddr, clusterContains) {
-					keep = false
-				}
-				if clusterNotContains != "" && strings.Contains(r.ControlAddr, clusterNotContains) {
-					keep = false
-				}
-
 				// Firmware Version Filter
 				if fwVersionHas != "" && !strings.Contains(r.FirmwareVersion, fwVersionHas) {
 					keep = false
@@ -495,7 +453,7 @@ func NewStatusCmd() *cobra.Command {
 				// If any device filter was active, we probably want to hide servers with 0 results
 				// Device filters: UUID, Seat, Online, ADB, USB, HasIP
 				hasDeviceFilter := opts.UUID != "" || opts.Seat > -1 || opts.FilterOnline != "" ||
-					opts.FilterADB != "" || opts.FilterUSB != "" || opts.FilterHasIP != ""
+					opts.FilterADB != "" || opts.FilterUSB != "" || opts.FilterHasIP != "" || hasDeviceSelector
 
 				if hasDeviceFilter && r.DeviceCount == 0 {
 					keep = false
@@ -511,6 +469,13 @@ func NewStatusCmd() *cobra.Command {
 				return results[i].OrderIndex < results[j].OrderIndex
 			})
 
+			if output.Structured() {
+				if err := output.Print("ServerStatusList", statusRecords(results)); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+				}
+				return
+			}
+
 			if len(results) == 0 {
 				fmt.Println("没有找到匹配的服务器或设备。")
 				return
@@ -628,12 +593,19 @@ func NewStatusCmd() *cobra.Command {
 				}
 
 				stLic := statusOnlineStyle
-				if r.LicenseStatus != "成功" {
+				if !r.Authorized || len(r.Anomalies) > 0 {
 					stLic = statusErrorStyle
 				}
 
 				// Construct detailed license info string
 				authInfo := r.LicenseStatus
+				if len(r.Anomalies) > 0 {
+					names := make([]string, len(r.Anomalies))
+					for i, a := range r.Anomalies {
+						names[i] = a.String()
+					}
+					authInfo = fmt.Sprintf("%s (⚠️ %s)", r.LicenseStatus, strings.Join(names, ", "))
+				}
 				if detail {
 					var details []string
 					if r.SN != "" {
@@ -646,7 +618,7 @@ func NewStatusCmd() *cobra.Command {
 						details = append(details, fmt.Sprintf("N:%s", r.LicenseName))
 					}
 					if len(details) > 0 {
-						authInfo = fmt.Sprintf("%s | %s", r.LicenseStatus, strings.Join(details, " | "))
+						authInfo = fmt.Sprintf("%s | %s", authInfo, strings.Join(details, " | "))
 					}
 				}
 
@@ -709,6 +681,16 @@ func NewStatusCmd() *cobra.Command {
 				totalStr = lipgloss.JoinHorizontal(lipgloss.Top, totalStr, cell)
 			}
 			fmt.Println(totalStr)
+
+			anomalies := 0
+			for _, r := range results {
+				if len(r.Anomalies) > 0 {
+					anomalies++
+				}
+			}
+			if anomalies > 0 {
+				fmt.Printf("\n⚠️ %d 台服务器授权异常，可运行 'jpy middleware license heal --dry-run' 查看修复计划\n", anomalies)
+			}
 		},
 	}
 
@@ -722,11 +704,7 @@ func NewStatusCmd() *cobra.Command {
 	cmd.Flags().IntVar(&ipCountLT, "ip-count-lt", -1, "筛选IP数小于指定值的服务器")
 	cmd.Flags().IntVar(&uuidCountGT, "uuid-count-gt", -1, "筛选UUID数大于指定值的服务器")
 	cmd.Flags().IntVar(&uuidCountLT, "uuid-count-lt", -1, "筛选UUID数小于指定值的服务器")
-	cmd.Flags().StringVar(&snGT, "sn-gt", "", "筛选序列号大于指定值的服务器")
-	cmd.Flags().StringVar(&snLT, "sn-lt", "", "筛选序列号小于指定值的服务器")
 	cmd.Flags().BoolVar(&authFailed, "auth-failed", false, "筛选授权状态非成功的服务器")
-	cmd.Flags().StringVar(&clusterContains, "cluster-contains", "", "筛选集控平台地址包含指定字符串的服务器")
-	cmd.Flags().StringVar(&clusterNotContains, "cluster-not-contains", "", "筛选集控平台地址不包含指定字符串的服务器")
 	cmd.Flags().StringVar(&fwVersionHas, "fw-has", "", "筛选固件版本包含指定字符串的服务器")
 	cmd.Flags().StringVar(&fwVersionNot, "fw-not", "", "筛选固件版本不包含指定字符串的服务器")
 	cmd.Flags().Float64Var(&netSpeedGT, "speed-gt", -1, "筛选网络速率大于指定值(Mbps)的服务器")
@@ -734,3 +712,54 @@ func NewStatusCmd() *cobra.Command {
 
 	return cmd
 }
+
+// ServerStatusRecord is the ServerStatusList item of structured output.
+type ServerStatusRecord struct {
+	Server          string   `json:"server"`
+	Status          string   `json:"status"` // Online / Offline / AuthFail
+	FirmwareVersion string   `json:"firmware_version"`
+	NetworkSpeed    float64  `json:"network_speed_mbps"`
+	Devices         int      `json:"devices"`
+	BizOnline       int      `json:"biz_online_count"`
+	IP              int      `json:"ip_count"`
+	UUID            int      `json:"uuid_count"`
+	ADB             int      `json:"adb_count"`
+	USB             int      `json:"usb_count"`
+	OTG             int      `json:"otg_count"`
+	Authorized      bool     `json:"authorized"`
+	LicenseStatus   string   `json:"license_status"`
+	SN              string   `json:"sn"`
+	Cluster         string   `json:"cluster"`
+	LicenseName     string   `json:"license_name"`
+	Anomalies       []string `json:"anomalies"`
+}
+
+func statusRecords(results []ServerStatusStats) []ServerStatusRecord {
+	records := make([]ServerStatusRecord, 0, len(results))
+	for _, r := range results {
+		anomalies := make([]string, 0, len(r.Anomalies))
+		for _, a := range r.Anomalies {
+			anomalies = append(anomalies, string(a))
+		}
+		records = append(records, ServerStatusRecord{
+			Server:          r.ServerURL,
+			Status:          r.Status,
+			FirmwareVersion: r.FirmwareVersion,
+			NetworkSpeed:    r.NetworkSpeedVal,
+			Devices:         r.DeviceCount,
+			BizOnline:       r.BizOnlineCount,
+			IP:              r.IPCount,
+			UUID:            r.UUIDCount,
+			ADB:             r.ADBCount,
+			USB:             r.USBCount,
+			OTG:             r.OTGCount,
+			Authorized:      r.Authorized,
+			LicenseStatus:   r.LicenseStatus,
+			SN:              r.SN,
+			Cluster:         r.ControlAddr,
+			LicenseName:     r.LicenseName,
+			Anomalies:       anomalies,
+		})
+	}
+	return records
+}
@@ -0,0 +1,229 @@
+package device
+
+import (
+	"fmt"
+	"io"
+	"jpy-cli/pkg/logger"
+	"jpy-cli/pkg/middleware/connector"
+	"jpy-cli/pkg/middleware/device/mirror"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/spf13/cobra"
+)
+
+var streamUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 64 * 1024,
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+func NewStreamCmd() *cobra.Command {
+	opts := CommonFlags{}
+	videoOpts := mirror.DefaultVideoOptions()
+	var (
+		outFile  string
+		listen   string
+		duration int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "stream",
+		Short: "转发设备实时画面 (H.264)",
+		Long: `开启设备的视频流 (f=251)，将 H.264 裸流写入文件或通过本地 HTTP/WebSocket 转发。
+
+本地服务端点 (--listen):
+  /stream.h264  H.264 裸流，例如: ffplay -f h264 http://127.0.0.1:8090/stream.h264
+  /ws           WebSocket，每条二进制消息为一帧 Annex-B 数据
+
+注意：此命令必须操作单个设备。`,
+		Example: `  jpy middleware device stream -s 192.168.1.10 --seat 3 --out seat3.h264
+  jpy middleware device stream -s 192.168.1.10 --seat 3 --listen 127.0.0.1:8090`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if outFile == "" && listen == "" {
+				return fmt.Errorf("请指定 --out 或 --listen")
+			}
+
+			target, server, cfg, err := selectSingleDevice(opts)
+			if err != nil {
+				return err
+			}
+
+			var out io.WriteCloser
+			if outFile == "-" {
+				out = os.Stdout
+			} else if outFile != "" {
+				f, err := os.Create(outFile)
+				if err != nil {
+					return fmt.Errorf("创建输出文件失败: %v", err)
+				}
+				out = f
+			}
+			if out != nil && out != os.Stdout {
+				defer out.Close()
+			}
+
+			relay := mirror.NewRelay()
+			defer relay.Close()
+
+			if listen != "" {
+				ln, err := net.Listen("tcp", listen)
+				if err != nil {
+					return fmt.Errorf("监听 %s 失败: %v", listen, err)
+				}
+				srv := &http.Server{Handler: newStreamHandler(relay)}
+				go srv.Serve(ln)
+				defer srv.Close()
+				fmt.Fprintf(os.Stderr, "本地转发已启动: http://%s/stream.h264 , ws://%s/ws\n", ln.Addr(), ln.Addr())
+			}
+
+			connService := connector.NewConnectorService(cfg)
+			ws, err := connService.ConnectMirror(server, target.Seat)
+			if err != nil {
+				return fmt.Errorf("连接镜像通道失败: %v", err)
+			}
+
+			session := mirror.NewMirrorSession(ws, target.Seat)
+			defer session.Close()
+
+			if err := session.StartVideo(videoOpts); err != nil {
+				return fmt.Errorf("开启视频流失败: %v", err)
+			}
+			fmt.Fprintf(os.Stderr, "正在接收 %s 机位 %d 的视频流，按 Ctrl+C 停止...\n", target.ServerURL, target.Seat)
+
+			sigChan := make(chan os.Signal, 1)
+			signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+			defer signal.Stop(sigChan)
+
+			var timeout <-chan time.Time
+			if duration > 0 {
+				timeout = time.After(time.Duration(duration) * time.Second)
+			}
+
+			var frames, total int64
+		Loop:
+			for {
+				select {
+				case frame := <-session.Video:
+					frames++
+					total += int64(len(frame))
+					if out != nil {
+						if _, err := out.Write(frame); err != nil {
+							return fmt.Errorf("写入输出失败: %v", err)
+						}
+					}
+					relay.Publish(frame)
+				case <-session.Closed:
+					logger.Warn("镜像连接已关闭")
+					break Loop
+				case <-timeout:
+					break Loop
+				case <-sigChan:
+					break Loop
+				}
+			}
+
+			if err := session.StopVideo(); err != nil {
+				logger.Warnf("关闭视频流失败: %v", err)
+			}
+			fmt.Fprintf(os.Stderr, "已停止，共接收 %d 帧 (%d 字节)\n", frames, total)
+			return nil
+		},
+	}
+
+	AddCommonFlags(cmd, &opts)
+	cmd.Flags().StringVarP(&outFile, "out", "o", "", "输出 H.264 文件路径 ('-' 为标准输出)")
+	cmd.Flags().StringVar(&listen, "listen", "", "本地 HTTP/WebSocket 转发地址 (例如: 127.0.0.1:8090)")
+	cmd.Flags().IntVar(&duration, "duration", 0, "录制时长 (秒)，0 表示直到 Ctrl+C")
+	cmd.Flags().IntVar(&videoOpts.FPS, "fps", videoOpts.FPS, "帧率")
+	cmd.Flags().IntVar(&videoOpts.Bitrate, "bitrate", videoOpts.Bitrate, "码率 (bps)")
+	cmd.Flags().IntVar(&videoOpts.Quality, "quality", videoOpts.Quality, "画质")
+	cmd.Flags().IntVar(&videoOpts.Width, "width", videoOpts.Width, "画面宽度")
+
+	return cmd
+}
+
+func newStreamHandler(relay *mirror.Relay) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/stream.h264", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "video/h264")
+		w.Header().Set("Cache-Control", "no-cache")
+		flusher, _ := w.(http.Flusher)
+
+		ch := relay.Subscribe()
+		defer relay.Unsubscribe(ch)
+
+		for {
+			select {
+			case frame, ok := <-ch:
+				if !ok {
+					return
+				}
+				if _, err := w.Write(frame); err != nil {
+					return
+				}
+				if flusher != nil {
+					flusher.Flush()
+				}
+			case <-r.Context().Done():
+				return
+			}
+		}
+	})
+
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := streamUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			logger.Warnf("WebSocket 升级失败: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		ch := relay.Subscribe()
+		defer relay.Unsubscribe(ch)
+
+		// Detect client disconnect
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		for {
+			select {
+			case frame, ok := <-ch:
+				if !ok {
+					return
+				}
+				if err := conn.WriteMessage(websocket.BinaryMessage, frame); err != nil {
+					return
+				}
+			case <-closed:
+				return
+			}
+		}
+	})
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintln(w, "/stream.h264  H.264 raw stream (ffplay -f h264 <url>)")
+		fmt.Fprintln(w, "/ws           WebSocket, one Annex-B frame per binary message")
+	})
+
+	return mux
+}
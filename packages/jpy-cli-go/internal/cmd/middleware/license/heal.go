@@ -0,0 +1,183 @@
+package license
+
+import (
+	"bufio"
+	"fmt"
+	"jpy-cli/internal/cmd/admin/login"
+	adminApi "jpy-cli/pkg/admin-middleware/api"
+	"jpy-cli/pkg/admin-middleware/service"
+	"jpy-cli/pkg/config"
+	"jpy-cli/pkg/middleware/license"
+	"jpy-cli/pkg/output"
+	"jpy-cli/pkg/tui"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func NewHealCmd() *cobra.Command {
+	var (
+		group   string
+		pattern string
+		rules   string
+		yes     bool
+		opts    = license.HealOptions{}
+	)
+
+	cmd := &cobra.Command{
+		Use:   "heal",
+		Short: "检测并修复授权异常",
+		Long: `检查服务器授权，按规则修复异常:
+
+  missing-control  已授权但缺少集控平台地址 -> 重新提交授权码
+  wrong-cluster    集控平台地址与 --cluster 不符 -> 后台修改集控地址后重新提交
+  expired          授权已过期 -> 后台延长 --renew-days 天后重新提交
+
+wrong-cluster 仅在指定 --cluster 时生效；需要修改后台的规则会登录管理后台。
+使用 --dry-run 仅输出计划执行的操作。`,
+		Example: `  jpy middleware license heal --dry-run
+  jpy middleware license heal --rules missing-control --yes
+  jpy middleware license heal -g rack1 --cluster 10.0.0.2:8080 --rules wrong-cluster
+  jpy middleware license heal --dry-run --output json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			if opts.Rules, err = license.ParseIssues(rules); err != nil {
+				return err
+			}
+			if opts.RenewDays <= 0 {
+				return fmt.Errorf("--renew-days 必须大于 0")
+			}
+
+			cfg, err := config.Load()
+			if err != nil {
+				return err
+			}
+			group, servers, err := selectServers(cfg, group, pattern)
+			if err != nil {
+				return err
+			}
+
+			output.Notef("当前分组: %s，正在检查 %d 台服务器的授权...\n", group, len(servers))
+			svc := license.NewService(cfg)
+			findings := svc.Diagnose(servers, opts)
+
+			var broken []license.Finding
+			needsAdmin, failed := false, 0
+			for _, f := range findings {
+				switch {
+				case f.Error != nil:
+					failed++
+				case len(f.Issues) > 0:
+					broken = append(broken, f)
+					needsAdmin = needsAdmin || f.NeedsAdmin()
+				}
+			}
+
+			if len(broken) == 0 {
+				output.Notef("未发现授权异常 (检查失败 %d 台)。\n", failed)
+				for _, f := range findings {
+					if f.Error != nil {
+						output.Notef("❌ %s: %v\n", f.Server.URL, f.Error)
+					}
+				}
+				if output.Structured() {
+					return output.Print("LicenseHealList", []license.HealResult{})
+				}
+				return nil
+			}
+
+			output.Notef("\n发现 %d 台服务器授权异常:\n", len(broken))
+			for _, f := range broken {
+				output.Notef(" - %s (%s): %s\n", f.Server.URL, f.License.Sn, issueList(f.Issues))
+			}
+
+			if !opts.DryRun && !yes {
+				if err := tui.RequirePrompt("请使用 --yes 跳过确认"); err != nil {
+					return err
+				}
+				output.Notef("\n是否修复以上服务器? [y/N]: ")
+				answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+				answer = strings.ToLower(strings.TrimSpace(answer))
+				if answer != "y" && answer != "yes" {
+					output.Notef("操作已取消。\n")
+					return nil
+				}
+			}
+
+			var adminClient *adminApi.Client
+			if needsAdmin && !opts.DryRun {
+				adminCfg, err := service.EnsureAuthLoggedIn()
+				if err != nil {
+					return err
+				}
+				adminClient = service.NewClient(adminCfg)
+			}
+
+			results := svc.Heal(adminClient, broken, opts)
+
+			if output.Structured() {
+				return output.Print("LicenseHealList", results)
+			}
+			return printHealResults(results, failed, opts.DryRun)
+		},
+	}
+
+	cmd.Flags().StringVarP(&group, "group", "g", "", "目标服务器分组")
+	cmd.Flags().StringVarP(&pattern, "server", "s", "", "服务器地址匹配模式")
+	cmd.Flags().StringVar(&rules, "rules", "missing-control,wrong-cluster,expired", "启用的修复规则 (逗号分隔)")
+	cmd.Flags().StringVar(&opts.Cluster, "cluster", "", "期望的集控平台地址 (用于 wrong-cluster)")
+	cmd.Flags().IntVar(&opts.RenewDays, "renew-days", 365, "过期授权的续期天数")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "仅显示计划执行的操作")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "跳过确认")
+	login.AddLoginFlags(cmd)
+
+	return cmd
+}
+
+func printHealResults(results []license.HealResult, checkFailed int, dryRun bool) error {
+	fmt.Println()
+	fixed, failed := 0, 0
+	for _, r := range results {
+		prefix := "✅"
+		switch {
+		case r.Error != "":
+			prefix = "❌"
+			failed++
+		case dryRun:
+			prefix = "📝"
+		case !r.Fixed:
+			prefix = "⚠️"
+			failed++
+		default:
+			fixed++
+		}
+		fmt.Printf("%s %s (%s) [%s]\n", prefix, r.Server, r.SN, issueList(r.Issues))
+		for _, a := range r.Actions {
+			fmt.Printf("     - %s\n", a)
+		}
+		if r.Error != "" {
+			fmt.Printf("     错误: %s\n", r.Error)
+		} else if len(r.Remains) > 0 {
+			fmt.Printf("     修复后仍存在: %s\n", issueList(r.Remains))
+		}
+	}
+
+	if dryRun {
+		fmt.Printf("\n预演完成: %d 台服务器待修复，检查失败 %d 台 (未执行任何操作)\n", len(results)-failed, checkFailed)
+		return nil
+	}
+	fmt.Printf("\n修复完成: 成功 %d, 失败 %d, 检查失败 %d\n", fixed, failed, checkFailed)
+	if failed > 0 {
+		return fmt.Errorf("%d 台服务器修复失败", failed)
+	}
+	return nil
+}
+
+func issueList(issues []license.Issue) string {
+	names := make([]string, len(issues))
+	for i, issue := range issues {
+		names[i] = issue.String()
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,50 @@
+package license
+
+import (
+	"fmt"
+	"jpy-cli/pkg/config"
+	"jpy-cli/pkg/middleware/device/selector"
+
+	"github.com/spf13/cobra"
+)
+
+func NewLicenseCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "license",
+		Short: "中间件授权管理",
+	}
+
+	cmd.AddCommand(NewReportCmd())
+	cmd.AddCommand(NewHealCmd())
+
+	return cmd
+}
+
+// selectServers returns the enabled servers of a group (default: the active group) matching pattern.
+func selectServers(cfg *config.Config, group, pattern string) (string, []config.LocalServerConfig, error) {
+	if group == "" {
+		group = cfg.ActiveGroup
+	}
+	if group == "" {
+		group = "default"
+	}
+
+	matcher, err := selector.ParseServerPattern(pattern)
+	if err != nil {
+		return group, nil, err
+	}
+
+	var targets []config.LocalServerConfig
+	for _, s := range config.GetGroupServers(cfg, group) {
+		if s.Disabled {
+			continue
+		}
+		if matcher.Match(s.URL) {
+			targets = append(targets, s)
+		}
+	}
+	if len(targets) == 0 {
+		return group, nil, fmt.Errorf("分组 '%s' 中未找到匹配的服务器", group)
+	}
+	return group, targets, nil
+}
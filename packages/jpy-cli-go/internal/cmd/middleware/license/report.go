@@ -0,0 +1,197 @@
+package license
+
+import (
+	"bufio"
+	"fmt"
+	"jpy-cli/internal/cmd/admin/login"
+	"jpy-cli/pkg/admin-middleware/service"
+	"jpy-cli/pkg/config"
+	"jpy-cli/pkg/middleware/license"
+	"jpy-cli/pkg/output"
+	"jpy-cli/pkg/tui"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func NewReportCmd() *cobra.Command {
+	var (
+		group        string
+		pattern      string
+		within       int
+		expiringOnly bool
+		renew        bool
+		renewDays    int
+		yes          bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "授权到期与席位使用报告",
+		Long: `检查每台服务器的授权，计算剩余天数和席位使用率 (已用/上限)，
+并标记已过期或在 --within 天内到期的授权。
+
+使用 --renew 时，对标记的授权在管理后台延长 --renew-days 天，
+再向服务器重新提交授权码 (Reauthorize) 并刷新授权信息。
+使用 --output 输出报告；与 --renew 一起使用时输出续期结果。`,
+		Example: `  jpy middleware license report
+  jpy middleware license report -g rack1 --within 15 --expiring
+  jpy middleware license report --renew --renew-days 365 --yes
+  jpy middleware license report --expiring --output json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if renew && renewDays <= 0 {
+				return fmt.Errorf("--renew-days 必须大于 0")
+			}
+
+			cfg, err := config.Load()
+			if err != nil {
+				return err
+			}
+			group, servers, err := selectServers(cfg, group, pattern)
+			if err != nil {
+				return err
+			}
+
+			output.Notef("当前分组: %s，正在检查 %d 台服务器的授权...\n", group, len(servers))
+			svc := license.NewService(cfg)
+			reports := svc.Report(servers, within)
+
+			var flagged []license.Report
+			shown := make([]license.Report, 0, len(reports))
+			for _, r := range reports {
+				if r.Expiring && r.License != nil {
+					flagged = append(flagged, r)
+				}
+				if !expiringOnly || r.Expiring || r.Error != "" {
+					shown = append(shown, r)
+				}
+			}
+
+			switch {
+			case !renew && output.Structured():
+				return output.Print("LicenseReportList", shown)
+			case !output.Structured():
+				printReports(shown, within)
+				printSummary(reports, flagged, within)
+			}
+			if !renew {
+				return nil
+			}
+			if len(flagged) == 0 {
+				if output.Structured() {
+					return output.Print("LicenseRenewList", []license.RenewResult{})
+				}
+				return nil
+			}
+
+			if !yes {
+				if err := tui.RequirePrompt("请使用 --yes 跳过确认"); err != nil {
+					return err
+				}
+				output.Notef("\n将为 %d 个授权延长 %d 天并重新提交。是否继续? [y/N]: ", len(flagged), renewDays)
+				answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+				answer = strings.ToLower(strings.TrimSpace(answer))
+				if answer != "y" && answer != "yes" {
+					output.Notef("操作已取消。\n")
+					return nil
+				}
+			}
+
+			adminCfg, err := service.EnsureAuthLoggedIn()
+			if err != nil {
+				return err
+			}
+			adminClient := service.NewClient(adminCfg)
+
+			byURL := make(map[string]config.LocalServerConfig, len(servers))
+			for _, s := range servers {
+				byURL[s.URL] = s
+			}
+
+			results := make([]license.RenewResult, 0, len(flagged))
+			failed := 0
+			for i, r := range flagged {
+				output.Notef("[%d/%d] 正在续期 %s (%s)... ", i+1, len(flagged), r.Server, r.License.Sn)
+				res := svc.Renew(adminClient, byURL[r.Server], r.License.Sn, renewDays, within)
+				results = append(results, res)
+				if res.Error != "" {
+					failed++
+					output.Notef("失败: %s\n", res.Error)
+				} else {
+					output.Notef("成功 (后台 %d -> %d 天，%s)\n", res.OldDays, res.NewDays, expiryText(res.After))
+				}
+			}
+
+			if output.Structured() {
+				if err := output.Print("LicenseRenewList", results); err != nil {
+					return err
+				}
+			} else {
+				fmt.Printf("\n续期完成。成功: %d, 失败: %d\n", len(results)-failed, failed)
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d 个授权续期失败", failed)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&group, "group", "g", "", "目标服务器分组")
+	cmd.Flags().StringVarP(&pattern, "server", "s", "", "服务器地址匹配模式")
+	cmd.Flags().IntVar(&within, "within", 30, "到期预警天数")
+	cmd.Flags().BoolVar(&expiringOnly, "expiring", false, "仅显示即将到期/已过期及检查失败的服务器")
+	cmd.Flags().BoolVar(&renew, "renew", false, "延长即将到期的授权并重新提交")
+	cmd.Flags().IntVar(&renewDays, "renew-days", 365, "续期天数")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "跳过确认")
+	login.AddLoginFlags(cmd)
+
+	return cmd
+}
+
+func printReports(reports []license.Report, within int) {
+	fmt.Printf("\n%-28s %-36s %-8s %-12s %-8s %-14s %s\n", "服务器", "序列号", "状态", "到期日", "剩余天数", "席位", "备注")
+	fmt.Println(strings.Repeat("-", 130))
+	for _, r := range reports {
+		if r.Error != "" {
+			fmt.Printf("%-28s %-36s %-8s %-12s %-8s %-14s %s\n", r.Server, "-", "-", "-", "-", "-", "❌ "+r.Error)
+			continue
+		}
+		expiry, days := "-", "-"
+		if r.HasExpiry() {
+			expiry = r.ExpiresAt.Format("2006-01-02")
+			days = fmt.Sprintf("%d", r.DaysLeft)
+		}
+		seats := fmt.Sprintf("%d/%d", r.Used, r.Limit)
+		if u := r.Utilisation(); u >= 0 {
+			seats += fmt.Sprintf(" (%.0f%%)", u)
+		}
+		note := ""
+		switch {
+		case r.HasExpiry() && r.DaysLeft < 0:
+			note = "⛔ 已过期"
+		case r.Expiring:
+			note = fmt.Sprintf("⚠️ %d 天内到期", within)
+		}
+		fmt.Printf("%-28s %-36s %-8s %-12s %-8s %-14s %s\n", r.Server, r.License.Sn, r.Status, expiry, days, seats, note)
+	}
+}
+
+func printSummary(all, flagged []license.Report, within int) {
+	expired, failed := 0, 0
+	for _, r := range all {
+		if r.Error != "" {
+			failed++
+		} else if r.HasExpiry() && r.DaysLeft < 0 {
+			expired++
+		}
+	}
+	fmt.Printf("\n共 %d 台服务器: 已过期 %d, %d 天内到期 %d, 检查失败 %d\n", len(all), expired, within, len(flagged)-expired, failed)
+}
+
+func expiryText(r license.Report) string {
+	if !r.HasExpiry() {
+		return "未返回到期时间"
+	}
+	return fmt.Sprintf("新到期日 %s，剩余 %d 天", r.ExpiresAt.Format("2006-01-02"), r.DaysLeft)
+}
@@ -4,6 +4,7 @@ import (
 	"jpy-cli/internal/cmd/middleware/admin"
 	"jpy-cli/internal/cmd/middleware/auth"
 	"jpy-cli/internal/cmd/middleware/device"
+	"jpy-cli/internal/cmd/middleware/license"
 
 	"github.com/spf13/cobra"
 )
@@ -17,6 +18,7 @@ func NewMiddlewareCmd() *cobra.Command {
 	cmd.AddCommand(auth.NewAuthCmd())
 	cmd.AddCommand(device.NewDeviceCmd())
 	cmd.AddCommand(admin.NewAdminCmd())
+	cmd.AddCommand(license.NewLicenseCmd())
 	cmd.AddCommand(NewSSHCmd())
 	cmd.AddCommand(NewRemoveCmd())
 	cmd.AddCommand(NewReloginCmd())
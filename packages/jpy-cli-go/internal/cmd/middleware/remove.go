@@ -6,6 +6,7 @@ import (
 	"jpy-cli/pkg/config"
 	"jpy-cli/pkg/middleware/connector"
 	"jpy-cli/pkg/middleware/device/selector"
+	"jpy-cli/pkg/tui"
 	"os"
 	"strings"
 	"sync"
@@ -18,6 +19,7 @@ func NewRemoveCmd() *cobra.Command {
 	var hasError bool
 	var force bool
 	var search string
+	var yes bool
 
 	cmd := &cobra.Command{
 		Use:   "remove",
@@ -58,14 +60,21 @@ func NewRemoveCmd() *cobra.Command {
 					indices = append(indices, idx)
 				}
 			} else if search != "" {
+				pattern, err := selector.ParseServerPattern(search)
+				if err != nil {
+					return err
+				}
 				for i, s := range servers {
-					if selector.MatchServerPattern(s.URL, search) || selector.MatchServerPattern(s.Username, search) {
+					if pattern.Match(s.URL) || pattern.MatchText(s.Username) {
 						targets = append(targets, &servers[i])
 						indices = append(indices, i)
 					}
 				}
 			} else {
 				// Interactive mode
+				if err := tui.RequirePrompt("请使用 --all、--has-error 或 --search 指定要移除的服务器"); err != nil {
+					return err
+				}
 				fmt.Println("进入交互模式...")
 				fmt.Printf("当前分组 (%s) 服务器列表:\n", activeGroup)
 				for i, s := range servers {
@@ -136,8 +145,14 @@ func NewRemoveCmd() *cobra.Command {
 				fmt.Printf(" - %s\n", t.URL)
 			}
 
-			if !confirmAction() {
-				return nil
+			if !yes {
+				ok, err := confirmAction()
+				if err != nil {
+					return err
+				}
+				if !ok {
+					return nil
+				}
 			}
 
 			// 3. Execute
@@ -190,7 +205,8 @@ func NewRemoveCmd() *cobra.Command {
 	cmd.Flags().BoolVar(&removeAll, "all", false, "删除当前分组内的所有服务器")
 	cmd.Flags().BoolVar(&hasError, "has-error", false, "只删除连接失败的服务器")
 	cmd.Flags().BoolVar(&force, "force", false, "永久删除 (不提供则为软删除)")
-	cmd.Flags().StringVar(&search, "search", "", "模糊匹配删除")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "跳过确认")
+	cmd.Flags().StringVar(&search, "search", "", "按地址或用户名匹配删除 (支持通配符/re:正则/CIDR/端口范围，|分隔，!前缀排除)")
 
 	return cmd
 }
@@ -230,9 +246,12 @@ func checkConnectionFailures(servers []config.LocalServerConfig, cfg *config.Con
 	return failedIndices
 }
 
-func confirmAction() bool {
+func confirmAction() (bool, error) {
+	if err := tui.RequirePrompt("请使用 --yes 跳过确认"); err != nil {
+		return false, err
+	}
 	reader := bufio.NewReader(os.Stdin)
 	fmt.Print("确认执行? [y/N]: ")
 	text, _ := reader.ReadString('\n')
-	return strings.ToLower(strings.TrimSpace(text)) == "y"
+	return strings.ToLower(strings.TrimSpace(text)) == "y", nil
 }
@@ -64,17 +64,5 @@ func shouldEnterInteractive(cmd *cobra.Command, opts *device.CommonFlags) bool {
 		return false
 	}
 
-	filterFlags := []string{
-		"group", "server", "uuid", "seat",
-		"filter-adb", "filter-usb", "filter-online", "filter-has-ip",
-		"authorized",
-	}
-
-	for _, name := range filterFlags {
-		if cmd.Flags().Changed(name) {
-			return false
-		}
-	}
-
-	return true
+	return !device.HasSelector(cmd)
 }
@@ -3,6 +3,7 @@ package middleware
 import (
 	"fmt"
 	"jpy-cli/pkg/config"
+	"jpy-cli/pkg/output"
 	"os"
 	"text/tabwriter"
 
@@ -40,6 +41,13 @@ func NewListCmd() *cobra.Command {
 				displayList = servers
 			}
 
+			if output.Structured() {
+				// Machine-readable output is unpaged unless --page/--size are given
+				if !cmd.Flags().Changed("page") && !cmd.Flags().Changed("size") {
+					return output.Print("ServerList", serverRecords(displayList, 0))
+				}
+			}
+
 			total := len(displayList)
 			start := (page - 1) * pageSize
 			end := start + pageSize
@@ -50,6 +58,9 @@ func NewListCmd() *cobra.Command {
 			if end > total {
 				end = total
 			}
+			if output.Structured() {
+				return output.Print("ServerList", serverRecords(displayList[start:end], start))
+			}
 
 			fmt.Printf("当前分组: %s (总数: %d, 显示: %d-%d)\n", activeGroup, total, start+1, end)
 			
@@ -76,3 +87,30 @@ func NewListCmd() *cobra.Command {
 
 	return cmd
 }
+
+// ServerRecord is the ServerList item of structured output.
+type ServerRecord struct {
+	Index          int    `json:"index"`
+	URL            string `json:"url"`
+	Username       string `json:"username"`
+	Group          string `json:"group"`
+	Disabled       bool   `json:"disabled"`
+	LastLoginTime  string `json:"last_login_time"`
+	LastLoginError string `json:"last_login_error"`
+}
+
+func serverRecords(servers []config.LocalServerConfig, offset int) []ServerRecord {
+	records := make([]ServerRecord, 0, len(servers))
+	for i, s := range servers {
+		records = append(records, ServerRecord{
+			Index:          offset + i + 1,
+			URL:            s.URL,
+			Username:       s.Username,
+			Group:          s.Group,
+			Disabled:       s.Disabled,
+			LastLoginTime:  s.LastLoginTime,
+			LastLoginError: s.LastLoginError,
+		})
+	}
+	return records
+}
@@ -3,7 +3,6 @@ package middleware
 import (
 	"encoding/base64"
 	"fmt"
-	"jpy-cli/pkg/admin-middleware/api"
 	"jpy-cli/pkg/admin-middleware/service"
 	"net"
 	"os/exec"
@@ -47,7 +46,7 @@ func NewSSHCmd() *cobra.Command {
 
 			// 4. Decrypt Password
 			fmt.Println("正在解密 Root 密码...")
-			client := api.NewClient(adminCfg.Token)
+			client := service.NewClient(adminCfg)
 			rawPassword, err := client.DecryptPassword(bannerKey)
 			if err != nil {
 				return fmt.Errorf("解密密码失败: %v", err)
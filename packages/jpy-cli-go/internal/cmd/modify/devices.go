@@ -0,0 +1,56 @@
+package modify
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func newDevicesCmd(opts *connOptions) *cobra.Command {
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   "devices",
+		Short: "查看改机服务的设备列表",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := connect(opts)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			devices, err := client.ListDevices()
+			if err != nil {
+				return fmt.Errorf("获取设备列表失败: %v", err)
+			}
+			sort.Slice(devices, func(i, j int) bool { return devices[i].ID < devices[j].ID })
+
+			if jsonOutput {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(devices)
+			}
+
+			fmt.Printf("%-10s %-6s %-36s %-16s %-20s %s\n", "设备ID", "机位", "UUID", "IP", "型号", "在线")
+			fmt.Println(strings.Repeat("-", 100))
+			online := 0
+			for _, d := range devices {
+				status := "否"
+				if d.Online {
+					status = "是"
+					online++
+				}
+				fmt.Printf("%-10d %-6d %-36s %-16s %-20s %s\n", d.ID, d.Seat, d.UUID, d.IP, d.Model, status)
+			}
+			fmt.Printf("\n共 %d 台设备，在线 %d 台\n", len(devices), online)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "以 JSON 格式输出")
+	return cmd
+}
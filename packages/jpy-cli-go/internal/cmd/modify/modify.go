@@ -0,0 +1,70 @@
+package modify
+
+import (
+	"fmt"
+	"jpy-cli/pkg/config"
+	modify "jpy-cli/pkg/device-modify"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// connOptions are the persistent flags shared by all modify subcommands.
+type connOptions struct {
+	URL       string
+	Heartbeat int
+}
+
+func NewModifyCmd() *cobra.Command {
+	opts := &connOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "modify",
+		Short: "改机任务管理",
+		Long: `连接改机服务 (WebSocket)，查看设备、下发改机任务并跟踪执行状态。
+
+首次使用需通过 --url 指定服务地址，之后会记住上次使用的地址。
+连接与请求超时由全局参数 --timeout 与 --request-timeout 控制。`,
+	}
+
+	cmd.PersistentFlags().StringVar(&opts.URL, "url", "", "改机服务 WebSocket 地址 (例如: ws://192.168.1.10:8080)")
+	cmd.PersistentFlags().IntVar(&opts.Heartbeat, "heartbeat", int(modify.DefaultHeartbeatInterval/time.Second), "心跳间隔 (秒)")
+
+	cmd.AddCommand(newDevicesCmd(opts))
+	cmd.AddCommand(newRunCmd(opts))
+	cmd.AddCommand(newStatusCmd(opts))
+
+	return cmd
+}
+
+// connect resolves the service URL (flag, then saved config), remembers it and connects.
+func connect(opts *connOptions) (*modify.Client, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	url := opts.URL
+	if url == "" {
+		url = cfg.ModifyURL
+	}
+	if url == "" {
+		return nil, fmt.Errorf("请通过 --url 指定改机服务地址")
+	}
+
+	client := modify.NewClient(url)
+	client.HeartbeatInterval = time.Duration(opts.Heartbeat) * time.Second
+	client.ConnectTimeout = config.GlobalSettings.ConnectTimeoutDuration()
+	client.Timeout = config.GlobalSettings.RequestTimeoutDuration()
+	if err := client.Connect(); err != nil {
+		return nil, fmt.Errorf("连接改机服务失败: %v", err)
+	}
+
+	if url != cfg.ModifyURL {
+		cfg.ModifyURL = url
+		if err := config.Save(cfg); err != nil {
+			fmt.Printf("警告: 保存改机服务地址失败: %v\n", err)
+		}
+	}
+	return client, nil
+}
@@ -0,0 +1,160 @@
+package modify
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	modify "jpy-cli/pkg/device-modify"
+	"jpy-cli/pkg/tui"
+
+	"github.com/spf13/cobra"
+)
+
+func newRunCmd(opts *connOptions) *cobra.Command {
+	var (
+		all            bool
+		uuid           string
+		includeOffline bool
+		yes            bool
+		noWait         bool
+		interval       int
+		maxWait        int
+		jsonOutput     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "run [deviceId...]",
+		Short: "对所选设备下发改机任务并跟踪到完成",
+		Long: `对指定设备下发改机任务 (f=515)，随后轮询主任务状态 (f=612) 直到所有设备完成。
+
+设备选择:
+  直接指定设备ID，或使用 --all 选择全部设备，--uuid 按 UUID 模糊匹配。
+  默认跳过离线设备，--include-offline 包含离线设备。
+
+跟踪过程中按 Ctrl+C 仅停止跟踪，任务会继续执行，可用 'jpy modify status <任务ID> --watch' 继续查看。`,
+		Example: `  jpy modify run 101 102 103 --url ws://192.168.1.10:8080
+  jpy modify run --all --yes
+  jpy modify run --uuid ABC --no-wait`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 && !all && uuid == "" {
+				return fmt.Errorf("请指定设备ID，或使用 --all / --uuid 选择设备")
+			}
+			if interval <= 0 {
+				return fmt.Errorf("--interval 必须大于 0")
+			}
+
+			client, err := connect(opts)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			devices, err := client.ListDevices()
+			if err != nil {
+				return fmt.Errorf("获取设备列表失败: %v", err)
+			}
+
+			targets, skipped, err := selectDevices(devices, args, all, uuid, includeOffline)
+			if err != nil {
+				return err
+			}
+			if skipped > 0 {
+				fmt.Printf("已跳过 %d 台离线设备\n", skipped)
+			}
+			if len(targets) == 0 {
+				fmt.Println("没有符合条件的设备。")
+				return nil
+			}
+
+			if !yes {
+				if err := tui.RequirePrompt("请使用 --yes 跳过确认"); err != nil {
+					return err
+				}
+				fmt.Printf("即将对 %d 台设备执行改机，是否继续? [y/N]: ", len(targets))
+				answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+				answer = strings.ToLower(strings.TrimSpace(answer))
+				if answer != "y" && answer != "yes" {
+					fmt.Println("操作已取消。")
+					return nil
+				}
+			}
+
+			mainTaskID, err := client.StartModify(targets)
+			if err != nil {
+				return fmt.Errorf("下发改机任务失败: %v", err)
+			}
+			fmt.Fprintf(os.Stderr, "改机任务已下发: %s (%d 台设备)\n", mainTaskID, len(targets))
+
+			if noWait {
+				fmt.Println(mainTaskID)
+				return nil
+			}
+
+			return trackTask(opts, client, mainTaskID, trackOptions{
+				Interval:   time.Duration(interval) * time.Second,
+				MaxWait:    time.Duration(maxWait) * time.Second,
+				JSONOutput: jsonOutput,
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&all, "all", false, "选择全部设备")
+	cmd.Flags().StringVarP(&uuid, "uuid", "u", "", "按 UUID 模糊匹配设备")
+	cmd.Flags().BoolVar(&includeOffline, "include-offline", false, "包含离线设备")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "跳过确认")
+	cmd.Flags().BoolVar(&noWait, "no-wait", false, "下发后立即返回任务ID，不跟踪")
+	cmd.Flags().IntVar(&interval, "interval", 3, "状态轮询间隔 (秒)")
+	cmd.Flags().IntVar(&maxWait, "max-wait", 0, "最长跟踪时间 (秒)，0 表示不限")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "以 JSON 格式输出最终结果")
+
+	return cmd
+}
+
+// selectDevices resolves explicit IDs or the --all/--uuid filters against the device list.
+// Explicit IDs must exist; offline devices are skipped unless includeOffline is set.
+func selectDevices(devices []modify.Device, ids []string, all bool, uuid string, includeOffline bool) ([]int64, int, error) {
+	byID := make(map[int64]modify.Device, len(devices))
+	for _, d := range devices {
+		byID[d.ID] = d
+	}
+
+	var candidates []modify.Device
+	if len(ids) > 0 {
+		for _, arg := range ids {
+			id, err := strconv.ParseInt(arg, 10, 64)
+			if err != nil {
+				return nil, 0, fmt.Errorf("无效的设备ID: %s", arg)
+			}
+			d, ok := byID[id]
+			if !ok {
+				return nil, 0, fmt.Errorf("设备 %d 不存在", id)
+			}
+			candidates = append(candidates, d)
+		}
+	} else {
+		candidates = devices
+	}
+
+	var targets []int64
+	skipped := 0
+	seen := make(map[int64]bool)
+	for _, d := range candidates {
+		if seen[d.ID] {
+			continue
+		}
+		seen[d.ID] = true
+		if uuid != "" && !strings.Contains(strings.ToLower(d.UUID), strings.ToLower(uuid)) {
+			continue
+		}
+		if !d.Online && !includeOffline {
+			skipped++
+			continue
+		}
+		targets = append(targets, d.ID)
+	}
+	return targets, skipped, nil
+}
@@ -0,0 +1,63 @@
+package modify
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func newStatusCmd(opts *connOptions) *cobra.Command {
+	var (
+		watch      bool
+		interval   int
+		maxWait    int
+		jsonOutput bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "status <mainTaskId>",
+		Short: "查询改机任务状态",
+		Long: `查询改机主任务 (f=612) 及每台设备的执行状态。
+
+使用 --watch 持续跟踪直到所有设备完成。`,
+		Example: `  jpy modify status 6f1c2a
+  jpy modify status 6f1c2a --watch --interval 5`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if interval <= 0 {
+				return fmt.Errorf("--interval 必须大于 0")
+			}
+
+			client, err := connect(opts)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			if watch {
+				return trackTask(opts, client, args[0], trackOptions{
+					Interval:   time.Duration(interval) * time.Second,
+					MaxWait:    time.Duration(maxWait) * time.Second,
+					JSONOutput: jsonOutput,
+				})
+			}
+
+			status, err := client.QueryStatus(args[0])
+			if err != nil {
+				return fmt.Errorf("查询改机状态失败: %v", err)
+			}
+			if !status.Finished() && !jsonOutput {
+				fmt.Println(progressLine(status))
+			}
+			return reportTask(status, jsonOutput)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&watch, "watch", "w", false, "持续跟踪直到完成")
+	cmd.Flags().IntVar(&interval, "interval", 3, "状态轮询间隔 (秒)")
+	cmd.Flags().IntVar(&maxWait, "max-wait", 0, "最长跟踪时间 (秒)，0 表示不限")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "以 JSON 格式输出")
+
+	return cmd
+}
@@ -0,0 +1,145 @@
+package modify
+
+import (
+	"encoding/json"
+	"fmt"
+	"jpy-cli/pkg/logger"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	modify "jpy-cli/pkg/device-modify"
+)
+
+// maxQueryFailures is the number of consecutive failed status queries before tracking gives up.
+const maxQueryFailures = 5
+
+type trackOptions struct {
+	Interval   time.Duration
+	MaxWait    time.Duration
+	JSONOutput bool
+}
+
+// trackTask polls the main task until every device finishes, printing a progress
+// line whenever the counts change. A lost connection is re-established.
+func trackTask(opts *connOptions, client *modify.Client, mainTaskID string, topts trackOptions) error {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+
+	var deadline <-chan time.Time
+	if topts.MaxWait > 0 {
+		deadline = time.After(topts.MaxWait)
+	}
+
+	// Reconnected clients are owned here; the original one belongs to the caller
+	current := client
+	defer func() {
+		if current != client {
+			current.Close()
+		}
+	}()
+
+	start := time.Now()
+	lastLine := ""
+	failures := 0
+	ticker := time.NewTicker(topts.Interval)
+	defer ticker.Stop()
+
+	for {
+		status, err := current.QueryStatus(mainTaskID)
+		if err != nil {
+			failures++
+			logger.Warnf("查询改机状态失败 (%d/%d): %v", failures, maxQueryFailures, err)
+			if failures >= maxQueryFailures {
+				return fmt.Errorf("查询改机状态连续失败: %v", err)
+			}
+			select {
+			case <-current.Done():
+				if c, err := connect(opts); err == nil {
+					if current != client {
+						current.Close()
+					}
+					current = c
+				}
+			default:
+			}
+		} else {
+			failures = 0
+			if line := progressLine(status); line != lastLine {
+				fmt.Fprintf(os.Stderr, "[%s] %s\n", time.Since(start).Truncate(time.Second), line)
+				lastLine = line
+			}
+			if status.Finished() {
+				return reportTask(status, topts.JSONOutput)
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			return fmt.Errorf("等待超时，任务仍在执行。可稍后使用 'jpy modify status %s --watch' 继续跟踪", mainTaskID)
+		case <-sigChan:
+			fmt.Fprintf(os.Stderr, "\n已停止跟踪，任务仍在执行。继续跟踪: jpy modify status %s --watch\n", mainTaskID)
+			return nil
+		}
+	}
+}
+
+func progressLine(status *modify.TaskStatus) string {
+	if len(status.Tasks) == 0 {
+		return fmt.Sprintf("任务 %s: %s", status.MainTaskID, status.Status)
+	}
+	counts := status.Counts()
+	return fmt.Sprintf("成功 %d / 失败 %d / 执行中 %d / 等待中 %d (共 %d)",
+		counts[modify.StateSuccess], counts[modify.StateFailed],
+		counts[modify.StateRunning], counts[modify.StatePending], len(status.Tasks))
+}
+
+// reportTask prints per-device results and fails when any device failed.
+func reportTask(status *modify.TaskStatus, jsonOutput bool) error {
+	tasks := append([]modify.SubTask(nil), status.Tasks...)
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].DeviceID < tasks[j].DeviceID })
+
+	if jsonOutput {
+		out := *status
+		out.Tasks = tasks
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(out); err != nil {
+			return err
+		}
+	} else {
+		printTasks(status.MainTaskID, status.Status, tasks)
+	}
+
+	if failed := status.Counts()[modify.StateFailed]; failed > 0 {
+		return fmt.Errorf("%d 台设备改机失败", failed)
+	}
+	if len(tasks) == 0 && status.Status == modify.StateFailed {
+		return fmt.Errorf("改机任务失败")
+	}
+	return nil
+}
+
+func printTasks(mainTaskID string, state modify.TaskState, tasks []modify.SubTask) {
+	fmt.Printf("任务 %s: %s\n", mainTaskID, state)
+	if len(tasks) == 0 {
+		return
+	}
+	fmt.Printf("%-10s %-8s %-6s %s\n", "设备ID", "状态", "进度", "信息")
+	fmt.Println(strings.Repeat("-", 60))
+	for _, t := range tasks {
+		mark := "  "
+		switch t.Status {
+		case modify.StateSuccess:
+			mark = "✅"
+		case modify.StateFailed:
+			mark = "❌"
+		}
+		fmt.Printf("%-10d %s %-6s %3d%%  %s\n", t.DeviceID, mark, t.Status, t.Progress, t.Msg)
+	}
+}
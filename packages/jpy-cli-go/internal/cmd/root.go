@@ -1,69 +1,60 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	adminAuth "jpy-cli/internal/cmd/admin/auth"
+	adminDHCP "jpy-cli/internal/cmd/admin/dhcp"
+	adminEnv "jpy-cli/internal/cmd/admin/env"
+	adminLogin "jpy-cli/internal/cmd/admin/login"
 	adminMiddleware "jpy-cli/internal/cmd/admin/middleware"
 	configCmd "jpy-cli/internal/cmd/config"
 	logCmd "jpy-cli/internal/cmd/log"
 	"jpy-cli/internal/cmd/middleware"
+	modifyCmd "jpy-cli/internal/cmd/modify"
 	"jpy-cli/internal/cmd/proxy"
 	"jpy-cli/internal/cmd/server"
 	"jpy-cli/internal/cmd/tools"
 	"jpy-cli/pkg/config"
 	"jpy-cli/pkg/logger"
+	"jpy-cli/pkg/output"
+	"jpy-cli/pkg/tui"
 	"os"
 	"path/filepath"
 
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
+	"golang.org/x/term"
 )
 
 var (
-	debug    bool
-	logLevel string
+	debug      bool
+	logLevel   string
+	outputSpec string
+	noTUI      bool
+	contextArg string
 )
 
-func loadConfig() *config.Settings {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return nil
-	}
-
-	configDir := filepath.Join(home, ".jpy")
-	configFile := filepath.Join(configDir, "config.yaml")
-
-	// Check if config exists, if not create it
-	if _, err := os.Stat(configFile); os.IsNotExist(err) {
-		_ = os.MkdirAll(configDir, 0755)
-		defaultConfig := []byte(`log_level: info
-log_output: file # console, file, both
-max_concurrency: 5
-connect_timeout: 3 # seconds
-`)
-		if err := os.WriteFile(configFile, defaultConfig, 0644); err != nil {
-			fmt.Printf("警告: 创建默认配置文件失败 %s: %v\n", configFile, err)
-		} else {
-			fmt.Printf("已创建默认配置文件 %s\n", configFile)
-		}
-	}
-
-	// Read config
-	data, err := os.ReadFile(configFile)
-	if err != nil {
-		return nil
-	}
-
-	var cfg config.Settings
-	if err := yaml.Unmarshal(data, &cfg); err == nil {
-		return &cfg
-	}
-	return nil
+// settingFlags maps global flags to the config.yaml keys they override.
+// Commands defining a local flag of the same name shadow the global one.
+var settingFlags = map[string]string{
+	"log-level":       "log_level",
+	"concurrency":     "max_concurrency",
+	"timeout":         "connect_timeout",
+	"request-timeout": "request_timeout",
 }
 
 var rootCmd = &cobra.Command{
 	Use:   "jpy",
 	Short: "JPY 中间件命令行工具",
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		outputOpts, err := output.Parse(outputSpec)
+		if err != nil {
+			return err
+		}
+		output.Set(outputOpts)
+		tui.SetNoTUI(noTUI)
+		config.SetContextOverride(contextArg)
+
 		// Strict log file path: ~/.jpy/logs/jpy.log
 		home, err := os.UserHomeDir()
 		if err != nil {
@@ -77,31 +68,29 @@ var rootCmd = &cobra.Command{
 		}
 		logPath := filepath.Join(logDir, "jpy.log")
 
-		level := "info"
-		logOutput := "file" // Default to file only
+		if path, created, err := config.InitSettingsFile(); err != nil {
+			output.Notef("警告: 创建默认配置文件失败 %s: %v\n", path, err)
+		} else if created {
+			output.Notef("已创建默认配置文件 %s\n", path)
+		}
 
-		// Load config from file
-		if cfg := loadConfig(); cfg != nil {
-			config.GlobalSettings = *cfg
-			if cfg.LogLevel != "" {
-				level = cfg.LogLevel
-			}
-			if cfg.LogOutput != "" {
-				logOutput = cfg.LogOutput
+		// defaults < config.yaml < context < JPY_* environment < flags
+		flags := map[string]string{}
+		for name, key := range settingFlags {
+			if f := cmd.Root().PersistentFlags().Lookup(name); f.Changed {
+				flags[key] = f.Value.String()
 			}
 		}
-
-		// Set default concurrency if not set
-		if config.GlobalSettings.MaxConcurrency == 0 {
-			config.GlobalSettings.MaxConcurrency = 5
+		settings, err := config.ResolveSettings(flags)
+		if errors.Is(err, config.ErrSettingsIgnored) {
+			output.Notef("警告: %v (运行 jpy config validate 查看详情)\n", err)
+		} else if err != nil {
+			return err
 		}
+		config.GlobalSettings = settings
+		level := settings.LogLevel
+		logOutput := settings.LogOutput
 
-		// Set default connect timeout if not set
-		if config.GlobalSettings.ConnectTimeout == 0 {
-			config.GlobalSettings.ConnectTimeout = 3
-		}
-
-		// Flag overrides
 		if debug {
 			level = "debug"
 			// If user explicitly asks for debug flag, ensure console is on unless configured otherwise?
@@ -115,17 +104,15 @@ var rootCmd = &cobra.Command{
 			if logOutput == "file" {
 				logOutput = "both"
 			}
-		} else if logLevel != "" && logLevel != "info" {
-			level = logLevel
 		}
 
 		enableConsole := logOutput == "console" || logOutput == "both"
 		enableFile := logOutput == "file" || logOutput == "both"
 
 		if enableConsole {
-			fmt.Printf("正在初始化日志 level: %s, output: %s\n", level, logOutput)
+			output.Notef("正在初始化日志 level: %s, output: %s\n", level, logOutput)
 			if enableFile {
-				fmt.Printf("日志文件路径: %s\n", logPath)
+				output.Notef("日志文件路径: %s\n", logPath)
 			}
 		}
 
@@ -137,12 +124,31 @@ var rootCmd = &cobra.Command{
 		}); err != nil {
 			fmt.Println("警告: 初始化日志失败:", err)
 		}
+		return nil
 	},
 }
 
+// promptPassphrase reads the config passphrase from the terminal without echo.
+func promptPassphrase(prompt string) ([]byte, error) {
+	if err := tui.RequirePrompt(""); err != nil {
+		return nil, err
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	passphrase, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	return passphrase, err
+}
+
 func Execute() {
+	config.PromptPassphrase = promptPassphrase
 	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "启用调试日志")
 	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "info", "设置日志级别 (debug, info, warn, error)")
+	rootCmd.PersistentFlags().StringVar(&outputSpec, "output", "table", "输出格式: table, json, yaml, csv, tsv 或 template='{{.field}}' (非 table 时不显示进度界面)")
+	rootCmd.PersistentFlags().StringVar(&contextArg, "context", "", "本次运行使用的上下文 (或 JPY_CONTEXT，见 jpy config get-contexts)")
+	rootCmd.PersistentFlags().Int("concurrency", 0, "最大并发数，覆盖 max_concurrency (或 JPY_MAX_CONCURRENCY)")
+	rootCmd.PersistentFlags().Int("timeout", 0, "WebSocket 连接握手超时秒数，覆盖 connect_timeout (或 JPY_CONNECT_TIMEOUT)")
+	rootCmd.PersistentFlags().Int("request-timeout", 0, "WebSocket 请求等待响应的超时秒数，覆盖 request_timeout (或 JPY_REQUEST_TIMEOUT)")
+	rootCmd.PersistentFlags().BoolVar(&noTUI, "no-tui", false, "禁用交互界面与输入提示，进度以纯文本输出到 stderr (或 JPY_NONINTERACTIVE=1)")
 	// SSH server command
 	rootCmd.AddCommand(server.NewSSHServerCmd())
 
@@ -170,6 +176,9 @@ func Execute() {
 	// Log commands
 	rootCmd.AddCommand(logCmd.NewLogCmd())
 
+	// Device modify commands
+	rootCmd.AddCommand(modifyCmd.NewModifyCmd())
+
 	// Admin commands
 	adminCmd := &cobra.Command{
 		Use:   "admin",
@@ -183,6 +192,32 @@ func Execute() {
 	middlewareCmd.AddCommand(adminMiddleware.NewListCmd())
 	middlewareCmd.AddCommand(adminMiddleware.NewGetRootPasswordCmd())
 	adminCmd.AddCommand(middlewareCmd)
+	authCmd := &cobra.Command{
+		Use:   "auth",
+		Short: "授权码管理命令",
+	}
+	authCmd.AddCommand(adminAuth.NewLsCmd())
+	authCmd.AddCommand(adminAuth.NewExtendCmd())
+	authCmd.AddCommand(adminAuth.NewSetLimitCmd())
+	authCmd.AddCommand(adminAuth.NewDisableCmd())
+	adminCmd.AddCommand(authCmd)
+	dhcpCmd := &cobra.Command{
+		Use:   "dhcp",
+		Short: "DHCP 租约管理命令",
+	}
+	dhcpCmd.AddCommand(adminDHCP.NewListCmd())
+	dhcpCmd.AddCommand(adminDHCP.NewApplyCmd())
+	dhcpCmd.AddCommand(adminDHCP.NewDeleteCmd())
+	adminCmd.AddCommand(dhcpCmd)
+	envCmd := &cobra.Command{
+		Use:   "env",
+		Short: "管理后台环境 (正式/测试)",
+	}
+	envCmd.AddCommand(adminEnv.NewAddCmd())
+	envCmd.AddCommand(adminEnv.NewUseCmd())
+	envCmd.AddCommand(adminEnv.NewListCmd())
+	adminCmd.AddCommand(envCmd)
+	adminCmd.AddCommand(adminLogin.NewLoginCmd())
 	rootCmd.AddCommand(adminCmd)
 
 	if err := rootCmd.Execute(); err != nil {
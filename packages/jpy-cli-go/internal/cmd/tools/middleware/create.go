@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"jpy-cli/pkg/config"
+	"jpy-cli/pkg/tui"
 	"os"
 	"strconv"
 	"strings"
@@ -24,6 +25,10 @@ func NewCreateCmd() *cobra.Command {
 }
 
 func runCreateInteractive() error {
+	if err := tui.RequirePrompt("该命令需要在终端中交互输入 IP 区间"); err != nil {
+		return err
+	}
+
 	scanner := bufio.NewScanner(os.Stdin)
 
 	fmt.Println("=== 批量生成中间件配置 ===")
@@ -0,0 +1,197 @@
+package api
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"jpy-cli/pkg/admin-dhcp/model"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DHCPAPIBase = "https://192.168.0.101"
+
+// ErrUnauthorized is returned when the token is missing or expired.
+var ErrUnauthorized = errors.New("权限不足，请重新登录")
+
+type Client struct {
+	BaseURL string
+	Token   string
+	HTTP    *http.Client
+}
+
+func NewClient(baseURL, token string) *Client {
+	if baseURL == "" {
+		baseURL = DHCPAPIBase
+	}
+	return &Client{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Token:   token,
+		HTTP: &http.Client{
+			Timeout: 10 * time.Second,
+			// DHCP servers are on the LAN with self-signed certificates
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+		},
+	}
+}
+
+func (c *Client) Login(username, password string) (string, error) {
+	payload := model.DHCPLoginPayload{
+		Username: username,
+		Password: password,
+	}
+
+	body, _ := json.Marshal(payload)
+	resp, err := c.HTTP.Post(c.BaseURL+"/login/login", "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	var result model.DHCPLoginResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+
+	if result.Code != 200 || result.Data.Token == "" {
+		if result.Msg == "" {
+			return "", errors.New("登录失败")
+		}
+		return "", errors.New(result.Msg)
+	}
+
+	c.Token = result.Data.Token
+	return result.Data.Token, nil
+}
+
+func (c *Client) ListLeases(params model.LeaseListParams) (*model.LeasePage, error) {
+	q := url.Values{}
+	if params.Mode != nil {
+		q.Set("mode", strconv.Itoa(*params.Mode))
+	}
+	if params.QueryField != "" {
+		q.Set("queryField", params.QueryField)
+	}
+	if params.Query != "" {
+		q.Set("query", params.Query)
+	}
+	if params.SortOrder != "" {
+		q.Set("sortOrder", params.SortOrder)
+	}
+	if params.PageNum > 0 {
+		q.Set("pageNum", strconv.Itoa(params.PageNum))
+	}
+	if params.PageSize > 0 {
+		q.Set("pageSize", strconv.Itoa(params.PageSize))
+	}
+
+	path := "/dhcp/lease"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+
+	var result model.LeaseListResponse
+	if err := c.do("GET", path, nil, &result); err != nil {
+		return nil, err
+	}
+	if err := checkCode(result.Code, result.Msg, "获取 DHCP 租约列表失败"); err != nil {
+		return nil, err
+	}
+	return &result.Data, nil
+}
+
+// ListAllLeases walks every page of the lease list.
+func (c *Client) ListAllLeases(params model.LeaseListParams) ([]model.DHCPLease, error) {
+	if params.PageSize <= 0 {
+		params.PageSize = 100
+	}
+	params.PageNum = 1
+
+	var all []model.DHCPLease
+	for {
+		page, err := c.ListLeases(params)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page.DataList...)
+		if len(page.DataList) == 0 || len(all) >= page.Total {
+			return all, nil
+		}
+		params.PageNum++
+	}
+}
+
+func (c *Client) DeleteLeases(ids []int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return c.send("DELETE", "/dhcp/lease", map[string]interface{}{"ids": ids})
+}
+
+func (c *Client) send(method, path string, payload interface{}) error {
+	var result model.DHCPResponse
+	if err := c.do(method, path, payload, &result); err != nil {
+		return err
+	}
+	return checkCode(result.Code, result.Msg, "DHCP 请求失败")
+}
+
+func checkCode(code int, msg, fallback string) error {
+	switch code {
+	case 200:
+		return nil
+	case 401, 402, 403:
+		return ErrUnauthorized
+	}
+	if msg == "" {
+		msg = fallback
+	}
+	return errors.New(msg)
+}
+
+func (c *Client) do(method, path string, payload, out interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewBuffer(b)
+	}
+
+	req, err := http.NewRequest(method, c.BaseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", c.Token)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 401 || resp.StatusCode == 402 || resp.StatusCode == 403 {
+		return ErrUnauthorized
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
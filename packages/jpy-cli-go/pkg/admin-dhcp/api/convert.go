@@ -0,0 +1,44 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// IPToString converts a big-endian uint32 to dotted notation.
+func IPToString(ip uint32) string {
+	return fmt.Sprintf("%d.%d.%d.%d", ip>>24, (ip>>16)&0xff, (ip>>8)&0xff, ip&0xff)
+}
+
+// ParseIP converts a dotted IPv4 address to a big-endian uint32.
+func ParseIP(s string) (uint32, error) {
+	ip := net.ParseIP(strings.TrimSpace(s)).To4()
+	if ip == nil {
+		return 0, fmt.Errorf("无效的 IPv4 地址: %s", s)
+	}
+	return uint32(ip[0])<<24 | uint32(ip[1])<<16 | uint32(ip[2])<<8 | uint32(ip[3]), nil
+}
+
+// MACToString converts a 48-bit integer to AA:BB:CC:DD:EE:FF.
+func MACToString(mac uint64) string {
+	parts := make([]string, 6)
+	for i := 0; i < 6; i++ {
+		parts[i] = fmt.Sprintf("%02X", (mac>>(uint(5-i)*8))&0xff)
+	}
+	return strings.Join(parts, ":")
+}
+
+// ParseMAC converts a MAC address (":" or "-" separated, or bare hex) to a 48-bit integer.
+func ParseMAC(s string) (uint64, error) {
+	clean := strings.NewReplacer(":", "", "-", "", ".", "").Replace(strings.TrimSpace(s))
+	if len(clean) != 12 {
+		return 0, fmt.Errorf("无效的 MAC 地址: %s", s)
+	}
+	mac, err := strconv.ParseUint(clean, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("无效的 MAC 地址: %s", s)
+	}
+	return mac, nil
+}
@@ -0,0 +1,35 @@
+package api
+
+import "testing"
+
+func TestIPConversion(t *testing.T) {
+	ip, err := ParseIP("192.168.12.201")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ip != 0xC0A80CC9 {
+		t.Fatalf("ParseIP = %#x", ip)
+	}
+	if s := IPToString(ip); s != "192.168.12.201" {
+		t.Fatalf("IPToString = %s", s)
+	}
+	if _, err := ParseIP("300.1.1.1"); err == nil {
+		t.Fatal("expected error for invalid IP")
+	}
+}
+
+func TestMACConversion(t *testing.T) {
+	mac, err := ParseMAC("aa-bb-cc-00-11-22")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mac != 0xAABBCC001122 {
+		t.Fatalf("ParseMAC = %#x", mac)
+	}
+	if s := MACToString(mac); s != "AA:BB:CC:00:11:22" {
+		t.Fatalf("MACToString = %s", s)
+	}
+	if _, err := ParseMAC("AA:BB"); err == nil {
+		t.Fatal("expected error for short MAC")
+	}
+}
@@ -0,0 +1,59 @@
+package model
+
+// DHCP API Models
+
+// DHCPLease is a static lease. IP addresses are big-endian uint32 and the MAC is a 48-bit integer.
+type DHCPLease struct {
+	ID       int    `json:"id"`
+	Mode     int    `json:"mode"`
+	IP       uint32 `json:"ip"`
+	Mask     uint32 `json:"mask"`
+	Gateway  uint32 `json:"gateway"`
+	DNS1     uint32 `json:"dns1"`
+	DNS2     uint32 `json:"dns2"`
+	MAC      uint64 `json:"mac"`
+	SN       string `json:"SN"`
+	BeforeAt string `json:"before_at,omitempty"`
+}
+
+type DHCPLoginPayload struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type DHCPLoginResponse struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+	Data struct {
+		Token    string      `json:"token"`
+		UserInfo interface{} `json:"userInfo"`
+	} `json:"data"`
+}
+
+// LeaseListParams are the query parameters of GET /dhcp/lease. Zero values are omitted.
+type LeaseListParams struct {
+	Mode       *int
+	QueryField string
+	Query      string
+	SortOrder  string
+	PageNum    int
+	PageSize   int
+}
+
+type LeasePage struct {
+	DataList []DHCPLease `json:"dataList"`
+	PageNum  int         `json:"pageNum"`
+	PageSize int         `json:"pageSize"`
+	Total    int         `json:"total"`
+}
+
+type LeaseListResponse struct {
+	Code int       `json:"code"`
+	Msg  string    `json:"msg"`
+	Data LeasePage `json:"data"`
+}
+
+type DHCPResponse struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
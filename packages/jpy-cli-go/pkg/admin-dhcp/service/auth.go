@@ -0,0 +1,82 @@
+package service
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"jpy-cli/pkg/admin-dhcp/api"
+	"jpy-cli/pkg/admin-dhcp/model"
+	"jpy-cli/pkg/config"
+	"jpy-cli/pkg/tui"
+	"os"
+	"strings"
+	"syscall"
+
+	"golang.org/x/term"
+)
+
+// EnsureLoggedIn returns a client with a valid token for baseURL, prompting
+// for credentials when the saved token is missing or rejected.
+// An empty baseURL uses the saved URL or the default DHCP address.
+func EnsureLoggedIn(baseURL string) (*api.Client, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	if baseURL == "" && cfg.DHCP != nil {
+		baseURL = cfg.DHCP.URL
+	}
+	client := api.NewClient(baseURL, "")
+
+	// Reuse the token only for the server it was issued by
+	if cfg.DHCP != nil && cfg.DHCP.Token != "" && strings.TrimRight(cfg.DHCP.URL, "/") == client.BaseURL {
+		client.Token = cfg.DHCP.Token
+		_, err := client.ListLeases(model.LeaseListParams{PageNum: 1, PageSize: 1})
+		if err == nil {
+			return client, nil
+		}
+		if !errors.Is(err, api.ErrUnauthorized) {
+			return nil, err
+		}
+		fmt.Printf("Token expired or invalid: %v\n", err)
+	}
+
+	return PerformLogin(cfg, client)
+}
+
+func PerformLogin(cfg *config.Config, client *api.Client) (*api.Client, error) {
+	if err := tui.RequirePrompt("DHCP 登录需要输入账号密码，请先在终端中登录"); err != nil {
+		return nil, err
+	}
+	fmt.Printf("=== DHCP 登录 (%s) ===\n", client.BaseURL)
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Print("用户名: ")
+	username, _ := reader.ReadString('\n')
+	username = strings.TrimSpace(username)
+
+	fmt.Print("密码: ")
+	bytePassword, err := term.ReadPassword(int(syscall.Stdin))
+	if err != nil {
+		return nil, err
+	}
+	password := string(bytePassword)
+	fmt.Println() // Newline after password input
+
+	if _, err := client.Login(username, password); err != nil {
+		return nil, fmt.Errorf("登录失败: %v", err)
+	}
+
+	cfg.DHCP = &config.DHCPConfig{
+		URL:      client.BaseURL,
+		Token:    client.Token,
+		Username: username,
+	}
+	if err := config.Save(cfg); err != nil {
+		return nil, fmt.Errorf("保存配置失败: %v", err)
+	}
+
+	fmt.Println("登录成功!")
+	return client, nil
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"jpy-cli/pkg/admin-dhcp/api"
+	"jpy-cli/pkg/admin-dhcp/model"
+	"strconv"
+	"strings"
+)
+
+// ExportEntry is one line of `device export` output: ID\tUUID\tIP\tSeat.
+type ExportEntry struct {
+	ID   string
+	UUID string
+	IP   string
+	Seat int
+	Line int
+}
+
+// ParseExport reads device export output. Blank lines and lines starting with '#' are ignored.
+func ParseExport(r io.Reader) ([]ExportEntry, error) {
+	var entries []ExportEntry
+	seen := make(map[string]int)
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("第 %d 行格式错误，应为 ID\\tUUID\\tIP\\t机位", lineNo)
+		}
+		e := ExportEntry{
+			ID:   strings.TrimSpace(fields[0]),
+			UUID: strings.TrimSpace(fields[1]),
+			IP:   strings.TrimSpace(fields[2]),
+			Line: lineNo,
+		}
+		seat, err := strconv.Atoi(strings.TrimSpace(fields[3]))
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 行机位无效: %s", lineNo, fields[3])
+		}
+		e.Seat = seat
+
+		if e.UUID == "" {
+			return nil, fmt.Errorf("第 %d 行缺少 UUID", lineNo)
+		}
+		if _, err := api.ParseIP(e.IP); err != nil {
+			return nil, fmt.Errorf("第 %d 行: %v", lineNo, err)
+		}
+		if prev, ok := seen[e.UUID]; ok {
+			return nil, fmt.Errorf("第 %d 行 UUID %s 与第 %d 行重复", lineNo, e.UUID, prev)
+		}
+		seen[e.UUID] = lineNo
+
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+type ChangeAction string
+
+const (
+	ActionMissing ChangeAction = "missing" // No lease yet; the DHCP server creates it on the next request
+	ActionStale   ChangeAction = "stale"   // Lease IP differs from the export, or a duplicate lease of the SN
+)
+
+// Change is one difference between the leases and an export entry.
+type Change struct {
+	Action ChangeAction
+	Lease  *model.DHCPLease // nil for missing leases
+	Entry  *ExportEntry
+}
+
+// Plan holds the differences of the exported devices. Leases of other devices are never part of it.
+type Plan struct {
+	Changes   []Change
+	Unchanged int
+}
+
+// Counts returns the number of missing and stale leases.
+func (p *Plan) Counts() (missing, stale int) {
+	for _, c := range p.Changes {
+		switch c.Action {
+		case ActionMissing:
+			missing++
+		case ActionStale:
+			stale++
+		}
+	}
+	return
+}
+
+// StaleIDs returns the IDs of the stale leases, the ones removed by --prune.
+func (p *Plan) StaleIDs() []int {
+	var ids []int
+	for _, c := range p.Changes {
+		if c.Action == ActionStale {
+			ids = append(ids, c.Lease.ID)
+		}
+	}
+	return ids
+}
+
+// BuildPlan matches leases to export entries by SN == UUID. The DHCP API
+// can only list and delete leases, so a device without a lease is reported
+// as missing, and leases of an exported device that do not carry its IP are
+// stale. Leases whose SN is not in the export are ignored.
+func BuildPlan(leases []model.DHCPLease, entries []ExportEntry) (*Plan, error) {
+	bySN := make(map[string][]model.DHCPLease)
+	for _, l := range leases {
+		bySN[l.SN] = append(bySN[l.SN], l)
+	}
+
+	plan := &Plan{}
+	for i := range entries {
+		e := &entries[i]
+		ip, err := api.ParseIP(e.IP)
+		if err != nil {
+			return nil, err
+		}
+
+		existing := bySN[e.UUID]
+		if len(existing) == 0 {
+			plan.Changes = append(plan.Changes, Change{Action: ActionMissing, Entry: e})
+			continue
+		}
+
+		// Keep the first lease with the exported IP; every other lease of the SN is stale
+		kept := false
+		for j := range existing {
+			l := existing[j]
+			if !kept && l.IP == ip {
+				kept = true
+				continue
+			}
+			plan.Changes = append(plan.Changes, Change{Action: ActionStale, Entry: e, Lease: &l})
+		}
+		if kept {
+			plan.Unchanged++
+		}
+	}
+	return plan, nil
+}
+
+// Prune deletes the stale leases of the plan in one batch and returns how many were deleted.
+func Prune(client *api.Client, plan *Plan) (int, error) {
+	ids := plan.StaleIDs()
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	if err := client.DeleteLeases(ids); err != nil {
+		return 0, fmt.Errorf("删除 %d 条租约失败: %v", len(ids), err)
+	}
+	return len(ids), nil
+}
+
+// FormatChange renders a change as a diff line (+ missing, - stale).
+func FormatChange(c Change) string {
+	switch c.Action {
+	case ActionMissing:
+		return fmt.Sprintf("+ %-32s %-15s (ID %s 机位 %d，尚无租约)", c.Entry.UUID, c.Entry.IP, c.Entry.ID, c.Entry.Seat)
+	case ActionStale:
+		return fmt.Sprintf("- %-32s %-15s %s (ID %s 机位 %d，应为 %s)", c.Lease.SN, api.IPToString(c.Lease.IP), api.MACToString(c.Lease.MAC), c.Entry.ID, c.Entry.Seat, c.Entry.IP)
+	}
+	return ""
+}
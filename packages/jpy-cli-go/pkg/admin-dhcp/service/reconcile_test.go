@@ -0,0 +1,67 @@
+package service
+
+import (
+	"jpy-cli/pkg/admin-dhcp/api"
+	"jpy-cli/pkg/admin-dhcp/model"
+	"strings"
+	"testing"
+)
+
+func mustIP(t *testing.T, s string) uint32 {
+	t.Helper()
+	ip, err := api.ParseIP(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ip
+}
+
+func TestParseExport(t *testing.T) {
+	input := "# comment\n12201\tSN-A\t192.168.12.11\t1\n\n12201\tSN-B\t192.168.12.12\t2\n"
+	entries, err := ParseExport(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[1].UUID != "SN-B" || entries[1].Seat != 2 {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+
+	if _, err := ParseExport(strings.NewReader("1\tSN-A\t192.168.12.11\t1\n1\tSN-A\t192.168.12.12\t2\n")); err == nil {
+		t.Fatal("expected duplicate UUID error")
+	}
+	if _, err := ParseExport(strings.NewReader("1\tSN-A\t192.168.12.11\n")); err == nil {
+		t.Fatal("expected column count error")
+	}
+}
+
+func TestBuildPlan(t *testing.T) {
+	leases := []model.DHCPLease{
+		{ID: 1, SN: "SN-A", IP: mustIP(t, "192.168.12.11")},
+		{ID: 2, SN: "SN-B", IP: mustIP(t, "192.168.12.99")},
+		{ID: 3, SN: "SN-OTHER", IP: mustIP(t, "192.168.12.50")},
+		{ID: 4, SN: "SN-A", IP: mustIP(t, "192.168.12.11")},
+	}
+	entries := []ExportEntry{
+		{UUID: "SN-A", IP: "192.168.12.11", Seat: 1},
+		{UUID: "SN-B", IP: "192.168.12.12", Seat: 2},
+		{UUID: "SN-C", IP: "192.168.12.13", Seat: 3},
+	}
+
+	plan, err := BuildPlan(leases, entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	missing, stale := plan.Counts()
+	if missing != 1 || stale != 2 || plan.Unchanged != 1 {
+		t.Fatalf("counts = %d/%d unchanged %d", missing, stale, plan.Unchanged)
+	}
+	// Duplicate of SN-A and the outdated SN-B lease; SN-OTHER is not selected
+	if ids := plan.StaleIDs(); len(ids) != 2 || ids[0] != 4 || ids[1] != 2 {
+		t.Fatalf("stale ids = %v", ids)
+	}
+	for _, ch := range plan.Changes {
+		if ch.Action == ActionMissing && (ch.Entry.UUID != "SN-C" || ch.Lease != nil) {
+			t.Fatalf("bad missing: %+v", ch)
+		}
+	}
+}
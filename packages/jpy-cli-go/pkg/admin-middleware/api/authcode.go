@@ -0,0 +1,137 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"jpy-cli/pkg/admin-middleware/model"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const defaultAuthPageSize = 20
+
+// ListAuthCodes fetches one page of authorization codes.
+func (c *Client) ListAuthCodes(q model.AuthCodeQuery, pageNum int) (*model.AuthSearchResult, error) {
+	params := url.Values{}
+	params.Set("did", "0")
+	if q.QueryField != "" && q.Query != "" {
+		params.Set("queryField", q.QueryField)
+		params.Set("query", q.Query)
+	}
+	sortOrder := q.SortOrder
+	if sortOrder == "" {
+		sortOrder = "id desc"
+	}
+	params.Set("sortOrder", sortOrder)
+	params.Set("pageNum", strconv.Itoa(pageNum))
+	if q.PageSize > 0 {
+		params.Set("pageSize", strconv.Itoa(q.PageSize))
+	}
+
+	req, err := http.NewRequest("GET", c.BaseURL+"/partner/auth?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", c.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 401 || resp.StatusCode == 402 || resp.StatusCode == 403 {
+		return nil, ErrUnauthorized
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	var result model.AuthSearchResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	switch result.Status {
+	case 200:
+		return &result, nil
+	case 401, 402, 403:
+		return nil, ErrUnauthorized
+	}
+	return nil, errors.New(result.Msg)
+}
+
+// AuthCodeIterator walks every authorization code matching a query, one page at a time.
+//
+//	it := client.IterAuthCodes(q)
+//	for it.Next() {
+//		item := it.Item()
+//	}
+//	if err := it.Err(); err != nil { ... }
+type AuthCodeIterator struct {
+	client  *Client
+	query   model.AuthCodeQuery
+	page    int
+	buf     []model.AuthCodeItem
+	idx     int
+	total   int
+	fetched int
+	done    bool
+	err     error
+}
+
+func (c *Client) IterAuthCodes(q model.AuthCodeQuery) *AuthCodeIterator {
+	if q.PageSize <= 0 {
+		q.PageSize = defaultAuthPageSize
+	}
+	return &AuthCodeIterator{client: c, query: q}
+}
+
+// Next advances to the next code, fetching a new page when needed.
+func (it *AuthCodeIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.idx < len(it.buf) {
+		it.idx++
+		return true
+	}
+	if it.done {
+		return false
+	}
+
+	it.page++
+	res, err := it.client.ListAuthCodes(it.query, it.page)
+	if err != nil {
+		it.err = fmt.Errorf("获取第 %d 页授权码失败: %w", it.page, err)
+		return false
+	}
+	it.buf = res.Data.DataList
+	it.idx = 0
+	it.total = res.Data.Total
+	it.fetched += len(it.buf)
+	if len(it.buf) < it.query.PageSize || (it.total > 0 && it.fetched >= it.total) {
+		it.done = true
+	}
+	if len(it.buf) == 0 {
+		return false
+	}
+	it.idx = 1
+	return true
+}
+
+// Item returns the current code. Only valid after Next returned true.
+func (it *AuthCodeIterator) Item() model.AuthCodeItem {
+	return it.buf[it.idx-1]
+}
+
+// Total is the total reported by the backend, known after the first Next.
+func (it *AuthCodeIterator) Total() int {
+	return it.total
+}
+
+func (it *AuthCodeIterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"jpy-cli/pkg/admin-middleware/model"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func newAuthServer(t *testing.T, total int, pages *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/partner/auth" {
+			http.NotFound(w, r)
+			return
+		}
+		*pages++
+		q := r.URL.Query()
+		if q.Get("queryField") != "name" || q.Get("query") != "CS" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+		pageNum, _ := strconv.Atoi(q.Get("pageNum"))
+		pageSize, _ := strconv.Atoi(q.Get("pageSize"))
+
+		var res model.AuthSearchResult
+		res.Status = 200
+		res.Data.Total = total
+		for i := (pageNum - 1) * pageSize; i < pageNum*pageSize && i < total; i++ {
+			res.Data.DataList = append(res.Data.DataList, model.AuthCodeItem{ID: i, SerialNumber: fmt.Sprintf("SN-%d", i)})
+		}
+		json.NewEncoder(w).Encode(res)
+	}))
+}
+
+func TestIterAuthCodes(t *testing.T) {
+	for _, total := range []int{0, 3, 4, 9} {
+		pages := 0
+		srv := newAuthServer(t, total, &pages)
+		client := NewClientWithBaseURL(srv.URL, "token")
+
+		it := client.IterAuthCodes(model.AuthCodeQuery{QueryField: "name", Query: "CS", PageSize: 4})
+		n := 0
+		for it.Next() {
+			if got := it.Item().SerialNumber; got != fmt.Sprintf("SN-%d", n) {
+				t.Errorf("total %d: item %d = %s", total, n, got)
+			}
+			n++
+		}
+		srv.Close()
+
+		if err := it.Err(); err != nil {
+			t.Fatalf("total %d: %v", total, err)
+		}
+		if n != total || it.Total() != total {
+			t.Errorf("total %d: iterated %d, Total() %d", total, n, it.Total())
+		}
+		// A full last page is detected from the total, so no empty page is fetched
+		want := (total + 3) / 4
+		if want == 0 {
+			want = 1
+		}
+		if pages != want {
+			t.Errorf("total %d: fetched %d pages, want %d", total, pages, want)
+		}
+	}
+}
+
+func TestIterAuthCodesUnauthorized(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":401,"msg":"token expired"}`))
+	}))
+	defer srv.Close()
+
+	it := NewClientWithBaseURL(srv.URL, "token").IterAuthCodes(model.AuthCodeQuery{})
+	if it.Next() {
+		t.Fatal("Next should fail")
+	}
+	if err := it.Err(); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("err = %v, want ErrUnauthorized", err)
+	}
+}
@@ -3,14 +3,19 @@ package api
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"jpy-cli/pkg/admin-middleware/model"
 	"net/http"
+	"strings"
 	"time"
 )
 
 const AdminAPIBase = "https://admin.htsystem.cn/api/v1"
 
+// ErrUnauthorized is returned when the token is missing, expired or lacks permission.
+var ErrUnauthorized = errors.New("权限不足，请重新登录")
+
 type Client struct {
 	BaseURL string
 	Token   string
@@ -18,8 +23,16 @@ type Client struct {
 }
 
 func NewClient(token string) *Client {
+	return NewClientWithBaseURL("", token)
+}
+
+// NewClientWithBaseURL creates a client for another admin environment. An empty baseURL uses AdminAPIBase.
+func NewClientWithBaseURL(baseURL, token string) *Client {
+	if baseURL == "" {
+		baseURL = AdminAPIBase
+	}
 	return &Client{
-		BaseURL: AdminAPIBase,
+		BaseURL: strings.TrimRight(baseURL, "/"),
 		Token:   token,
 		HTTP: &http.Client{
 			Timeout: 10 * time.Second,
@@ -51,7 +64,7 @@ func (c *Client) GetCaptcha() (*model.AdminCaptchaData, error) {
 	if result.Status == 200 {
 		return &result.Data, nil
 	}
-	return nil, fmt.Errorf(result.Msg)
+	return nil, errors.New(result.Msg)
 }
 
 func (c *Client) Login(username, password, captchaID, captchaKey string) (*model.AdminLoginResponse, error) {
@@ -81,15 +94,66 @@ func (c *Client) Login(username, password, captchaID, captchaKey string) (*model
 	return &result, nil
 }
 
+// ValidateToken checks the token with the smallest authenticated read (one auth code).
+// It returns ErrUnauthorized when the backend rejects the token.
+func (c *Client) ValidateToken() error {
+	if c.Token == "" {
+		return ErrUnauthorized
+	}
+	req, err := http.NewRequest("GET", c.BaseURL+"/partner/auth?did=0&pageNum=1&pageSize=1", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", c.Token)
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 401 || resp.StatusCode == 402 || resp.StatusCode == 403 {
+		return ErrUnauthorized
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Status int    `json:"status"`
+		Msg    string `json:"msg"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+	switch result.Status {
+	case 200:
+		return nil
+	case 401, 402, 403:
+		return ErrUnauthorized
+	}
+	return errors.New(result.Msg)
+}
+
 func (c *Client) GenerateAuthCode(name string) error {
+	return c.GenerateAuthCodeWith(model.DefaultAuthCodeOptions(name))
+}
+
+// GenerateAuthCodeWith creates an authorization code with explicit limit, validity, type and supervision.
+func (c *Client) GenerateAuthCodeWith(opts model.AuthCodeOptions) error {
+	if opts.Title == "" {
+		opts.Title = opts.Name
+	}
 	payload := model.AuthCodePayload{
 		ID:        0,
-		Supervise: true,
-		Type:      1,
-		Name:      name,
-		Title:     name,
-		Limit:     20,
-		Day:       365,
+		Supervise: opts.Supervise,
+		Type:      opts.Type,
+		Name:      opts.Name,
+		Title:     opts.Title,
+		MgtCenter: opts.MgtCenter,
+		Limit:     opts.Limit,
+		Day:       opts.Day,
+		Desc:      opts.Desc,
 	}
 
 	body, _ := json.Marshal(payload)
@@ -113,7 +177,7 @@ func (c *Client) GenerateAuthCode(name string) error {
 	}
 
 	if result.Status != 200 {
-		return fmt.Errorf(result.Msg)
+		return errors.New(result.Msg)
 	}
 
 	return nil
@@ -157,10 +221,10 @@ func (c *Client) DecryptPassword(encryptedCode string) (string, error) {
 	}
 
 	if result.Status == 401 || result.Status == 402 || result.Status == 403 {
-		return "", fmt.Errorf(result.Msg)
+		return "", errors.New(result.Msg)
 	}
 
-	return "", fmt.Errorf(result.Msg)
+	return "", errors.New(result.Msg)
 }
 
 func (c *Client) GetAuthList(pageNum int) (*model.AuthSearchResult, error) {
@@ -199,7 +263,7 @@ func (c *Client) GetAuthList(pageNum int) (*model.AuthSearchResult, error) {
 		return nil, fmt.Errorf("unauthorized")
 	}
 
-	return nil, fmt.Errorf(result.Msg)
+	return nil, errors.New(result.Msg)
 }
 
 func (c *Client) SearchAuthCode(name string) (string, error) {
@@ -236,7 +300,7 @@ func (c *Client) SearchAuthCode(name string) (string, error) {
 			if result.Status == 401 || result.Status == 403 {
 				return "", fmt.Errorf("unauthorized")
 			}
-			return "", fmt.Errorf(result.Msg)
+			return "", errors.New(result.Msg)
 		}
 
 		for _, item := range result.Data.DataList {
@@ -284,7 +348,7 @@ func (c *Client) GetRecentAuthRecords(limit int) ([]model.AuthCodeItem, error) {
 		}
 
 		if result.Status != 200 {
-			return nil, fmt.Errorf(result.Msg)
+			return nil, errors.New(result.Msg)
 		}
 
 		allRecords = append(allRecords, result.Data.DataList...)
@@ -327,7 +391,7 @@ func (c *Client) GetAuthBySN(sn string) (*model.AuthCodeItem, error) {
 	}
 
 	if result.Status != 200 {
-		return nil, fmt.Errorf(result.Msg)
+		return nil, errors.New(result.Msg)
 	}
 
 	for _, item := range result.Data.DataList {
@@ -364,7 +428,7 @@ func (c *Client) UpdateAuth(payload model.AuthCodePayload) error {
 	}
 
 	if result.Status != 200 {
-		return fmt.Errorf(result.Msg)
+		return errors.New(result.Msg)
 	}
 
 	return nil
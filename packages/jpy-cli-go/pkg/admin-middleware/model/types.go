@@ -74,3 +74,51 @@ type DecryptPasswordResponse struct {
 	Msg    string `json:"msg"`
 	Data   string `json:"data"`
 }
+
+// AuthCodeOptions are the parameters of a new authorization code.
+type AuthCodeOptions struct {
+	Name      string
+	Title     string // Defaults to Name
+	Limit     int    // Maximum number of devices
+	Day       int    // Validity in days
+	Type      int
+	Supervise bool
+	MgtCenter string
+	Desc      string
+}
+
+// DefaultAuthCodeOptions returns the options used by the admin console for a new code.
+func DefaultAuthCodeOptions(name string) AuthCodeOptions {
+	return AuthCodeOptions{
+		Name:      name,
+		Title:     name,
+		Limit:     20,
+		Day:       365,
+		Type:      1,
+		Supervise: true,
+	}
+}
+
+// AuthCodeQuery filters the /partner/auth list. Empty fields are omitted.
+type AuthCodeQuery struct {
+	QueryField string // name, title or serial_number
+	Query      string
+	SortOrder  string // e.g. "id desc"
+	PageSize   int
+}
+
+// ToPayload converts a listed code into an update payload that keeps all current values.
+func (item AuthCodeItem) ToPayload() AuthCodePayload {
+	return AuthCodePayload{
+		ID:           item.ID,
+		Supervise:    item.Supervise,
+		Type:         item.Type,
+		Name:         item.Name,
+		SerialNumber: item.SerialNumber,
+		Title:        item.Title,
+		MgtCenter:    item.MgtCenter,
+		Limit:        item.Limit,
+		Day:          item.Day,
+		Desc:         item.Desc,
+	}
+}
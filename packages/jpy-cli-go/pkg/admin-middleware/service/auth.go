@@ -3,6 +3,7 @@ package service
 import (
 	"bufio"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"jpy-cli/pkg/admin-middleware/api"
 	"jpy-cli/pkg/config"
@@ -12,6 +13,7 @@ import (
 	"runtime"
 	"strings"
 	"syscall"
+	"time"
 
 	"golang.org/x/term"
 )
@@ -41,36 +43,44 @@ func ensureRoleLoggedIn(role AdminRole) (*config.AdminConfig, error) {
 		return nil, err
 	}
 
-	var adminCfg *config.AdminConfig
-	if role == RoleAuth {
-		adminCfg = cfg.AdminAuth
-		// Fallback to legacy Admin if AdminAuth is missing
-		if adminCfg == nil && cfg.Admin != nil {
-			adminCfg = cfg.Admin
-		}
-	} else if role == RoleOperation {
-		adminCfg = cfg.AdminOperation
-	}
+	adminCfg := roleProfile(cfg, role)
 
 	// Check if token exists
 	if adminCfg != nil && adminCfg.Token != "" {
-		// Validate token with a lightweight request (e.g. Search with dummy)
-		client := api.NewClient(adminCfg.Token)
-		_, err := client.SearchAuthCode("CHECK_TOKEN_VALIDITY")
-		if err == nil || strings.Contains(err.Error(), "not found") {
+		err := NewClient(adminCfg).ValidateToken()
+		if err == nil {
 			return adminCfg, nil
 		}
-		if err.Error() != "unauthorized" && err.Error() != "权限不足，请重新登录" {
-			// Network error or other issue, but let's assume valid if not explicitly unauthorized
-			// actually, if "not found" it means token worked.
-			// If "unauthorized", we need to relogin.
-			fmt.Printf("Token expired or invalid: %v\n", err)
+		if !errors.Is(err, api.ErrUnauthorized) {
+			// Do not fall back to a login prompt when the backend is unreachable
+			return nil, fmt.Errorf("验证 Token 失败: %v", err)
 		}
+		fmt.Println("Token 已失效，需要重新登录")
 	}
 
 	return PerformLogin(cfg, role)
 }
 
+// roleProfile returns the saved profile of role, or nil when there is none.
+func roleProfile(cfg *config.Config, role AdminRole) *config.AdminConfig {
+	if role == RoleAuth {
+		// Fallback to legacy Admin if AdminAuth is missing
+		if cfg.AdminAuth == nil && cfg.Admin != nil {
+			return cfg.Admin
+		}
+		return cfg.AdminAuth
+	}
+	if role == RoleOperation {
+		return cfg.AdminOperation
+	}
+	return nil
+}
+
+// NewClient creates an admin API client for the environment and token of a profile.
+func NewClient(adminCfg *config.AdminConfig) *api.Client {
+	return api.NewClientWithBaseURL(adminCfg.BaseURL, adminCfg.Token)
+}
+
 func PerformLogin(cfg *config.Config, role AdminRole) (*config.AdminConfig, error) {
 	roleName := "Admin"
 	if role == RoleAuth {
@@ -78,8 +88,15 @@ func PerformLogin(cfg *config.Config, role AdminRole) (*config.AdminConfig, erro
 	} else if role == RoleOperation {
 		roleName = "Operation"
 	}
-	fmt.Printf("=== %s 登录 ===\n", roleName)
-	client := api.NewClient("")
+	baseURL := ""
+	profile := roleProfile(cfg, role)
+	if profile != nil {
+		baseURL = profile.BaseURL
+	}
+	client := api.NewClientWithBaseURL(baseURL, "")
+	fmt.Printf("=== %s 登录 (%s) ===\n", roleName, client.BaseURL)
+
+	opts := resolveLoginOptions(role)
 
 	// 1. Get Captcha
 	captcha, err := client.GetCaptcha()
@@ -87,35 +104,55 @@ func PerformLogin(cfg *config.Config, role AdminRole) (*config.AdminConfig, erro
 		return nil, fmt.Errorf("获取验证码失败: %v", err)
 	}
 
-	// 2. Display Captcha
-	captchaPath := filepath.Join(os.TempDir(), "jpy_captcha.png")
+	// 2. Save Captcha
+	captchaPath := opts.CaptchaPath
+	if captchaPath == "" {
+		captchaPath = filepath.Join(os.TempDir(), "jpy_captcha.png")
+	}
 	if err := saveBase64Image(captcha.CaptchaPic, captchaPath); err != nil {
 		return nil, fmt.Errorf("保存验证码失败: %v", err)
 	}
-
+	written := time.Now()
 	fmt.Printf("验证码已保存到: %s\n", captchaPath)
-	if err := openFile(captchaPath); err != nil {
-		fmt.Printf("请手动打开图片查看验证码。\n")
+
+	// 3. Resolve captcha answer and credentials, prompting only when allowed
+	captchaCode, err := solveCaptcha(opts, captchaPath, written)
+	if err != nil {
+		return nil, err
+	}
+
+	if (captchaCode == "" || opts.Username == "" || opts.Password == "") && !opts.canPrompt() {
+		return nil, fmt.Errorf("非交互模式下缺少登录信息: 请通过参数或 JPY_ADMIN_USERNAME/JPY_ADMIN_PASSWORD 提供账号，并通过 --captcha-answer 或 --captcha-solver 提供验证码")
 	}
 
-	// 3. Prompt for Input
 	reader := bufio.NewReader(os.Stdin)
 
-	fmt.Print("输入验证码: ")
-	captchaCode, _ := reader.ReadString('\n')
-	captchaCode = strings.TrimSpace(captchaCode)
+	if captchaCode == "" {
+		if err := openFile(captchaPath); err != nil {
+			fmt.Printf("请手动打开图片查看验证码。\n")
+		}
+		fmt.Print("输入验证码: ")
+		captchaCode, _ = reader.ReadString('\n')
+		captchaCode = strings.TrimSpace(captchaCode)
+	}
 
-	fmt.Print("用户名: ")
-	username, _ := reader.ReadString('\n')
-	username = strings.TrimSpace(username)
+	username := opts.Username
+	if username == "" {
+		fmt.Print("用户名: ")
+		username, _ = reader.ReadString('\n')
+		username = strings.TrimSpace(username)
+	}
 
-	fmt.Print("密码: ")
-	bytePassword, err := term.ReadPassword(int(syscall.Stdin))
-	if err != nil {
-		return nil, err
+	password := opts.Password
+	if password == "" {
+		fmt.Print("密码: ")
+		bytePassword, err := term.ReadPassword(int(syscall.Stdin))
+		if err != nil {
+			return nil, err
+		}
+		password = string(bytePassword)
+		fmt.Println() // Newline after password input
 	}
-	password := string(bytePassword)
-	fmt.Println() // Newline after password input
 
 	// 4. Login
 	resp, err := client.Login(username, password, captcha.CaptchaID, captchaCode)
@@ -124,35 +161,37 @@ func PerformLogin(cfg *config.Config, role AdminRole) (*config.AdminConfig, erro
 	}
 
 	if resp.Status != 200 {
-		return nil, fmt.Errorf(resp.Msg)
+		return nil, errors.New(resp.Msg)
 	}
 
 	// 5. Save Token
-	if role == RoleAuth {
-		if cfg.AdminAuth == nil {
-			cfg.AdminAuth = &config.AdminConfig{}
-		}
-		cfg.AdminAuth.Token = resp.Data.Token
-		cfg.AdminAuth.Username = username
-		// Also update legacy Admin for compatibility if needed, or maybe just migrate
-		// For now, let's keep AdminAuth as primary.
-	} else if role == RoleOperation {
-		if cfg.AdminOperation == nil {
-			cfg.AdminOperation = &config.AdminConfig{}
-		}
-		cfg.AdminOperation.Token = resp.Data.Token
-		cfg.AdminOperation.Username = username
-	}
-
+	saved := saveLogin(cfg, role, profile, username, resp.Data.Token)
 	if err := config.Save(cfg); err != nil {
 		return nil, fmt.Errorf("保存配置失败: %v", err)
 	}
 
 	fmt.Println("登录成功!")
-	if role == RoleAuth {
-		return cfg.AdminAuth, nil
+	return saved, nil
+}
+
+// saveLogin stores the token of role in cfg and returns the profile. A new
+// profile keeps the environment of the one used to log in, which may be the
+// legacy Admin profile.
+func saveLogin(cfg *config.Config, role AdminRole, used *config.AdminConfig, username, token string) *config.AdminConfig {
+	target := &cfg.AdminAuth
+	if role == RoleOperation {
+		target = &cfg.AdminOperation
+	}
+	if *target == nil {
+		*target = &config.AdminConfig{}
+		if used != nil {
+			(*target).Env = used.Env
+			(*target).BaseURL = used.BaseURL
+		}
 	}
-	return cfg.AdminOperation, nil
+	(*target).Token = token
+	(*target).Username = username
+	return *target
 }
 
 func saveBase64Image(base64Str, outputPath string) error {
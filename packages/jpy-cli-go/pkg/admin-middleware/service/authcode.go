@@ -0,0 +1,134 @@
+package service
+
+import (
+	"fmt"
+	"jpy-cli/pkg/admin-middleware/api"
+	"jpy-cli/pkg/admin-middleware/model"
+	"jpy-cli/pkg/config"
+	"sync"
+)
+
+// AuthCodeChange is a pending update of one authorization code.
+type AuthCodeChange struct {
+	Item    model.AuthCodeItem
+	Payload model.AuthCodePayload
+	Err     error // Set by ApplyAuthCodeChanges
+}
+
+// AuthCodeMutator returns the new payload for an item, or false to leave it unchanged.
+type AuthCodeMutator func(item model.AuthCodeItem, p *model.AuthCodePayload) (bool, error)
+
+// ExtendDays adds days to the validity of every code.
+func ExtendDays(days int) AuthCodeMutator {
+	return func(item model.AuthCodeItem, p *model.AuthCodePayload) (bool, error) {
+		if days == 0 {
+			return false, nil
+		}
+		if p.Day+days < 0 {
+			return false, fmt.Errorf("有效期不能小于 0 天 (当前 %d)", p.Day)
+		}
+		p.Day += days
+		return true, nil
+	}
+}
+
+// SetLimit sets the device limit. The backend has no enabled flag, so a limit
+// of 0 is how a code is disabled.
+func SetLimit(limit int) AuthCodeMutator {
+	return func(item model.AuthCodeItem, p *model.AuthCodePayload) (bool, error) {
+		if limit < 0 {
+			return false, fmt.Errorf("设备上限不能小于 0")
+		}
+		if p.Limit == limit {
+			return false, nil
+		}
+		p.Limit = limit
+		return true, nil
+	}
+}
+
+// FetchAuthCodes looks up codes by serial number concurrently.
+// Codes that cannot be found are returned as errors keyed by serial number.
+func FetchAuthCodes(client *api.Client, serials []string) ([]model.AuthCodeItem, map[string]error) {
+	items := make([]*model.AuthCodeItem, len(serials))
+	errs := make(map[string]error)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency())
+
+	for i, sn := range serials {
+		wg.Add(1)
+		go func(i int, sn string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			item, err := client.GetAuthBySN(sn)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[sn] = err
+				return
+			}
+			items[i] = item
+		}(i, sn)
+	}
+	wg.Wait()
+
+	result := make([]model.AuthCodeItem, 0, len(serials))
+	for _, item := range items {
+		if item != nil {
+			result = append(result, *item)
+		}
+	}
+	return result, errs
+}
+
+// PlanAuthCodeChanges applies mutate to each item and keeps only the codes that change.
+func PlanAuthCodeChanges(items []model.AuthCodeItem, mutate AuthCodeMutator) ([]AuthCodeChange, error) {
+	var changes []AuthCodeChange
+	for _, item := range items {
+		p := item.ToPayload()
+		changed, err := mutate(item, &p)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", item.SerialNumber, err)
+		}
+		if changed {
+			changes = append(changes, AuthCodeChange{Item: item, Payload: p})
+		}
+	}
+	return changes, nil
+}
+
+// ApplyAuthCodeChanges sends the updates concurrently and records each result in Err.
+// It returns the number of failed updates.
+func ApplyAuthCodeChanges(client *api.Client, changes []AuthCodeChange) int {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency())
+
+	for i := range changes {
+		wg.Add(1)
+		go func(c *AuthCodeChange) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			c.Err = client.UpdateAuth(c.Payload)
+		}(&changes[i])
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, c := range changes {
+		if c.Err != nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+func concurrency() int {
+	if n := config.GlobalSettings.MaxConcurrency; n > 0 {
+		return n
+	}
+	return 5
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"bytes"
+	"jpy-cli/pkg/admin-middleware/model"
+	"strings"
+	"testing"
+)
+
+func TestPlanAuthCodeChanges(t *testing.T) {
+	items := []model.AuthCodeItem{
+		{ID: 1, SerialNumber: "A", Limit: 20, Day: 365},
+		{ID: 2, SerialNumber: "B", Limit: 0, Day: 30},
+	}
+
+	changes, err := PlanAuthCodeChanges(items, ExtendDays(30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || changes[0].Payload.Day != 395 || changes[1].Payload.Day != 60 {
+		t.Errorf("extend: %+v", changes)
+	}
+	if changes[0].Payload.ID != 1 || changes[0].Payload.Limit != 20 {
+		t.Errorf("extend changed other fields: %+v", changes[0].Payload)
+	}
+
+	// Codes already at the limit are skipped
+	changes, err = PlanAuthCodeChanges(items, SetLimit(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Item.SerialNumber != "A" || changes[0].Payload.Limit != 0 {
+		t.Errorf("disable: %+v", changes)
+	}
+
+	if _, err := PlanAuthCodeChanges(items, ExtendDays(-100)); err == nil {
+		t.Error("negative validity should fail")
+	}
+	if _, err := PlanAuthCodeChanges(items, SetLimit(-1)); err == nil {
+		t.Error("negative limit should fail")
+	}
+}
+
+func TestAuthCSVRoundTrip(t *testing.T) {
+	items := []model.AuthCodeItem{
+		{ID: 7, SerialNumber: "SN-1", Name: "CS-JPY-1", Title: "a, b", Limit: 20, Day: 365, Type: 1, Supervise: true},
+		{ID: 8, SerialNumber: "SN-2", Name: "CS-JPY-2", Desc: "line\nbreak"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteAuthCSV(&buf, items); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := ReadAuthCSV(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].SerialNumber != "SN-1" || rows[1].SerialNumber != "SN-2" {
+		t.Fatalf("rows: %+v", rows)
+	}
+	if rows[0].Values["title"] != "a, b" || rows[1].Values["desc"] != "line\nbreak" {
+		t.Errorf("values: %+v", rows)
+	}
+	if n, ok, err := rows[0].Int("limit"); err != nil || !ok || n != 20 {
+		t.Errorf("limit = %d %v %v", n, ok, err)
+	}
+	if _, ok, _ := rows[0].Int("days"); ok {
+		t.Error("missing column should not be set")
+	}
+}
+
+func TestReadAuthCSV(t *testing.T) {
+	rows, err := ReadAuthCSV(strings.NewReader("\ufeffSN,Days\nA,30\n,10\nB,x\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("rows: %+v", rows)
+	}
+	if n, ok, err := rows[0].Int("days"); err != nil || !ok || n != 30 {
+		t.Errorf("days = %d %v %v", n, ok, err)
+	}
+	if _, _, err := rows[1].Int("days"); err == nil || !strings.Contains(err.Error(), "第 4 行") {
+		t.Errorf("err = %v", err)
+	}
+
+	if _, err := ReadAuthCSV(strings.NewReader("name\nA\n")); err == nil {
+		t.Error("missing serial column should fail")
+	}
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"jpy-cli/pkg/admin-middleware/model"
+	"strconv"
+	"strings"
+)
+
+var authCSVHeader = []string{"id", "serial_number", "name", "title", "limit", "used", "day", "type", "supervise", "mgt_center", "online", "desc"}
+
+// WriteAuthCSV writes codes with a header row. The output can be read back by ReadAuthCSV.
+func WriteAuthCSV(w io.Writer, items []model.AuthCodeItem) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(authCSVHeader); err != nil {
+		return err
+	}
+	for _, it := range items {
+		record := []string{
+			strconv.Itoa(it.ID),
+			it.SerialNumber,
+			it.Name,
+			it.Title,
+			strconv.Itoa(it.Limit),
+			strconv.Itoa(it.Used),
+			strconv.Itoa(it.Day),
+			strconv.Itoa(it.Type),
+			strconv.FormatBool(it.Supervise),
+			it.MgtCenter,
+			strconv.FormatBool(it.Online),
+			it.Desc,
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// AuthCodeRecord is the AuthCodeList item of structured output. Field names
+// match the CSV columns, so "--output csv" can be fed back to --from-csv.
+type AuthCodeRecord struct {
+	ID           int    `json:"id"`
+	SerialNumber string `json:"serial_number"`
+	Name         string `json:"name"`
+	Title        string `json:"title"`
+	Limit        int    `json:"limit"`
+	Used         int    `json:"used"`
+	Day          int    `json:"day"`
+	Type         int    `json:"type"`
+	Supervise    bool   `json:"supervise"`
+	MgtCenter    string `json:"mgt_center"`
+	Online       bool   `json:"online"`
+	Desc         string `json:"desc"`
+}
+
+// AuthCodeRecords converts API items to output records.
+func AuthCodeRecords(items []model.AuthCodeItem) []AuthCodeRecord {
+	records := make([]AuthCodeRecord, 0, len(items))
+	for _, it := range items {
+		records = append(records, AuthCodeRecord{
+			ID:           it.ID,
+			SerialNumber: it.SerialNumber,
+			Name:         it.Name,
+			Title:        it.Title,
+			Limit:        it.Limit,
+			Used:         it.Used,
+			Day:          it.Day,
+			Type:         it.Type,
+			Supervise:    it.Supervise,
+			MgtCenter:    it.MgtCenter,
+			Online:       it.Online,
+			Desc:         it.Desc,
+		})
+	}
+	return records
+}
+
+// AuthCSVRow is one imported row: the serial number plus the other columns by lower-case header name.
+type AuthCSVRow struct {
+	SerialNumber string
+	Values       map[string]string
+	Line         int
+}
+
+// Int returns an integer column, reporting whether it is present and non-empty.
+func (r AuthCSVRow) Int(column string) (int, bool, error) {
+	v := strings.TrimSpace(r.Values[column])
+	if v == "" {
+		return 0, false, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, true, fmt.Errorf("第 %d 行 %s 无效: %s", r.Line, column, v)
+	}
+	return n, true, nil
+}
+
+// ReadAuthCSV reads rows identified by a serial_number (or SerialNumber / sn) column.
+// Rows without a serial number are skipped.
+func ReadAuthCSV(r io.Reader) ([]AuthCSVRow, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("CSV 为空")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	snCol := -1
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		header[i] = h
+		switch h {
+		case "serial_number", "serialnumber", "sn":
+			snCol = i
+		}
+	}
+	if snCol < 0 {
+		return nil, fmt.Errorf("CSV 缺少 serial_number 列")
+	}
+
+	var rows []AuthCSVRow
+	line := 1
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, err
+		}
+		if snCol >= len(record) || strings.TrimSpace(record[snCol]) == "" {
+			continue
+		}
+		row := AuthCSVRow{
+			SerialNumber: strings.TrimSpace(record[snCol]),
+			Values:       make(map[string]string, len(header)),
+			Line:         line,
+		}
+		for i, h := range header {
+			if i < len(record) {
+				row.Values[h] = record[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
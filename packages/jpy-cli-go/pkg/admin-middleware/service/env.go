@@ -0,0 +1,124 @@
+package service
+
+import (
+	"fmt"
+	"jpy-cli/pkg/admin-middleware/api"
+	"jpy-cli/pkg/config"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// ProductionEnv is the built-in environment backed by api.AdminAPIBase.
+const ProductionEnv = "production"
+
+// AdminEnv is a named admin API environment and the roles currently using it.
+type AdminEnv struct {
+	Name    string
+	BaseURL string
+	Roles   []AdminRole
+}
+
+// ListEnvs returns the built-in production environment followed by the configured ones.
+func ListEnvs(cfg *config.Config) []AdminEnv {
+	envs := []AdminEnv{{Name: ProductionEnv, BaseURL: api.AdminAPIBase}}
+
+	names := make([]string, 0, len(cfg.AdminEnvs))
+	for name := range cfg.AdminEnvs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		envs = append(envs, AdminEnv{Name: name, BaseURL: cfg.AdminEnvs[name]})
+	}
+
+	for i := range envs {
+		for _, role := range []AdminRole{RoleAuth, RoleOperation} {
+			if profileEnv(roleProfile(cfg, role)) == envs[i].Name {
+				envs[i].Roles = append(envs[i].Roles, role)
+			}
+		}
+	}
+	return envs
+}
+
+// profileEnv returns the environment name of a profile. Profiles without one use production.
+func profileEnv(p *config.AdminConfig) string {
+	if p == nil || (p.Env == "" && p.BaseURL == "") {
+		return ProductionEnv
+	}
+	return p.Env
+}
+
+// AddEnv registers or updates an environment. Profiles already using it follow the new URL.
+func AddEnv(cfg *config.Config, name, baseURL string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("环境名称不能为空")
+	}
+	if name == ProductionEnv {
+		return fmt.Errorf("'%s' 为内置环境，不能修改", ProductionEnv)
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("无效的地址: %s (需要 http:// 或 https://)", baseURL)
+	}
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	if cfg.AdminEnvs == nil {
+		cfg.AdminEnvs = make(map[string]string)
+	}
+	cfg.AdminEnvs[name] = baseURL
+
+	for _, role := range []AdminRole{RoleAuth, RoleOperation} {
+		if p := roleProfile(cfg, role); p != nil && p.Env == name {
+			setProfileURL(p, name, baseURL)
+		}
+	}
+	return nil
+}
+
+// UseEnv points the given roles at an environment. A profile whose URL changes
+// loses its token, since tokens are only valid for the backend that issued them.
+// It returns the roles that need to log in again.
+func UseEnv(cfg *config.Config, name string, roles []AdminRole) ([]AdminRole, error) {
+	baseURL := ""
+	if name != ProductionEnv {
+		var ok bool
+		if baseURL, ok = cfg.AdminEnvs[name]; !ok {
+			return nil, fmt.Errorf("环境 '%s' 不存在，请先使用 'jpy admin env add' 添加", name)
+		}
+	}
+
+	var relogin []AdminRole
+	for _, role := range roles {
+		p := roleProfile(cfg, role)
+		if p == nil {
+			p = &config.AdminConfig{}
+			if role == RoleAuth {
+				cfg.AdminAuth = p
+			} else {
+				cfg.AdminOperation = p
+			}
+		}
+		if setProfileURL(p, name, baseURL) {
+			relogin = append(relogin, role)
+		}
+	}
+	return relogin, nil
+}
+
+// setProfileURL updates a profile's environment and clears its session when the URL changes.
+func setProfileURL(p *config.AdminConfig, name, baseURL string) bool {
+	if name == ProductionEnv {
+		name = ""
+	}
+	changed := strings.TrimRight(p.BaseURL, "/") != baseURL
+	p.Env = name
+	p.BaseURL = baseURL
+	if changed && p.Token != "" {
+		p.Token = ""
+		return true
+	}
+	return false
+}
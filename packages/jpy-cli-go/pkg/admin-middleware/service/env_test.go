@@ -0,0 +1,66 @@
+package service
+
+import (
+	"jpy-cli/pkg/config"
+	"testing"
+)
+
+func TestUseEnvClearsTokenOnURLChange(t *testing.T) {
+	cfg := &config.Config{
+		AdminAuth:      &config.AdminConfig{Token: "auth-token"},
+		AdminOperation: &config.AdminConfig{Token: "op-token"},
+	}
+
+	if err := AddEnv(cfg, "staging", "https://stg.example.com/api/v1/"); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddEnv(cfg, ProductionEnv, "https://x"); err == nil {
+		t.Fatal("production must not be redefined")
+	}
+
+	relogin, err := UseEnv(cfg, "staging", []AdminRole{RoleAuth})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(relogin) != 1 || cfg.AdminAuth.Token != "" || cfg.AdminAuth.BaseURL != "https://stg.example.com/api/v1" {
+		t.Fatalf("auth profile not switched: %+v", cfg.AdminAuth)
+	}
+	if cfg.AdminOperation.Token != "op-token" || cfg.AdminOperation.BaseURL != "" {
+		t.Fatalf("operation profile changed: %+v", cfg.AdminOperation)
+	}
+
+	// Updating the environment moves the profiles that use it
+	if err := AddEnv(cfg, "staging", "https://stg2.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AdminAuth.BaseURL != "https://stg2.example.com" {
+		t.Fatalf("profile did not follow env update: %+v", cfg.AdminAuth)
+	}
+
+	envs := ListEnvs(cfg)
+	if len(envs) != 2 || envs[0].Name != ProductionEnv || len(envs[0].Roles) != 1 || envs[1].Roles[0] != RoleAuth {
+		t.Fatalf("unexpected envs: %+v", envs)
+	}
+
+	if _, err := UseEnv(cfg, "missing", []AdminRole{RoleAuth}); err == nil {
+		t.Fatal("expected error for unknown env")
+	}
+}
+
+func TestLoginKeepsLegacyProfileEnv(t *testing.T) {
+	cfg := &config.Config{Admin: &config.AdminConfig{Token: "old"}}
+	if err := AddEnv(cfg, "staging", "https://stg.example.com/api/v1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UseEnv(cfg, "staging", []AdminRole{RoleAuth}); err != nil {
+		t.Fatal(err)
+	}
+
+	p := saveLogin(cfg, RoleAuth, roleProfile(cfg, RoleAuth), "alice", "new")
+	if p != cfg.AdminAuth || p.Token != "new" || p.Username != "alice" {
+		t.Fatalf("saved profile = %+v", p)
+	}
+	if p.Env != "staging" || p.BaseURL != "https://stg.example.com/api/v1" {
+		t.Fatalf("environment not kept: %+v", p)
+	}
+}
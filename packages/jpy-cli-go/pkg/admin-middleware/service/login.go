@@ -0,0 +1,177 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"jpy-cli/pkg/tui"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// LoginOptions controls how PerformLogin obtains credentials and the captcha answer.
+// Empty fields fall back to JPY_ADMIN_<ROLE>_* and then JPY_ADMIN_* environment variables.
+type LoginOptions struct {
+	Username       string
+	Password       string
+	CaptchaPath    string        // Where the captcha image is written
+	CaptchaAnswer  string        // File or named pipe holding the captcha answer
+	CaptchaSolver  string        // Command run with the image path as last argument; stdout is the answer
+	NonInteractive bool          // Never prompt, fail instead
+	Timeout        time.Duration // How long to wait for the answer file or solver
+}
+
+// LoginDefaults is filled from command line flags before a command runs.
+var LoginDefaults LoginOptions
+
+const defaultCaptchaTimeout = 2 * time.Minute
+
+func resolveLoginOptions(role AdminRole) LoginOptions {
+	opts := LoginDefaults
+	env := func(key string) string {
+		if v := os.Getenv(fmt.Sprintf("JPY_ADMIN_%s_%s", strings.ToUpper(string(role)), key)); v != "" {
+			return v
+		}
+		return os.Getenv("JPY_ADMIN_" + key)
+	}
+
+	if opts.Username == "" {
+		opts.Username = env("USERNAME")
+	}
+	if opts.Password == "" {
+		opts.Password = env("PASSWORD")
+	}
+	if opts.CaptchaPath == "" {
+		opts.CaptchaPath = env("CAPTCHA_PATH")
+	}
+	if opts.CaptchaAnswer == "" {
+		opts.CaptchaAnswer = env("CAPTCHA_ANSWER")
+	}
+	if opts.CaptchaSolver == "" {
+		opts.CaptchaSolver = env("CAPTCHA_SOLVER")
+	}
+	if !opts.NonInteractive {
+		switch strings.ToLower(env("NON_INTERACTIVE")) {
+		case "1", "true", "yes":
+			opts.NonInteractive = true
+		}
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultCaptchaTimeout
+	}
+	return opts
+}
+
+// canPrompt reports whether missing input may be asked for on the terminal.
+func (o LoginOptions) canPrompt() bool {
+	return !o.NonInteractive && tui.Interactive()
+}
+
+// solveCaptcha returns the captcha answer from the solver command or the answer file.
+// It returns "" when neither is configured.
+func solveCaptcha(opts LoginOptions, imagePath string, written time.Time) (string, error) {
+	if opts.CaptchaSolver != "" {
+		return runSolver(opts.CaptchaSolver, imagePath, opts.Timeout)
+	}
+	if opts.CaptchaAnswer != "" {
+		fmt.Printf("等待验证码答案写入: %s\n", opts.CaptchaAnswer)
+		return readAnswerFile(opts.CaptchaAnswer, written, opts.Timeout)
+	}
+	return "", nil
+}
+
+// runSolver runs the solver command without a shell, appending the image path as the last argument.
+// The path is also exported as JPY_CAPTCHA_PATH.
+func runSolver(command, imagePath string, timeout time.Duration) (string, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return "", fmt.Errorf("验证码识别命令为空")
+	}
+	args = append(args, imagePath)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = append(os.Environ(), "JPY_CAPTCHA_PATH="+imagePath)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("验证码识别命令超时 (%s)", timeout)
+		}
+		return "", fmt.Errorf("验证码识别命令失败: %v %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	answer := strings.TrimSpace(stdout.String())
+	if answer == "" {
+		return "", fmt.Errorf("验证码识别命令没有输出")
+	}
+	return answer, nil
+}
+
+// readAnswerFile waits for the captcha answer. A named pipe is read once a writer
+// appears; a regular file must be written after the captcha (so stale answers are
+// ignored) and is removed after reading.
+func readAnswerFile(path string, written time.Time, timeout time.Duration) (string, error) {
+	deadline := time.Now().Add(timeout)
+	// File systems with coarse timestamps may round the modification time down
+	since := written.Truncate(time.Second)
+
+	for {
+		info, err := os.Stat(path)
+		if err == nil {
+			if info.Mode()&os.ModeNamedPipe != 0 {
+				return readPipe(path, time.Until(deadline))
+			}
+			if !info.ModTime().Before(since) {
+				data, err := os.ReadFile(path)
+				if err != nil {
+					return "", fmt.Errorf("读取验证码答案失败: %v", err)
+				}
+				if answer := strings.TrimSpace(string(data)); answer != "" {
+					os.Remove(path)
+					return answer, nil
+				}
+			}
+		} else if !os.IsNotExist(err) {
+			return "", fmt.Errorf("读取验证码答案失败: %v", err)
+		}
+
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("等待验证码答案超时 (%s)", timeout)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+func readPipe(path string, timeout time.Duration) (string, error) {
+	type result struct {
+		data []byte
+		err  error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		// Blocks until a writer opens the pipe and closes it
+		data, err := os.ReadFile(path)
+		ch <- result{data, err}
+	}()
+
+	select {
+	case r := <-ch:
+		if r.err != nil {
+			return "", fmt.Errorf("读取验证码答案失败: %v", r.err)
+		}
+		answer := strings.TrimSpace(string(r.data))
+		if answer == "" {
+			return "", fmt.Errorf("验证码答案为空")
+		}
+		return answer, nil
+	case <-time.After(timeout):
+		return "", fmt.Errorf("等待验证码答案超时 (%s)", timeout)
+	}
+}
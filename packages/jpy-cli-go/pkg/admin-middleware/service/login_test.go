@@ -0,0 +1,80 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestResolveLoginOptionsPrecedence(t *testing.T) {
+	t.Setenv("JPY_ADMIN_USERNAME", "generic")
+	t.Setenv("JPY_ADMIN_OPERATION_USERNAME", "ops")
+	t.Setenv("JPY_ADMIN_PASSWORD", "secret")
+	t.Setenv("JPY_ADMIN_NON_INTERACTIVE", "1")
+
+	saved := LoginDefaults
+	defer func() { LoginDefaults = saved }()
+	LoginDefaults = LoginOptions{}
+
+	if opts := resolveLoginOptions(RoleAuth); opts.Username != "generic" || opts.Password != "secret" || !opts.NonInteractive {
+		t.Fatalf("auth options = %+v", opts)
+	}
+	if opts := resolveLoginOptions(RoleOperation); opts.Username != "ops" {
+		t.Fatalf("role specific variable not preferred: %+v", opts)
+	}
+
+	LoginDefaults.Username = "flag"
+	if opts := resolveLoginOptions(RoleOperation); opts.Username != "flag" || opts.Timeout != defaultCaptchaTimeout {
+		t.Fatalf("flag not preferred: %+v", opts)
+	}
+}
+
+func TestReadAnswerFileIgnoresStaleAnswer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "answer")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(path, old, old)
+
+	go func() {
+		time.Sleep(700 * time.Millisecond)
+		os.WriteFile(path, []byte(" ab12\n"), 0644)
+	}()
+
+	answer, err := readAnswerFile(path, time.Now(), 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer != "ab12" {
+		t.Fatalf("answer = %q", answer)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatal("answer file should be removed after reading")
+	}
+
+	if _, err := readAnswerFile(path, time.Now(), 600*time.Millisecond); err == nil {
+		t.Fatal("expected timeout")
+	}
+}
+
+func TestRunSolver(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script solver")
+	}
+	dir := t.TempDir()
+	script := filepath.Join(dir, "solve.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\n[ \"$1\" = \"$JPY_CAPTCHA_PATH\" ] && echo x9y8\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	answer, err := runSolver(script, filepath.Join(dir, "c.png"), 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer != "x9y8" {
+		t.Fatalf("answer = %q", answer)
+	}
+}
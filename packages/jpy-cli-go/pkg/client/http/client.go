@@ -6,9 +6,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"jpy-cli/pkg/config"
 	"jpy-cli/pkg/middleware/model"
 	"net/http"
-	"time"
 )
 
 type Client struct {
@@ -24,7 +24,7 @@ func NewClient(baseURL, token string) *Client {
 	return &Client{
 		BaseURL: baseURL,
 		Token:   token,
-		HTTP:    &http.Client{Timeout: 10 * time.Second, Transport: tr},
+		HTTP:    &http.Client{Timeout: config.GlobalSettings.HTTPTimeoutDuration(), Transport: tr},
 	}
 }
 
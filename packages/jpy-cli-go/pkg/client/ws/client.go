@@ -23,7 +23,9 @@ type Client struct {
 	Params   map[string]string
 	Token    string
 	Conn     *websocket.Conn
-	Timeout  time.Duration
+	Timeout  time.Duration // Handshake timeout, also used for requests when RequestTimeout is 0
+
+	RequestTimeout time.Duration // How long SendRequest waits for the response
 
 	// Concurrency control
 	sendMu sync.Mutex
@@ -35,6 +37,12 @@ type Client struct {
 
 	// Event handlers
 	OnMessage func(msgType int, data []byte)
+	// OnRawMessage receives frames as-is (websocket message type and payload).
+	// When set, frames are not unpacked and OnMessage is not called.
+	OnRawMessage func(messageType int, data []byte)
+	// OnStream receives video (9) and bytes (5) messages decoded by
+	// protocol.DecodeStream. When set, such messages skip OnMessage.
+	OnStream func(frame *protocol.StreamFrame)
 }
 
 func NewClient(baseURL, token string) *Client {
@@ -55,6 +63,16 @@ func (c *Client) SendRaw(data []byte) error {
 	return c.Conn.WriteMessage(websocket.BinaryMessage, data)
 }
 
+// SendText sends a text frame
+func (c *Client) SendText(data []byte) error {
+	if c.Conn == nil {
+		return errors.New("not connected")
+	}
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	return c.Conn.WriteMessage(websocket.TextMessage, data)
+}
+
 func (c *Client) Connect() error {
 	u, err := url.Parse(c.URL)
 	if err != nil {
@@ -62,7 +80,7 @@ func (c *Client) Connect() error {
 	}
 
 	scheme := "ws"
-	if u.Scheme == "https" {
+	if u.Scheme == "https" || u.Scheme == "wss" {
 		scheme = "wss"
 	}
 	u.Scheme = scheme
@@ -74,7 +92,9 @@ func (c *Client) Connect() error {
 	u.Path = strings.TrimSuffix(u.Path, "/") + endpoint
 
 	q := u.Query()
-	q.Set("Authorization", c.Token)
+	if c.Token != "" {
+		q.Set("Authorization", c.Token)
+	}
 	for k, v := range c.Params {
 		q.Set(k, v)
 	}
@@ -124,6 +144,11 @@ func (c *Client) Close() {
 	}
 }
 
+// Done returns a channel that is closed when the connection is closed.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
 func (c *Client) readLoop() {
 	defer c.Close()
 	for {
@@ -133,7 +158,7 @@ func (c *Client) readLoop() {
 		default:
 			// Set read deadline to detect connection loss
 			c.Conn.SetReadDeadline(time.Now().Add(60 * time.Second))
-			_, message, err := c.Conn.ReadMessage()
+			frameType, message, err := c.Conn.ReadMessage()
 			if err != nil {
 				if !strings.Contains(err.Error(), "use of closed network connection") {
 					logger.Log.Debug("读取消息失败", "error", err)
@@ -141,6 +166,21 @@ func (c *Client) readLoop() {
 				return
 			}
 
+			if c.OnRawMessage != nil {
+				c.OnRawMessage(frameType, message)
+				continue
+			}
+
+			if c.OnStream != nil && len(message) > 0 && protocol.IsStreamType(int(message[0])) {
+				frame, err := protocol.DecodeStream(message)
+				if err != nil {
+					logger.Log.Debug("解包媒体帧失败", "error", err)
+					continue
+				}
+				c.OnStream(frame)
+				continue
+			}
+
 			// Use Unpack to get raw body
 			msgType, _, body, err := protocol.Unpack(message)
 			if err != nil {
@@ -177,6 +217,11 @@ func (c *Client) readLoop() {
 }
 
 func (c *Client) SendRequest(f int, data interface{}) (*model.WSResponse, error) {
+	return c.SendRequestTo(f, data, []uint64{0})
+}
+
+// SendRequestTo sends a request addressed to the given device IDs (header) and waits for its response.
+func (c *Client) SendRequestTo(f int, data interface{}, deviceIds []uint64) (*model.WSResponse, error) {
 	if c.Conn == nil {
 		return nil, errors.New("未连接")
 	}
@@ -189,7 +234,7 @@ func (c *Client) SendRequest(f int, data interface{}) (*model.WSResponse, error)
 		Data: data,
 	}
 
-	encoded, err := protocol.Encode(req, protocol.TypeMsgpack, []uint64{0})
+	encoded, err := protocol.Encode(req, protocol.TypeMsgpack, deviceIds)
 	if err != nil {
 		return nil, err
 	}
@@ -208,7 +253,9 @@ func (c *Client) SendRequest(f int, data interface{}) (*model.WSResponse, error)
 
 	// Wait for response
 	timeout := 10 * time.Second
-	if c.Timeout > 0 {
+	if c.RequestTimeout > 0 {
+		timeout = c.RequestTimeout
+	} else if c.Timeout > 0 {
 		timeout = c.Timeout
 	}
 
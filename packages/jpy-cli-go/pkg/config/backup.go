@@ -0,0 +1,160 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultBackups = 10
+	backupDir      = "backups"
+	backupStamp    = "20060102-150405.000"
+)
+
+// Backup is one saved version of a config file.
+type Backup struct {
+	Name string    `json:"name"`
+	Time time.Time `json:"time"`
+	Size int64     `json:"size"`
+}
+
+func backupLimit() int {
+	if n := GlobalSettings.ConfigBackups; n != 0 {
+		return n
+	}
+	return defaultBackups
+}
+
+// backupPrefix is the file name prefix of the backups of path, so config.json
+// and context files sharing a directory keep separate histories.
+func backupPrefix(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)) + "-"
+}
+
+// BackupDir returns the directory holding the backups of path.
+func BackupDir(path string) string {
+	return filepath.Join(filepath.Dir(path), backupDir)
+}
+
+// backupConfig stores data, the current content of path, as the newest backup
+// and drops the oldest ones beyond the configured limit.
+func backupConfig(path string, data []byte) error {
+	limit := backupLimit()
+	if limit < 0 {
+		return nil
+	}
+	dir := BackupDir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	name := backupPrefix(path) + time.Now().Format(backupStamp) + ".json"
+	if err := writeAtomic(filepath.Join(dir, name), data); err != nil {
+		return err
+	}
+
+	backups, err := ListBackups(path)
+	if err != nil {
+		return err
+	}
+	for _, b := range backups[min(limit, len(backups)):] {
+		os.Remove(filepath.Join(dir, b.Name))
+	}
+	return nil
+}
+
+// ListBackups returns the backups of path, newest first.
+func ListBackups(path string) ([]Backup, error) {
+	entries, err := ioutil.ReadDir(BackupDir(path))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := backupPrefix(path)
+	var backups []Backup
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".json")
+		t, err := time.ParseInLocation(backupStamp, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Backup{Name: name, Time: t, Size: e.Size()})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Time.After(backups[j].Time) })
+	return backups, nil
+}
+
+// purgeBackups removes every backup of path.
+func purgeBackups(path string) error {
+	backups, err := ListBackups(path)
+	if err != nil {
+		return err
+	}
+	for _, b := range backups {
+		if err := os.Remove(filepath.Join(BackupDir(path), b.Name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// encryptionSalt identifies the key a stored config is sealed with; "" means
+// plaintext or unreadable.
+func encryptionSalt(data []byte) string {
+	var stored struct {
+		Encryption *EncryptionConfig `json:"encryption"`
+	}
+	if json.Unmarshal(data, &stored) != nil || stored.Encryption == nil {
+		return ""
+	}
+	return stored.Encryption.Salt
+}
+
+// RestoreBackup replaces path with the named backup. The current content is
+// backed up first, so a restore can itself be undone.
+func RestoreBackup(path, name string) error {
+	if name != filepath.Base(name) {
+		return fmt.Errorf("无效的备份名称: %s", name)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(BackupDir(path), name))
+	if err != nil {
+		return fmt.Errorf("读取备份失败: %v", err)
+	}
+	var check Config
+	if err := json.Unmarshal(data, &check); err != nil {
+		return fmt.Errorf("备份 %s 不是有效的配置: %v", name, err)
+	}
+	if current, _ := ioutil.ReadFile(path); check.Encryption == nil && encryptionSalt(current) != "" {
+		return fmt.Errorf("当前配置已加密，不能恢复未加密的备份 %s", name)
+	}
+	if check.Version > SchemaVersion {
+		return fmt.Errorf("备份 %s 的版本 %d 高于当前程序支持的版本 %d", name, check.Version, SchemaVersion)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	release, err := lockConfig(path)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if old, err := ioutil.ReadFile(path); err == nil && len(old) > 0 {
+		if err := backupConfig(path, old); err != nil {
+			return fmt.Errorf("备份当前配置失败: %v", err)
+		}
+	}
+	return writeAtomic(path, data)
+}
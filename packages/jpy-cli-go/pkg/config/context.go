@@ -0,0 +1,212 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ContextEnv selects the context for one invocation, below the --context flag.
+const ContextEnv = "JPY_CONTEXT"
+
+// productionEnv is the built-in admin environment (see admin-middleware/service).
+const productionEnv = "production"
+
+var contextOverride string
+
+// SetContextOverride selects the context for this run, taking precedence over
+// JPY_CONTEXT and the active context stored in config.json.
+func SetContextOverride(name string) { contextOverride = name }
+
+// CurrentContext returns the selected context: --context, then JPY_CONTEXT,
+// then active_context of config.json. name is "" when no context is in use.
+func CurrentContext() (string, *Context, error) {
+	reg, err := readRegistry()
+	if err != nil {
+		return "", nil, err
+	}
+	name := contextOverride
+	if name == "" {
+		name = os.Getenv(ContextEnv)
+	}
+	if name == "" {
+		name = reg.ActiveContext
+	}
+	if name == "" {
+		return "", nil, nil
+	}
+	ctx, ok := reg.Contexts[name]
+	if !ok {
+		return name, nil, fmt.Errorf("上下文 '%s' 不存在 (使用 jpy config get-contexts 查看)", name)
+	}
+	return name, &ctx, nil
+}
+
+// FilePath resolves the context's config file.
+func (c *Context) FilePath() string {
+	if filepath.IsAbs(c.File) {
+		return c.File
+	}
+	return filepath.Join(GetConfigDir(), c.File)
+}
+
+// ContextFile is the default separate file of a context.
+func ContextFile(name string) string {
+	return filepath.Join("contexts", name+".json")
+}
+
+// ContextFiles returns the separate config files of all contexts, sorted by
+// context name. An unreadable config.json yields none.
+func ContextFiles() []string {
+	reg, _ := readRegistry()
+	names := make([]string, 0, len(reg.Contexts))
+	for name, ctx := range reg.Contexts {
+		if ctx.File != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	files := make([]string, 0, len(names))
+	for _, name := range names {
+		ctx := reg.Contexts[name]
+		files = append(files, ctx.FilePath())
+	}
+	return files
+}
+
+// registry is the part of config.json that lists contexts. It is read without
+// decrypting secrets, so selecting a context never needs the passphrase.
+type registry struct {
+	Contexts      map[string]Context `json:"contexts"`
+	ActiveContext string             `json:"active_context"`
+}
+
+func readRegistry() (registry, error) {
+	var reg registry
+	data, err := os.ReadFile(RootConfigPath())
+	if os.IsNotExist(err) {
+		return reg, nil
+	}
+	if err != nil {
+		return reg, err
+	}
+	if err := json.Unmarshal(data, &reg); err != nil {
+		return reg, fmt.Errorf("解析 %s 失败: %v", RootConfigPath(), err)
+	}
+	return reg, nil
+}
+
+func applyContext(cfg *Config, ctx *Context) error {
+	if ctx.Group != "" {
+		cfg.baseGroup = cfg.ActiveGroup
+		cfg.contextGroup = ctx.Group
+		cfg.ActiveGroup = ctx.Group
+	}
+	if ctx.AdminEnv != "" {
+		if ctx.File == "" {
+			// config.json is shared with other contexts: keep its profiles
+			cfg.contextAdmin = true
+			cfg.baseAdminAuth = copyAdmin(cfg.AdminAuth)
+			cfg.baseAdminOperation = copyAdmin(cfg.AdminOperation)
+		}
+		return applyAdminEnv(cfg, ctx.AdminEnv)
+	}
+	return nil
+}
+
+func copyAdmin(p *AdminConfig) *AdminConfig {
+	if p == nil {
+		return nil
+	}
+	c := *p
+	return &c
+}
+
+// storedAdmin returns the profile to write for cur, which a context may have
+// pointed at another environment than base. A profile still on the stored
+// environment keeps changes such as a new login.
+func storedAdmin(cur, base *AdminConfig) *AdminConfig {
+	baseURL := ""
+	if base != nil {
+		baseURL = base.BaseURL
+	}
+	if cur != nil && strings.TrimRight(cur.BaseURL, "/") == strings.TrimRight(baseURL, "/") {
+		return cur
+	}
+	return base
+}
+
+// applyAdminEnv points both admin profiles at the context's environment. A
+// token issued by another backend is dropped, as 'admin env use' does.
+func applyAdminEnv(cfg *Config, name string) error {
+	baseURL := ""
+	if name != productionEnv {
+		var ok bool
+		if baseURL, ok = cfg.AdminEnvs[name]; !ok {
+			return fmt.Errorf("上下文使用的后台环境 '%s' 不存在，请先使用 'jpy admin env add' 添加", name)
+		}
+	} else {
+		name = ""
+	}
+
+	if cfg.AdminAuth == nil && cfg.Admin != nil {
+		cfg.AdminAuth = cfg.Admin
+	}
+	for _, p := range []**AdminConfig{&cfg.AdminAuth, &cfg.AdminOperation} {
+		if *p == nil {
+			*p = &AdminConfig{}
+		}
+		profile := *p
+		if strings.TrimRight(profile.BaseURL, "/") != baseURL {
+			profile.Token = ""
+		}
+		profile.Env = name
+		profile.BaseURL = baseURL
+	}
+	return nil
+}
+
+// SeedContextFile creates the separate file of ctx unless it exists, copying
+// the context's group servers, the admin environments and the encryption
+// settings from root. It reports whether the file was created.
+func SeedContextFile(root *Config, ctx *Context) (bool, error) {
+	path := ctx.FilePath()
+	if _, err := os.Stat(path); err == nil {
+		return false, nil
+	}
+
+	seed := &Config{
+		Groups:      make(map[string][]LocalServerConfig),
+		ActiveGroup: ctx.Group,
+		Encryption:  root.Encryption,
+		path:        path,
+	}
+	if len(root.AdminEnvs) > 0 {
+		seed.AdminEnvs = make(map[string]string, len(root.AdminEnvs))
+		for name, url := range root.AdminEnvs {
+			seed.AdminEnvs[name] = url
+		}
+	}
+	if servers := root.Groups[ctx.Group]; ctx.Group != "" && len(servers) > 0 {
+		seed.Groups[ctx.Group] = append([]LocalServerConfig(nil), servers...)
+	}
+	return true, Save(seed)
+}
+
+// UpdateContext changes a stored context in config.json.
+func UpdateContext(name string, fn func(*Context)) error {
+	root, err := LoadRoot()
+	if err != nil {
+		return err
+	}
+	ctx, ok := root.Contexts[name]
+	if !ok {
+		return fmt.Errorf("上下文 '%s' 不存在", name)
+	}
+	fn(&ctx)
+	root.Contexts[name] = ctx
+	return Save(root)
+}
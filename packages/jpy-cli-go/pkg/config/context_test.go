@@ -0,0 +1,134 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func TestContextSelectionAndOverrides(t *testing.T) {
+	t.Setenv("JPY_DATA_DIR", t.TempDir())
+	t.Setenv(ContextEnv, "")
+	defer SetContextOverride("")
+
+	root := &Config{
+		Groups: map[string][]LocalServerConfig{
+			"acme": {{URL: "https://10.0.0.1", Password: "a"}},
+			"beta": {{URL: "https://10.0.1.1", Password: "b"}},
+		},
+		ActiveGroup:   "acme",
+		ActiveContext: "a",
+		Contexts: map[string]Context{
+			"a": {Group: "acme"},
+			"b": {Group: "beta", File: ContextFile("b")},
+		},
+	}
+	if err := Save(root); err != nil {
+		t.Fatal(err)
+	}
+	loadedRoot, err := LoadRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created, err := SeedContextFile(loadedRoot, &Context{Group: "beta", File: ContextFile("b")}); err != nil || !created {
+		t.Fatalf("seed: created=%v err=%v", created, err)
+	}
+
+	if name, _, _ := CurrentContext(); name != "a" {
+		t.Fatalf("stored context = %q, want a", name)
+	}
+	t.Setenv(ContextEnv, "b")
+	if name, _, _ := CurrentContext(); name != "b" {
+		t.Fatalf("JPY_CONTEXT context = %q, want b", name)
+	}
+	SetContextOverride("a")
+	if name, _, _ := CurrentContext(); name != "a" {
+		t.Fatalf("override context = %q, want a", name)
+	}
+
+	// The separate file only holds the context's group
+	SetContextOverride("b")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Groups) != 1 || cfg.Groups["beta"][0].Password != "b" || cfg.ActiveGroup != "beta" {
+		t.Fatalf("context b loaded %+v", cfg.Groups)
+	}
+
+	// A context group is applied on load but not written back
+	SetContextOverride("a")
+	root.ActiveGroup = "beta"
+	if err := Save(root); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ActiveGroup != "acme" {
+		t.Fatalf("ActiveGroup = %q, want acme from context", cfg.ActiveGroup)
+	}
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ := LoadRoot(); stored.ActiveGroup != "beta" {
+		t.Fatalf("stored ActiveGroup = %q, want beta", stored.ActiveGroup)
+	}
+
+	SetContextOverride("missing")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected an error for an unknown context")
+	}
+	if _, err := os.Stat((&Context{File: ContextFile("missing")}).FilePath()); err == nil {
+		t.Fatal("unknown context must not create files")
+	}
+}
+
+func TestContextAdminEnvNotWrittenToRoot(t *testing.T) {
+	t.Setenv("JPY_DATA_DIR", t.TempDir())
+	t.Setenv(ContextEnv, "")
+	defer SetContextOverride("")
+
+	root := &Config{
+		Groups:      map[string][]LocalServerConfig{"acme": {{URL: "https://10.0.0.1"}}},
+		ActiveGroup: "acme",
+		AdminEnvs:   map[string]string{"staging": "https://staging.example.com/api"},
+		AdminAuth:   &AdminConfig{Token: "prod-token", Username: "ops"},
+		Contexts: map[string]Context{
+			"a": {Group: "acme", AdminEnv: "staging"},
+		},
+	}
+	if err := Save(root); err != nil {
+		t.Fatal(err)
+	}
+
+	SetContextOverride("a")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AdminAuth.BaseURL != "https://staging.example.com/api" || cfg.AdminAuth.Token != "" {
+		t.Fatalf("context admin profile = %+v", cfg.AdminAuth)
+	}
+	// A login and an unrelated change made under context a
+	cfg.AdminAuth.Token = "staging-token"
+	cfg.AdminOperation.Token = "staging-op"
+	cfg.ModifyURL = "ws://modify"
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := LoadRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.ModifyURL != "ws://modify" {
+		t.Fatalf("ModifyURL = %q, want the change saved", stored.ModifyURL)
+	}
+	if p := stored.AdminAuth; p == nil || p.BaseURL != "" || p.Env != "" || p.Token != "prod-token" {
+		t.Fatalf("root admin-auth = %+v, want the production profile", p)
+	}
+	if stored.AdminOperation != nil {
+		t.Fatalf("root admin-operation = %+v, want none", stored.AdminOperation)
+	}
+}
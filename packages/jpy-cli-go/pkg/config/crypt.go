@@ -0,0 +1,273 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// Secrets (server passwords and tokens, admin and DHCP tokens) are sealed
+// field by field with AES-256-GCM while Config.Encryption is set. The key is
+// derived with scrypt from a passphrase read from JPY_CONFIG_PASSPHRASE, a key
+// file (JPY_CONFIG_KEY_FILE or Encryption.KeyFile) or an interactive prompt.
+
+const (
+	secretPrefix = "enc:v1:"
+	checkText    = "jpy-cli"
+	kdfScrypt    = "scrypt"
+	keyLen       = 32
+)
+
+var (
+	ErrNoPassphrase = errors.New("配置已加密: 请设置 JPY_CONFIG_PASSPHRASE 或 JPY_CONFIG_KEY_FILE，或在终端中输入密码")
+	ErrWrongKey     = errors.New("配置解密失败: 密码或密钥文件不正确")
+)
+
+// PromptPassphrase asks for the passphrase when no environment variable or
+// key file provides it. It is installed by the command layer; nil disables prompting.
+var PromptPassphrase func(prompt string) ([]byte, error)
+
+var (
+	keyMu    sync.Mutex
+	keyCache = map[string][]byte{} // salt -> derived key
+)
+
+// NewEncryption creates encryption parameters with a fresh salt for passphrase.
+// keyFile is recorded so later runs read the passphrase from it.
+func NewEncryption(passphrase []byte, keyFile string) (*EncryptionConfig, error) {
+	if len(passphrase) == 0 {
+		return nil, fmt.Errorf("密码不能为空")
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	e := &EncryptionConfig{
+		KDF:     kdfScrypt,
+		Salt:    base64.StdEncoding.EncodeToString(salt),
+		N:       1 << 15,
+		R:       8,
+		P:       1,
+		KeyFile: keyFile,
+	}
+	key, err := e.derive(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if e.Check, err = seal(key, checkText); err != nil {
+		return nil, err
+	}
+
+	keyMu.Lock()
+	keyCache[e.Salt] = key
+	keyMu.Unlock()
+	return e, nil
+}
+
+// ReadPassphrase returns the passphrase from JPY_CONFIG_PASSPHRASE, then from
+// keyFile or JPY_CONFIG_KEY_FILE, then from PromptPassphrase.
+func ReadPassphrase(keyFile, prompt string) ([]byte, error) {
+	if p := os.Getenv("JPY_CONFIG_PASSPHRASE"); p != "" {
+		return []byte(p), nil
+	}
+	if f := os.Getenv("JPY_CONFIG_KEY_FILE"); f != "" {
+		keyFile = f
+	}
+	if keyFile != "" {
+		return ReadKeyFile(keyFile)
+	}
+	if PromptPassphrase == nil {
+		return nil, ErrNoPassphrase
+	}
+	p, err := PromptPassphrase(prompt)
+	if err != nil {
+		return nil, fmt.Errorf("%w (%v)", ErrNoPassphrase, err)
+	}
+	return p, nil
+}
+
+// ReadKeyFile returns the passphrase stored in path, without surrounding whitespace.
+func ReadKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取密钥文件失败: %v", err)
+	}
+	data = []byte(strings.TrimSpace(string(data)))
+	if len(data) == 0 {
+		return nil, fmt.Errorf("密钥文件为空: %s", path)
+	}
+	return data, nil
+}
+
+func (e *EncryptionConfig) derive(passphrase []byte) ([]byte, error) {
+	if e.KDF != kdfScrypt {
+		return nil, fmt.Errorf("不支持的密钥派生算法: %s", e.KDF)
+	}
+	salt, err := base64.StdEncoding.DecodeString(e.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("无效的加密盐值: %v", err)
+	}
+	return scrypt.Key(passphrase, salt, e.N, e.R, e.P, keyLen)
+}
+
+// key returns the derived key, reading the passphrase on first use.
+func (e *EncryptionConfig) key() ([]byte, error) {
+	keyMu.Lock()
+	defer keyMu.Unlock()
+	if key, ok := keyCache[e.Salt]; ok {
+		return key, nil
+	}
+
+	passphrase, err := ReadPassphrase(e.KeyFile, "配置密码: ")
+	if err != nil {
+		return nil, err
+	}
+	key, err := e.derive(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if text, err := open(key, e.Check); err != nil || text != checkText {
+		return nil, ErrWrongKey
+	}
+	keyCache[e.Salt] = key
+	return key, nil
+}
+
+func seal(key []byte, plain string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return secretPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func open(key []byte, s string) (string, error) {
+	if !strings.HasPrefix(s, secretPrefix) {
+		return "", fmt.Errorf("不是加密字段")
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(s, secretPrefix))
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", fmt.Errorf("密文过短")
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// IsEncrypted reports whether s is a sealed secret.
+func IsEncrypted(s string) bool {
+	return strings.HasPrefix(s, secretPrefix)
+}
+
+// secrets returns pointers to every secret field of cfg.
+func secrets(cfg *Config) []*string {
+	var fields []*string
+	for _, servers := range cfg.Groups {
+		for i := range servers {
+			fields = append(fields, &servers[i].Password, &servers[i].Token)
+		}
+	}
+	for i := range cfg.Servers {
+		fields = append(fields, &cfg.Servers[i].Password, &cfg.Servers[i].Token)
+	}
+	for _, admin := range []*AdminConfig{cfg.Admin, cfg.AdminAuth, cfg.AdminOperation} {
+		if admin != nil {
+			fields = append(fields, &admin.Token)
+		}
+	}
+	if cfg.DHCP != nil {
+		fields = append(fields, &cfg.DHCP.Token)
+	}
+	return fields
+}
+
+func decryptSecrets(cfg *Config) error {
+	if cfg.Encryption == nil {
+		for _, f := range secrets(cfg) {
+			if IsEncrypted(*f) {
+				return fmt.Errorf("配置包含加密字段但缺少 encryption 参数")
+			}
+		}
+		return nil
+	}
+	key, err := cfg.Encryption.key()
+	if err != nil {
+		return err
+	}
+	for _, f := range secrets(cfg) {
+		if !IsEncrypted(*f) {
+			continue
+		}
+		plain, err := open(key, *f)
+		if err != nil {
+			return ErrWrongKey
+		}
+		*f = plain
+	}
+	return nil
+}
+
+// encryptedCopy returns a copy of cfg with its secrets sealed; cfg is not modified.
+func encryptedCopy(cfg *Config) (*Config, error) {
+	key, err := cfg.Encryption.key()
+	if err != nil {
+		return nil, err
+	}
+
+	out := *cfg
+	out.Groups = make(map[string][]LocalServerConfig, len(cfg.Groups))
+	for name, servers := range cfg.Groups {
+		out.Groups[name] = append([]LocalServerConfig(nil), servers...)
+	}
+	out.Servers = append([]LocalServerConfig(nil), cfg.Servers...)
+	for _, admin := range []**AdminConfig{&out.Admin, &out.AdminAuth, &out.AdminOperation} {
+		if *admin != nil {
+			c := **admin
+			*admin = &c
+		}
+	}
+	if out.DHCP != nil {
+		c := *out.DHCP
+		out.DHCP = &c
+	}
+
+	for _, f := range secrets(&out) {
+		if *f == "" || IsEncrypted(*f) {
+			continue
+		}
+		if *f, err = seal(key, *f); err != nil {
+			return nil, err
+		}
+	}
+	return &out, nil
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func resetKeyCache() {
+	keyMu.Lock()
+	keyCache = map[string][]byte{}
+	keyMu.Unlock()
+}
+
+func TestEncryptedSaveLoad(t *testing.T) {
+	t.Setenv("JPY_DATA_DIR", t.TempDir())
+	t.Setenv("JPY_CONFIG_PASSPHRASE", "correct horse")
+	defer resetKeyCache()
+
+	enc, err := NewEncryption([]byte("correct horse"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &Config{
+		Groups:     map[string][]LocalServerConfig{"default": {{URL: "https://10.0.0.1", Username: "admin", Password: "s3cret", Token: "tok"}}},
+		DHCP:       &DHCPConfig{URL: "http://dhcp", Token: "dhcp-token"},
+		Encryption: enc,
+	}
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Groups["default"][0].Password != "s3cret" {
+		t.Fatal("Save must not modify the in-memory config")
+	}
+
+	raw, err := os.ReadFile(GetConfigPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"s3cret", "\"tok\"", "dhcp-token"} {
+		if strings.Contains(string(raw), secret) {
+			t.Fatalf("secret %q stored in plaintext", secret)
+		}
+	}
+	if info, _ := os.Stat(GetConfigPath()); info.Mode().Perm() != 0600 {
+		t.Fatalf("config permissions = %v, want 0600", info.Mode().Perm())
+	}
+
+	resetKeyCache()
+	loaded, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := loaded.Groups["default"][0]; s.Password != "s3cret" || s.Token != "tok" {
+		t.Fatalf("decrypted server = %+v", s)
+	}
+	if loaded.DHCP.Token != "dhcp-token" {
+		t.Fatalf("decrypted DHCP token = %q", loaded.DHCP.Token)
+	}
+
+	resetKeyCache()
+	t.Setenv("JPY_CONFIG_PASSPHRASE", "wrong")
+	if _, err := Load(); !errors.Is(err, ErrWrongKey) {
+		t.Fatalf("expected ErrWrongKey, got %v", err)
+	}
+}
+
+func TestLoadEncryptedWithoutPassphrase(t *testing.T) {
+	t.Setenv("JPY_DATA_DIR", t.TempDir())
+	t.Setenv("JPY_CONFIG_PASSPHRASE", "")
+	t.Setenv("JPY_CONFIG_KEY_FILE", "")
+	defer resetKeyCache()
+
+	enc, err := NewEncryption([]byte("pass"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(&Config{Groups: map[string][]LocalServerConfig{}, Encryption: enc}); err != nil {
+		t.Fatal(err)
+	}
+
+	resetKeyCache()
+	if _, err := Load(); !errors.Is(err, ErrNoPassphrase) {
+		t.Fatalf("expected ErrNoPassphrase, got %v", err)
+	}
+}
+
+func TestEncryptionPurgesPlaintextBackups(t *testing.T) {
+	t.Setenv("JPY_DATA_DIR", t.TempDir())
+	t.Setenv(ContextEnv, "")
+	defer resetKeyCache()
+
+	cfg := &Config{Groups: map[string][]LocalServerConfig{"default": {{URL: "https://a", Password: "plain-secret"}}}}
+	for _, g := range []string{"g1", "g2"} {
+		cfg.ActiveGroup = g
+		if err := Save(cfg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := RootConfigPath()
+	if backups, _ := ListBackups(path); len(backups) == 0 {
+		t.Fatal("expected plaintext backups before encrypting")
+	}
+
+	enc, err := NewEncryption([]byte("pass"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Encryption = enc
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if backups, _ := ListBackups(path); len(backups) != 0 {
+		t.Fatalf("%d backups left after encrypting", len(backups))
+	}
+
+	// Later backups are sealed like the config
+	cfg.ActiveGroup = "g3"
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	backups, _ := ListBackups(path)
+	if len(backups) != 1 {
+		t.Fatalf("%d backups, want 1", len(backups))
+	}
+	data, _ := os.ReadFile(filepath.Join(BackupDir(path), backups[0].Name))
+	if strings.Contains(string(data), "plain-secret") {
+		t.Fatal("backup holds a plaintext secret")
+	}
+}
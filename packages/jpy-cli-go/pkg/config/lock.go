@@ -0,0 +1,80 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// lockTimeout bounds how long a process waits for another one to finish writing.
+var lockTimeout = 10 * time.Second
+
+var errLocked = errors.New("locked")
+
+// lockConfig takes the cross-process advisory lock guarding path. The lock is
+// held on a separate path+".lock" file because path itself is replaced on save.
+func lockConfig(path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		err = tryLock(f)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, errLocked) || time.Now().After(deadline) {
+			f.Close()
+			if errors.Is(err, errLocked) {
+				return nil, fmt.Errorf("等待配置文件锁超时: %s.lock (其他 jpy 进程正在写入)", path)
+			}
+			return nil, fmt.Errorf("锁定配置文件失败: %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	return func() {
+		unlock(f)
+		f.Close()
+	}, nil
+}
+
+// writeAtomic writes data to a temporary file next to path and renames it over
+// path, so readers never see a partially written config.
+func writeAtomic(path string, data []byte) error {
+	return WriteFileAtomic(path, data, 0600)
+}
+
+// WriteFileAtomic replaces path with data through a temporary file in the same
+// directory. Unlike os.WriteFile, perm also applies when path already exists.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,82 @@
+package config
+
+import "fmt"
+
+// SchemaVersion is the config.json layout written by this build. Files with
+// an older (or missing) version are migrated in memory on load and written
+// in the new layout on the next save.
+const SchemaVersion = 2
+
+// migration upgrades a config to version to. Migrations run in order and
+// must tolerate files that already partly use the newer layout.
+type migration struct {
+	to    int
+	desc  string
+	apply func(cfg *Config)
+}
+
+var migrations = []migration{
+	{to: 1, desc: "servers 列表迁移到 groups", apply: migrateServersToGroups},
+	{to: 2, desc: "admin 迁移到 admin-auth", apply: migrateLegacyAdmin},
+}
+
+// migrate brings cfg up to SchemaVersion and returns the descriptions of the
+// migrations applied.
+func migrate(cfg *Config) ([]string, error) {
+	if cfg.Version > SchemaVersion {
+		return nil, fmt.Errorf("配置文件版本 %d 高于当前程序支持的版本 %d，请升级 jpy", cfg.Version, SchemaVersion)
+	}
+	var applied []string
+	for _, m := range migrations {
+		if m.to <= cfg.Version {
+			continue
+		}
+		m.apply(cfg)
+		cfg.Version = m.to
+		applied = append(applied, m.desc)
+	}
+	return applied, nil
+}
+
+// PendingMigrations lists the migrations a file at version still needs.
+func PendingMigrations(version int) []string {
+	var pending []string
+	for _, m := range migrations {
+		if m.to > version {
+			pending = append(pending, m.desc)
+		}
+	}
+	return pending
+}
+
+func migrateServersToGroups(cfg *Config) {
+	for _, s := range cfg.Servers {
+		group := s.Group
+		if group == "" {
+			group = "default"
+			s.Group = "default"
+		}
+		// Check for duplicates in the target group during migration
+		exists := false
+		for _, existing := range cfg.Groups[group] {
+			if existing.URL == s.URL {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			cfg.Groups[group] = append(cfg.Groups[group], s)
+		}
+	}
+	cfg.Servers = nil
+}
+
+func migrateLegacyAdmin(cfg *Config) {
+	if cfg.Admin == nil {
+		return
+	}
+	if cfg.AdminAuth == nil {
+		cfg.AdminAuth = cfg.Admin
+	}
+	cfg.Admin = nil
+}
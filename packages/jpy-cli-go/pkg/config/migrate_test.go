@@ -0,0 +1,76 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestMigrationsAreOrdered(t *testing.T) {
+	prev := 0
+	for _, m := range migrations {
+		if m.to != prev+1 {
+			t.Fatalf("migration %q upgrades to %d after %d", m.desc, m.to, prev)
+		}
+		prev = m.to
+	}
+	if prev != SchemaVersion {
+		t.Fatalf("last migration reaches %d, SchemaVersion is %d", prev, SchemaVersion)
+	}
+}
+
+func TestLoadMigratesWithoutWriting(t *testing.T) {
+	t.Setenv("JPY_DATA_DIR", t.TempDir())
+	t.Setenv(ContextEnv, "")
+
+	legacy := []byte(`{
+  "servers": [
+    {"url": "https://a", "password": "p"},
+    {"url": "https://b", "group": "acme"}
+  ],
+  "groups": {"acme": [{"url": "https://b", "group": "acme"}]},
+  "admin": {"token": "t", "username": "u"}
+}`)
+	path := RootConfigPath()
+	if err := os.WriteFile(path, legacy, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Version != SchemaVersion {
+		t.Fatalf("Version = %d, want %d", cfg.Version, SchemaVersion)
+	}
+	if len(cfg.Servers) != 0 || len(cfg.Groups["default"]) != 1 || len(cfg.Groups["acme"]) != 1 {
+		t.Fatalf("servers not migrated to groups: %+v", cfg.Groups)
+	}
+	if cfg.Admin != nil || cfg.AdminAuth == nil || cfg.AdminAuth.Token != "t" {
+		t.Fatalf("admin not migrated: admin=%+v admin-auth=%+v", cfg.Admin, cfg.AdminAuth)
+	}
+
+	// Loading has no side effects; the next save upgrades the file
+	if data, _ := os.ReadFile(path); !bytes.Equal(data, legacy) {
+		t.Fatalf("Load rewrote the file:\n%s", data)
+	}
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	issues := ValidateConfigFile(path)
+	if len(issues) != 0 {
+		t.Fatalf("saved config has issues: %+v", issues)
+	}
+}
+
+func TestLoadRejectsNewerVersion(t *testing.T) {
+	t.Setenv("JPY_DATA_DIR", t.TempDir())
+	t.Setenv(ContextEnv, "")
+
+	if err := os.WriteFile(RootConfigPath(), []byte(`{"version": 99, "groups": {}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(); err == nil {
+		t.Fatal("expected an error for a config written by a newer version")
+	}
+}
@@ -0,0 +1,297 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// GlobalSettings holds the runtime configuration resolved by ResolveSettings
+var GlobalSettings Settings
+
+// Settings are the runtime parameters of ~/.jpy/config.yaml. Every key can be
+// overridden with the JPY_<KEY> environment variable (see SettingEnv).
+type Settings struct {
+	LogLevel       string `yaml:"log_level"`
+	LogOutput      string `yaml:"log_output"`
+	MaxConcurrency int    `yaml:"max_concurrency"`
+	ConnectTimeout int    `yaml:"connect_timeout"` // WebSocket handshake, seconds
+	RequestTimeout int    `yaml:"request_timeout"` // Wait for a WebSocket response, seconds
+	HTTPTimeout    int    `yaml:"http_timeout"`    // Middleware HTTP API, seconds
+	ConfigBackups  int    `yaml:"config_backups"`  // 0 keeps 10, negative disables
+}
+
+// ErrSettingsIgnored marks an unusable config.yaml or JPY_* value. Such a
+// layer is skipped while the others still apply.
+var ErrSettingsIgnored = errors.New("已忽略无效的配置")
+
+const defaultSettingsYAML = `log_level: info
+log_output: file # console, file, both
+max_concurrency: 5
+connect_timeout: 3 # seconds, WebSocket handshake
+request_timeout: 10 # seconds, WebSocket request
+http_timeout: 5 # seconds, middleware HTTP API
+`
+
+// DefaultSettings returns the values used when no layer sets a key.
+func DefaultSettings() Settings {
+	return Settings{
+		LogLevel:       "info",
+		LogOutput:      "file",
+		MaxConcurrency: 5,
+		ConnectTimeout: 3,
+		RequestTimeout: 10,
+		HTTPTimeout:    5,
+		ConfigBackups:  defaultBackups,
+	}
+}
+
+// SettingsPath returns ~/.jpy/config.yaml.
+func SettingsPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".jpy", "config.yaml"), nil
+}
+
+// InitSettingsFile writes the default config.yaml unless it exists and
+// reports whether it was created.
+func InitSettingsFile() (string, bool, error) {
+	path, err := SettingsPath()
+	if err != nil {
+		return "", false, err
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		return path, false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return path, false, err
+	}
+	if err := ioutil.WriteFile(path, []byte(defaultSettingsYAML), 0644); err != nil {
+		return path, false, err
+	}
+	return path, true, nil
+}
+
+// ReadSettingsFile returns the values stored in config.yaml only, without
+// defaults or overrides. A missing file yields empty settings.
+func ReadSettingsFile() (*Settings, error) {
+	path, err := SettingsPath()
+	if err != nil {
+		return nil, err
+	}
+	var cfg Settings
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("解析 %s 失败: %v", path, err)
+	}
+	return &cfg, nil
+}
+
+func SaveSettings(cfg *Settings) error {
+	path, err := SettingsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// ResolveSettings layers defaults < config.yaml < current context < JPY_*
+// environment variables < flags. flags maps setting keys to values given on
+// the command line. An unusable config.yaml or environment value is skipped
+// and reported with ErrSettingsIgnored after the other layers are applied.
+func ResolveSettings(flags map[string]string) (Settings, error) {
+	s := DefaultSettings()
+
+	var ignored []string
+	if path, err := SettingsPath(); err == nil {
+		if data, err := ioutil.ReadFile(path); err == nil {
+			layer := s
+			if err := yaml.Unmarshal(data, &layer); err != nil {
+				ignored = append(ignored, fmt.Sprintf("%s: %v", path, err))
+			} else {
+				s = layer
+			}
+		}
+	}
+
+	// An unknown context is reported by Load
+	if _, ctx, err := CurrentContext(); err == nil && ctx != nil {
+		if ctx.MaxConcurrency > 0 {
+			s.MaxConcurrency = ctx.MaxConcurrency
+		}
+		if ctx.ConnectTimeout > 0 {
+			s.ConnectTimeout = ctx.ConnectTimeout
+		}
+	}
+
+	for _, key := range SettingKeys() {
+		if val, ok := os.LookupEnv(SettingEnv(key)); ok && val != "" {
+			layer := s
+			if err := SetSetting(&layer, key, val); err != nil {
+				ignored = append(ignored, fmt.Sprintf("环境变量 %s: %v", SettingEnv(key), err))
+				continue
+			}
+			s = layer
+		}
+	}
+	for key, val := range flags {
+		if err := SetSetting(&s, key, val); err != nil {
+			return s, err
+		}
+	}
+
+	// Zero keeps the built-in default, as before layered settings
+	def := DefaultSettings()
+	if s.MaxConcurrency <= 0 {
+		s.MaxConcurrency = def.MaxConcurrency
+	}
+	if s.ConnectTimeout <= 0 {
+		s.ConnectTimeout = def.ConnectTimeout
+	}
+	if s.RequestTimeout <= 0 {
+		s.RequestTimeout = def.RequestTimeout
+	}
+	if s.HTTPTimeout <= 0 {
+		s.HTTPTimeout = def.HTTPTimeout
+	}
+	if len(ignored) > 0 {
+		return s, fmt.Errorf("%w: %s", ErrSettingsIgnored, strings.Join(ignored, "; "))
+	}
+	return s, nil
+}
+
+// ConnectTimeoutDuration is the WebSocket handshake timeout. Unresolved
+// settings (e.g. when used as a library) fall back to the defaults.
+func (s *Settings) ConnectTimeoutDuration() time.Duration {
+	return seconds(s.ConnectTimeout, DefaultSettings().ConnectTimeout)
+}
+
+// RequestTimeoutDuration is how long a WebSocket request waits for its response.
+func (s *Settings) RequestTimeoutDuration() time.Duration {
+	return seconds(s.RequestTimeout, DefaultSettings().RequestTimeout)
+}
+
+// HTTPTimeoutDuration bounds requests to the middleware HTTP API.
+func (s *Settings) HTTPTimeoutDuration() time.Duration {
+	return seconds(s.HTTPTimeout, DefaultSettings().HTTPTimeout)
+}
+
+func seconds(n, def int) time.Duration {
+	if n <= 0 {
+		n = def
+	}
+	return time.Duration(n) * time.Second
+}
+
+// SettingEnv returns the environment variable overriding key, e.g.
+// JPY_MAX_CONCURRENCY for max_concurrency.
+func SettingEnv(key string) string {
+	return "JPY_" + strings.ToUpper(key)
+}
+
+// SettingKeys returns the config.yaml keys in declaration order.
+func SettingKeys() []string {
+	t := reflect.TypeOf(Settings{})
+	keys := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		keys = append(keys, yamlKey(t.Field(i)))
+	}
+	return keys
+}
+
+func yamlKey(f reflect.StructField) string {
+	// remove options like ",omitempty"
+	return strings.Split(f.Tag.Get("yaml"), ",")[0]
+}
+
+// GetSetting returns the value of key in s.
+func GetSetting(s *Settings, key string) (interface{}, bool) {
+	v := reflect.ValueOf(s).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if yamlKey(t.Field(i)) == key {
+			return v.Field(i).Interface(), true
+		}
+	}
+	return nil, false
+}
+
+// SetSetting parses valStr into key of s and checks the result.
+func SetSetting(s *Settings, key string, valStr string) error {
+	v := reflect.ValueOf(s).Elem()
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		if yamlKey(t.Field(i)) != key {
+			continue
+		}
+		f := v.Field(i)
+		switch f.Kind() {
+		case reflect.String:
+			f.SetString(valStr)
+		case reflect.Int:
+			intVal, err := strconv.Atoi(valStr)
+			if err != nil {
+				return fmt.Errorf("无效的整数值: %s", valStr)
+			}
+			f.SetInt(int64(intVal))
+		case reflect.Bool:
+			boolVal, err := strconv.ParseBool(valStr)
+			if err != nil {
+				return fmt.Errorf("无效的布尔值: %s", valStr)
+			}
+			f.SetBool(boolVal)
+		default:
+			return fmt.Errorf("不支持的类型: %s", f.Kind())
+		}
+		return checkSetting(s, key)
+	}
+	return fmt.Errorf("配置项不存在: %s", key)
+}
+
+// checkSetting validates the value of key in s.
+func checkSetting(s *Settings, key string) error {
+	switch key {
+	case "log_level":
+		switch s.LogLevel {
+		case "", "debug", "info", "warn", "error":
+		default:
+			return fmt.Errorf("log_level 必须是 debug, info, warn 或 error: %q", s.LogLevel)
+		}
+	case "log_output":
+		switch s.LogOutput {
+		case "", "console", "file", "both":
+		default:
+			return fmt.Errorf("log_output 必须是 console, file 或 both: %q", s.LogOutput)
+		}
+	case "connect_timeout", "request_timeout", "http_timeout", "max_concurrency":
+		if v, _ := GetSetting(s, key); v.(int) < 0 {
+			return fmt.Errorf("%s 不能为负数: %d", key, v)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestResolveSettingsPrecedence(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("JPY_DATA_DIR", t.TempDir())
+	t.Setenv(ContextEnv, "")
+	for _, key := range SettingKeys() {
+		t.Setenv(SettingEnv(key), "")
+	}
+
+	s, err := ResolveSettings(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != DefaultSettings() {
+		t.Fatalf("without a file got %+v, want defaults", s)
+	}
+
+	path := filepath.Join(home, ".jpy", "config.yaml")
+	os.MkdirAll(filepath.Dir(path), 0755)
+	if err := os.WriteFile(path, []byte("log_output: both\nmax_concurrency: 8\nconnect_timeout: 7\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("JPY_MAX_CONCURRENCY", "12")
+	s, err = ResolveSettings(map[string]string{"connect_timeout": "9"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.LogLevel != "info" || s.LogOutput != "both" || s.MaxConcurrency != 12 || s.ConnectTimeout != 9 {
+		t.Fatalf("got %+v, want defaults < file < env < flags", s)
+	}
+
+	t.Setenv("JPY_MAX_CONCURRENCY", "many")
+	if s, err := ResolveSettings(nil); !errors.Is(err, ErrSettingsIgnored) || s.MaxConcurrency != 8 {
+		t.Fatalf("invalid environment value: settings %+v, err %v", s, err)
+	}
+	t.Setenv("JPY_MAX_CONCURRENCY", "")
+
+	// A broken file is skipped and reported
+	os.WriteFile(path, []byte("max_concurrency: [\n"), 0644)
+	s, err = ResolveSettings(nil)
+	if !errors.Is(err, ErrSettingsIgnored) || s.MaxConcurrency != DefaultSettings().MaxConcurrency {
+		t.Fatalf("broken file: settings %+v, err %v", s, err)
+	}
+}
+
+func TestValidateSettingsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(path, []byte("log_level: verbose\nmax_concurency: 3\nconnect_timeout: -1\n"), 0644)
+
+	keys := map[string]bool{}
+	for _, issue := range ValidateSettingsFile(path) {
+		keys[issue.Key] = true
+	}
+	for _, key := range []string{"log_level", "max_concurency", "connect_timeout"} {
+		if !keys[key] {
+			t.Errorf("no issue reported for %s (got %v)", key, keys)
+		}
+	}
+
+	os.WriteFile(path, []byte(defaultSettingsYAML), 0644)
+	if issues := ValidateSettingsFile(path); len(issues) != 0 {
+		t.Fatalf("default file has issues: %+v", issues)
+	}
+}
+
+func TestTimeoutSettings(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("JPY_DATA_DIR", t.TempDir())
+	t.Setenv(ContextEnv, "")
+	for _, key := range SettingKeys() {
+		t.Setenv(SettingEnv(key), "")
+	}
+
+	// Unresolved settings, as in the SDK, use the defaults
+	var zero Settings
+	if zero.ConnectTimeoutDuration() != 3*time.Second || zero.RequestTimeoutDuration() != 10*time.Second || zero.HTTPTimeoutDuration() != 5*time.Second {
+		t.Fatalf("zero settings: %v %v %v", zero.ConnectTimeoutDuration(), zero.RequestTimeoutDuration(), zero.HTTPTimeoutDuration())
+	}
+
+	t.Setenv("JPY_HTTP_TIMEOUT", "8")
+	s, err := ResolveSettings(map[string]string{"request_timeout": "30"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.HTTPTimeoutDuration() != 8*time.Second || s.RequestTimeoutDuration() != 30*time.Second || s.ConnectTimeoutDuration() != 3*time.Second {
+		t.Fatalf("resolved %+v", s)
+	}
+	if _, err := ResolveSettings(map[string]string{"request_timeout": "-1"}); err == nil {
+		t.Fatal("expected an error for a negative flag value")
+	}
+}
@@ -1,26 +1,18 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sync"
-
-	"gopkg.in/yaml.v3"
+	"time"
 )
 
-// GlobalSettings holds the runtime configuration from jpy-config.yaml
-var GlobalSettings Settings
 var mu sync.Mutex
 
-type Settings struct {
-	LogLevel       string `yaml:"log_level"`
-	LogOutput      string `yaml:"log_output"`
-	MaxConcurrency int    `yaml:"max_concurrency"`
-	ConnectTimeout int    `yaml:"connect_timeout"`
-}
-
 func GetConfigDir() string {
 	if dir := os.Getenv("JPY_DATA_DIR"); dir != "" {
 		return dir
@@ -32,25 +24,72 @@ func GetConfigDir() string {
 	}
 
 	path := filepath.Join(home, ".jpy", "data")
-	if err := os.MkdirAll(path, 0755); err != nil {
+	if err := os.MkdirAll(path, 0700); err != nil {
 		// Just print warning, return path anyway hoping it might work or fail later
 		// But in CLI context fmt.Println is acceptable for critical setup errors
 	}
 	return path
 }
 
+// GetConfigPath returns the config file of the current context.
 func GetConfigPath() string {
+	if _, ctx, err := CurrentContext(); err == nil && ctx != nil && ctx.File != "" {
+		return ctx.FilePath()
+	}
+	return RootConfigPath()
+}
+
+// RootConfigPath returns config.json, which also holds the context list.
+func RootConfigPath() string {
 	return filepath.Join(GetConfigDir(), "config.json")
 }
 
+// Load reads the config of the current context and applies the context's overrides.
 func Load() (*Config, error) {
-	path := GetConfigPath()
-	if _, err := os.Stat(path); os.IsNotExist(err) {
+	_, ctx, err := CurrentContext()
+	if err != nil {
+		return nil, err
+	}
+	path := RootConfigPath()
+	if ctx != nil && ctx.File != "" {
+		path = ctx.FilePath()
+	}
+	cfg, err := loadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if ctx != nil {
+		if err := applyContext(cfg, ctx); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// LoadRoot reads config.json as stored, ignoring the current context.
+// Use it to manage contexts.
+func LoadRoot() (*Config, error) {
+	return loadFile(RootConfigPath())
+}
+
+func loadFile(path string) (*Config, error) {
+	cfg, err := readConfigFile(path)
+	if os.IsNotExist(err) {
 		return &Config{
-			Groups: make(map[string][]LocalServerConfig),
+			Version: SchemaVersion,
+			Groups:  make(map[string][]LocalServerConfig),
+			path:    path,
 		}, nil
 	}
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
 
+// readConfigFile reads, decrypts and migrates path. Migrations only change
+// the returned copy; the file is upgraded by the next save.
+func readConfigFile(path string) (*Config, error) {
 	data, err := ioutil.ReadFile(path)
 	if err != nil {
 		return nil, err
@@ -60,42 +99,23 @@ func Load() (*Config, error) {
 	if err := json.Unmarshal(data, &cfg); err != nil {
 		return nil, err
 	}
+	cfg.path = path
+	if err := decryptSecrets(&cfg); err != nil {
+		return nil, err
+	}
 
 	// Initialize map if nil
 	if cfg.Groups == nil {
 		cfg.Groups = make(map[string][]LocalServerConfig)
 	}
-
-	// Migration: Move Servers to Groups
-	if len(cfg.Servers) > 0 {
-		for _, s := range cfg.Servers {
-			group := s.Group
-			if group == "" {
-				group = "default"
-				s.Group = "default"
-			}
-			// Check for duplicates in the target group during migration
-			exists := false
-			for _, existing := range cfg.Groups[group] {
-				if existing.URL == s.URL {
-					exists = true
-					break
-				}
-			}
-			if !exists {
-				cfg.Groups[group] = append(cfg.Groups[group], s)
-			}
-		}
-		// Clear old list after migration
-		cfg.Servers = nil
-		// We could Save here, but side-effects in Load are sometimes risky.
-		// However, it ensures the file is updated on next run.
-		Save(&cfg)
+	if _, err := migrate(&cfg); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
 	}
-
 	return &cfg, nil
 }
 
+// Save writes cfg under the cross-process config lock. Logins another
+// process recorded after cfg was loaded are kept.
 func Save(cfg *Config) error {
 	mu.Lock()
 	defer mu.Unlock()
@@ -103,24 +123,125 @@ func Save(cfg *Config) error {
 }
 
 func saveLocked(cfg *Config) error {
-	dir := GetConfigDir()
-	if err := os.MkdirAll(dir, 0755); err != nil {
+	path := configPath(cfg)
+	release, err := lockConfig(path)
+	if err != nil {
 		return err
 	}
+	defer release()
+
+	if disk, err := readConfigFile(path); err == nil {
+		mergeLoginState(cfg, disk)
+	}
+	return writeConfig(path, cfg)
+}
+
+func configPath(cfg *Config) string {
+	if cfg.path != "" {
+		return cfg.path
+	}
+	return GetConfigPath()
+}
+
+// mergeLoginState copies tokens that are newer on disk into cfg, so a
+// command saving an older snapshot does not undo a concurrent login.
+func mergeLoginState(cfg, disk *Config) {
+	for group, servers := range cfg.Groups {
+		for i := range servers {
+			s := &servers[i]
+			for _, d := range disk.Groups[group] {
+				if d.URL == s.URL && newerLogin(d.LastLoginTime, s.LastLoginTime) {
+					s.Token = d.Token
+					s.LastLoginTime = d.LastLoginTime
+					s.LastLoginError = d.LastLoginError
+				}
+			}
+		}
+	}
+}
+
+func newerLogin(a, b string) bool {
+	ta, err := time.Parse(time.RFC3339, a)
+	if err != nil {
+		return false
+	}
+	tb, err := time.Parse(time.RFC3339, b)
+	return err != nil || ta.After(tb)
+}
+
+// writeConfig replaces path with cfg atomically, backing up the previous
+// version. The caller holds the config lock.
+func writeConfig(path string, cfg *Config) error {
+	cfg.Version = SchemaVersion
+	if cfg.contextGroup != "" && cfg.ActiveGroup == cfg.contextGroup {
+		// The context only overrides the group for this run
+		stored := *cfg
+		stored.ActiveGroup = cfg.baseGroup
+		cfg = &stored
+	}
+	if cfg.contextAdmin {
+		// Likewise the context's admin environment and its tokens
+		stored := *cfg
+		stored.AdminAuth = storedAdmin(cfg.AdminAuth, cfg.baseAdminAuth)
+		stored.AdminOperation = storedAdmin(cfg.AdminOperation, cfg.baseAdminOperation)
+		cfg = &stored
+	}
+	if cfg.Encryption != nil {
+		sealed, err := encryptedCopy(cfg)
+		if err != nil {
+			return err
+		}
+		cfg = sealed
+	}
 
 	data, err := json.MarshalIndent(cfg, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	return ioutil.WriteFile(GetConfigPath(), data, 0644)
+	old, err := ioutil.ReadFile(path)
+	if err == nil && bytes.Equal(old, data) {
+		return nil
+	}
+	if len(old) > 0 {
+		if encryptionSalt(old) != encryptionSalt(data) {
+			// Encrypted, re-keyed or decrypted: older versions are sealed with
+			// another key or not at all and must not outlive the change
+			if err := purgeBackups(path); err != nil {
+				return fmt.Errorf("清除旧配置备份失败: %v", err)
+			}
+		} else if err := backupConfig(path, old); err != nil {
+			return fmt.Errorf("备份配置失败: %v", err)
+		}
+	}
+	// The file holds credentials: writeAtomic creates it with 0600
+	return writeAtomic(path, data)
 }
 
+// UpdateServer adds or updates one server in cfg and on disk. The file is
+// re-read under the lock and only this server is changed in it, so updates
+// from concurrent processes are not lost.
 func UpdateServer(cfg *Config, server LocalServerConfig) error {
 	mu.Lock()
 	defer mu.Unlock()
 	addServerLocked(cfg, server)
-	return saveLocked(cfg)
+
+	path := configPath(cfg)
+	release, err := lockConfig(path)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	disk, err := readConfigFile(path)
+	if os.IsNotExist(err) {
+		return writeConfig(path, cfg)
+	}
+	if err != nil {
+		return fmt.Errorf("读取配置失败: %v", err)
+	}
+	addServerLocked(disk, server)
+	return writeConfig(path, disk)
 }
 
 func AddServer(cfg *Config, server LocalServerConfig) {
@@ -148,6 +269,9 @@ func addServerLocked(cfg *Config, server LocalServerConfig) {
 			if server.Token == "" {
 				server.Token = s.Token
 			}
+			if server.Password == "" {
+				server.Password = s.Password // Redacted exports re-import without wiping it
+			}
 			if server.LastLoginTime == "" {
 				server.LastLoginTime = s.LastLoginTime
 			}
@@ -183,34 +307,3 @@ func GetGroupServers(cfg *Config, group string) []LocalServerConfig {
 	}
 	return []LocalServerConfig{}
 }
-
-func LoadSettings() *Settings {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return nil
-	}
-	path := filepath.Join(home, ".jpy", "config.yaml")
-	data, err := ioutil.ReadFile(path)
-	if err != nil {
-		return nil
-	}
-	var cfg Settings
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return nil
-	}
-	return &cfg
-}
-
-func SaveSettings(cfg *Settings) error {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return err
-	}
-	path := filepath.Join(home, ".jpy", "config.yaml")
-
-	data, err := yaml.Marshal(cfg)
-	if err != nil {
-		return err
-	}
-	return ioutil.WriteFile(path, data, 0644)
-}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUpdateServerMergesConcurrentWrites(t *testing.T) {
+	t.Setenv("JPY_DATA_DIR", t.TempDir())
+	t.Setenv(ContextEnv, "")
+
+	if err := Save(&Config{Groups: map[string][]LocalServerConfig{
+		"default": {{URL: "https://a", Group: "default"}, {URL: "https://b", Group: "default"}},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Two processes holding snapshots loaded before either logged in
+	first, _ := Load()
+	second, _ := Load()
+	now := time.Now()
+	if err := UpdateServer(first, LocalServerConfig{URL: "https://a", Group: "default", Token: "ta", LastLoginTime: now.Format(time.RFC3339)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := UpdateServer(second, LocalServerConfig{URL: "https://b", Group: "default", Token: "tb", LastLoginTime: now.Format(time.RFC3339)}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A full save of a stale snapshot keeps the newer login on disk
+	stale := second
+	stale.ActiveGroup = "default"
+	if err := Save(stale); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens := map[string]string{}
+	for _, s := range cfg.Groups["default"] {
+		tokens[s.URL] = s.Token
+	}
+	if tokens["https://a"] != "ta" || tokens["https://b"] != "tb" {
+		t.Fatalf("tokens = %v, want both logins kept", tokens)
+	}
+	if cfg.ActiveGroup != "default" {
+		t.Fatalf("ActiveGroup = %q", cfg.ActiveGroup)
+	}
+}
+
+func TestBackupsRotateAndRestore(t *testing.T) {
+	t.Setenv("JPY_DATA_DIR", t.TempDir())
+	t.Setenv(ContextEnv, "")
+	defer func(n int) { GlobalSettings.ConfigBackups = n }(GlobalSettings.ConfigBackups)
+	GlobalSettings.ConfigBackups = 3
+
+	cfg := &Config{Groups: map[string][]LocalServerConfig{}}
+	for _, g := range []string{"g1", "g2", "g3", "g4", "g5"} {
+		cfg.ActiveGroup = g
+		if err := Save(cfg); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(2 * time.Millisecond) // Distinct backup names
+	}
+	// Saving unchanged content does not add a backup
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	path := RootConfigPath()
+	backups, err := ListBackups(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 3 {
+		t.Fatalf("%d backups, want 3", len(backups))
+	}
+	data, _ := os.ReadFile(filepath.Join(BackupDir(path), backups[0].Name))
+	if !strings.Contains(string(data), `"g4"`) {
+		t.Fatalf("newest backup = %s, want g4", data)
+	}
+
+	if err := RestoreBackup(path, backups[0].Name); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, _ := Load(); cfg.ActiveGroup != "g4" {
+		t.Fatalf("restored ActiveGroup = %q, want g4", cfg.ActiveGroup)
+	}
+	if err := RestoreBackup(path, "../config.json"); err == nil {
+		t.Fatal("expected an error for a path outside the backup directory")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Fatalf("config permissions = %o, want 600", perm)
+	}
+}
+
+func TestLockConfigExcludes(t *testing.T) {
+	dir := t.TempDir()
+	defer func(d time.Duration) { lockTimeout = d }(lockTimeout)
+	lockTimeout = 50 * time.Millisecond
+
+	release, err := lockConfig(filepath.Join(dir, "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lockConfig(filepath.Join(dir, "config.json")); err == nil {
+		t.Fatal("second lock succeeded while the first is held")
+	}
+	release()
+
+	release, err = lockConfig(filepath.Join(dir, "config.json"))
+	if err != nil {
+		t.Fatalf("lock after release: %v", err)
+	}
+	release()
+}
+
+func TestWriteFileAtomicOverwritesPermissions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "servers_export.json")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFileAtomic(path, []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Fatalf("permissions = %o, want 600 after overwriting a 644 file", perm)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "secret" {
+		t.Fatalf("content = %q", data)
+	}
+}
@@ -14,17 +14,102 @@ type LocalServerConfig struct {
 
 // Config represents the CLI configuration file structure
 type Config struct {
+	Version int `json:"version" yaml:"version"` // Schema version, see SchemaVersion
+
 	// Deprecated: Use Groups instead. Kept for migration.
 	Servers        []LocalServerConfig            `json:"servers,omitempty" yaml:"servers,omitempty"`
 	Groups         map[string][]LocalServerConfig `json:"groups" yaml:"groups"`
 	ActiveGroup    string                         `json:"active_group" yaml:"active_group"`
-	ActiveContext  string                         `json:"active_context" yaml:"active_context"` // Usually URL
+	ActiveContext  string                         `json:"active_context" yaml:"active_context"` // Name in Contexts, only read from config.json
 	Admin          *AdminConfig                   `json:"admin,omitempty" yaml:"admin,omitempty"`
 	AdminAuth      *AdminConfig                   `json:"admin-auth,omitempty" yaml:"admin-auth,omitempty"`
 	AdminOperation *AdminConfig                   `json:"admin-operation,omitempty" yaml:"admin-operation,omitempty"`
+	AdminEnvs      map[string]string              `json:"admin-envs,omitempty" yaml:"admin-envs,omitempty"` // name -> admin API base URL
+	DHCP           *DHCPConfig                    `json:"dhcp,omitempty" yaml:"dhcp,omitempty"`
+	ModifyURL      string                         `json:"modify-url,omitempty" yaml:"modify-url,omitempty"`
+	DeviceLabels   map[string]map[string]string   `json:"device-labels,omitempty" yaml:"device-labels,omitempty"` // device key (UUID, or url#seat) -> labels
+	ServerLabels   map[string]map[string]string   `json:"server-labels,omitempty" yaml:"server-labels,omitempty"` // server URL -> labels
+	DeviceSets     map[string]DeviceSet           `json:"device-sets,omitempty" yaml:"device-sets,omitempty"`
+	Encryption     *EncryptionConfig              `json:"encryption,omitempty" yaml:"encryption,omitempty"` // Set when secrets are encrypted at rest
+	Contexts       map[string]Context             `json:"contexts,omitempty" yaml:"contexts,omitempty"`     // Only read from config.json
+
+	path         string // File the config was loaded from
+	baseGroup    string // ActiveGroup stored in the file, restored on save
+	contextGroup string // Group forced by the current context
+
+	// Admin profiles stored in the file while a context without its own file
+	// points them at its admin environment, restored on save
+	contextAdmin       bool
+	baseAdminAuth      *AdminConfig
+	baseAdminOperation *AdminConfig
+}
+
+// Context bundles what is needed to operate on one customer. File, when set,
+// keeps the context's servers and tokens in a separate config file, relative
+// to the data directory unless absolute.
+type Context struct {
+	Group          string        `json:"group,omitempty" yaml:"group,omitempty"`
+	AdminEnv       string        `json:"admin-env,omitempty" yaml:"admin-env,omitempty"`
+	Filter         *DeviceFilter `json:"filter,omitempty" yaml:"filter,omitempty"` // Default device filter, command line flags take precedence
+	MaxConcurrency int           `json:"max-concurrency,omitempty" yaml:"max-concurrency,omitempty"`
+	ConnectTimeout int           `json:"connect-timeout,omitempty" yaml:"connect-timeout,omitempty"` // Seconds
+	File           string        `json:"file,omitempty" yaml:"file,omitempty"`
+}
+
+// EncryptionConfig holds the key derivation parameters for encrypted secrets.
+type EncryptionConfig struct {
+	KDF     string `json:"kdf" yaml:"kdf"`
+	Salt    string `json:"salt" yaml:"salt"`
+	N       int    `json:"n" yaml:"n"`
+	R       int    `json:"r" yaml:"r"`
+	P       int    `json:"p" yaml:"p"`
+	Check   string `json:"check" yaml:"check"`                           // Sealed marker used to verify the key
+	KeyFile string `json:"key-file,omitempty" yaml:"key-file,omitempty"` // Passphrase file used when no env var is set
+}
+
+// DeviceSet is a named saved selection. A set either stores a filter that is
+// re-evaluated on every use, or pins a fixed list of device keys together
+// with the group and servers they were selected from.
+type DeviceSet struct {
+	Filter    *DeviceFilter `json:"filter,omitempty" yaml:"filter,omitempty"`
+	Devices   []string      `json:"devices,omitempty" yaml:"devices,omitempty"` // Pinned device keys
+	Group     string        `json:"group,omitempty" yaml:"group,omitempty"`     // Group of the pinned devices
+	Servers   []string      `json:"servers,omitempty" yaml:"servers,omitempty"` // Server URLs of the pinned devices
+	CreatedAt string        `json:"created_at,omitempty" yaml:"created_at,omitempty"`
+}
+
+// DeviceFilter mirrors the device selector flags; empty fields are unset.
+type DeviceFilter struct {
+	Group              string   `json:"group,omitempty" yaml:"group,omitempty"`
+	Server             string   `json:"server,omitempty" yaml:"server,omitempty"`
+	UUID               string   `json:"uuid,omitempty" yaml:"uuid,omitempty"`
+	Seat               *int     `json:"seat,omitempty" yaml:"seat,omitempty"`
+	ADB                string   `json:"adb,omitempty" yaml:"adb,omitempty"`
+	USB                string   `json:"usb,omitempty" yaml:"usb,omitempty"`
+	Online             string   `json:"online,omitempty" yaml:"online,omitempty"`
+	HasIP              string   `json:"has_ip,omitempty" yaml:"has_ip,omitempty"`
+	HasUUID            string   `json:"has_uuid,omitempty" yaml:"has_uuid,omitempty"`
+	Platform           string   `json:"platform,omitempty" yaml:"platform,omitempty"`
+	Authorized         bool     `json:"authorized,omitempty" yaml:"authorized,omitempty"`
+	LicenseStatus      string   `json:"license_status,omitempty" yaml:"license_status,omitempty"`
+	ClusterContains    string   `json:"cluster_contains,omitempty" yaml:"cluster_contains,omitempty"`
+	ClusterNotContains string   `json:"cluster_not_contains,omitempty" yaml:"cluster_not_contains,omitempty"`
+	SNGT               string   `json:"sn_gt,omitempty" yaml:"sn_gt,omitempty"`
+	SNLT               string   `json:"sn_lt,omitempty" yaml:"sn_lt,omitempty"`
+	ExpiringWithin     int      `json:"expiring_within,omitempty" yaml:"expiring_within,omitempty"`
+	Where              string   `json:"where,omitempty" yaml:"where,omitempty"`
+	Labels             []string `json:"labels,omitempty" yaml:"labels,omitempty"`
 }
 
 type AdminConfig struct {
 	Token    string `json:"token" yaml:"token"`
 	Username string `json:"username" yaml:"username"`
+	Env      string `json:"env,omitempty" yaml:"env,omitempty"`           // Name in AdminEnvs, informational
+	BaseURL  string `json:"base-url,omitempty" yaml:"base-url,omitempty"` // Empty means the production admin API
+}
+
+type DHCPConfig struct {
+	URL      string `json:"url" yaml:"url"`
+	Token    string `json:"token" yaml:"token"`
+	Username string `json:"username" yaml:"username"`
 }
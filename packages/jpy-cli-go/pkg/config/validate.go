@@ -0,0 +1,243 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Issue is one problem found by validation. Warnings do not stop the CLI
+// from using the file.
+type Issue struct {
+	File    string `json:"file"`
+	Key     string `json:"key"`
+	Warning bool   `json:"warning"`
+	Message string `json:"message"`
+}
+
+// ValidateSettingsFile checks config.yaml for syntax errors, unknown keys
+// and invalid values. A missing file is valid.
+func ValidateSettingsFile(path string) []Issue {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return []Issue{{File: path, Message: err.Error()}}
+	}
+
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return []Issue{{File: path, Message: fmt.Sprintf("YAML 语法错误: %v", err)}}
+	}
+	var issues []Issue
+	for _, key := range unknownKeys(raw, reflect.TypeOf(Settings{}), "yaml", "") {
+		issues = append(issues, Issue{File: path, Key: key, Message: "未知的配置项"})
+	}
+
+	var s Settings
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return append(issues, Issue{File: path, Message: fmt.Sprintf("无效的值: %v", err)})
+	}
+	for _, key := range SettingKeys() {
+		if err := checkSetting(&s, key); err != nil {
+			issues = append(issues, Issue{File: path, Key: key, Message: err.Error()})
+		}
+	}
+	return issues
+}
+
+// ValidateSettingsEnv checks the JPY_* overrides of settings that are set.
+func ValidateSettingsEnv() []Issue {
+	var issues []Issue
+	for _, key := range SettingKeys() {
+		val, ok := os.LookupEnv(SettingEnv(key))
+		if !ok || val == "" {
+			continue
+		}
+		s := DefaultSettings()
+		if err := SetSetting(&s, key, val); err != nil {
+			issues = append(issues, Issue{File: "$" + SettingEnv(key), Key: key, Message: err.Error()})
+		}
+	}
+	return issues
+}
+
+// ValidateConfigFile checks a config.json or context file without
+// decrypting it: syntax, unknown keys, schema version and references.
+func ValidateConfigFile(path string) []Issue {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return []Issue{{File: path, Message: err.Error()}}
+	}
+
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return []Issue{{File: path, Message: fmt.Sprintf("JSON 语法错误: %v", err)}}
+	}
+	var issues []Issue
+	add := func(warning bool, key, format string, args ...interface{}) {
+		issues = append(issues, Issue{File: path, Key: key, Warning: warning, Message: fmt.Sprintf(format, args...)})
+	}
+	for _, key := range unknownKeys(raw, reflect.TypeOf(Config{}), "json", "") {
+		add(true, key, "未知的配置项")
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		add(false, "", "无效的值: %v", err)
+		return issues
+	}
+
+	if cfg.Version > SchemaVersion {
+		add(false, "version", "版本 %d 高于当前程序支持的版本 %d，请升级 jpy", cfg.Version, SchemaVersion)
+	} else if pending := PendingMigrations(cfg.Version); len(pending) > 0 {
+		add(true, "version", "版本 %d 将在下次保存时升级到 %d (%s)", cfg.Version, SchemaVersion, strings.Join(pending, "; "))
+	}
+
+	if cfg.Encryption == nil {
+		for _, f := range secrets(&cfg) {
+			if IsEncrypted(*f) {
+				add(false, "encryption", "包含加密字段但缺少 encryption 参数")
+				break
+			}
+		}
+	} else if cfg.Encryption.KDF != kdfScrypt {
+		add(false, "encryption.kdf", "不支持的密钥派生算法: %s", cfg.Encryption.KDF)
+	}
+
+	groups := make([]string, 0, len(cfg.Groups))
+	for name := range cfg.Groups {
+		groups = append(groups, name)
+	}
+	sort.Strings(groups)
+	for _, name := range groups {
+		seen := make(map[string]bool)
+		for i, s := range cfg.Groups[name] {
+			key := fmt.Sprintf("groups.%s[%d]", name, i)
+			if strings.TrimSpace(s.URL) == "" {
+				add(false, key+".url", "服务器地址为空")
+			} else if seen[s.URL] {
+				add(true, key+".url", "重复的服务器地址: %s", s.URL)
+			}
+			seen[s.URL] = true
+			if s.LastLoginTime != "" && !validTime(s.LastLoginTime) {
+				add(true, key+".last_login_time", "无法解析的时间: %s", s.LastLoginTime)
+			}
+		}
+	}
+	if cfg.ActiveGroup != "" {
+		if _, ok := cfg.Groups[cfg.ActiveGroup]; !ok {
+			add(true, "active_group", "当前分组 '%s' 不存在", cfg.ActiveGroup)
+		}
+	}
+
+	if cfg.ActiveContext != "" {
+		if _, ok := cfg.Contexts[cfg.ActiveContext]; !ok {
+			add(false, "active_context", "当前上下文 '%s' 不存在", cfg.ActiveContext)
+		}
+	}
+	names := make([]string, 0, len(cfg.Contexts))
+	for name := range cfg.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ctx := cfg.Contexts[name]
+		key := "contexts." + name
+		if ctx.AdminEnv != "" && ctx.AdminEnv != productionEnv {
+			if _, ok := cfg.AdminEnvs[ctx.AdminEnv]; !ok {
+				add(false, key+".admin-env", "后台环境 '%s' 不存在", ctx.AdminEnv)
+			}
+		}
+		if ctx.MaxConcurrency < 0 || ctx.ConnectTimeout < 0 {
+			add(false, key, "并发数与超时不能为负数")
+		}
+		if ctx.File != "" {
+			if _, err := os.Stat(ctx.FilePath()); err != nil {
+				add(false, key+".file", "上下文配置文件不可用: %v", err)
+			}
+		} else if ctx.Group != "" {
+			if _, ok := cfg.Groups[ctx.Group]; !ok {
+				add(true, key+".group", "分组 '%s' 不存在", ctx.Group)
+			}
+		}
+	}
+	return issues
+}
+
+func validTime(s string) bool {
+	_, err := time.Parse(time.RFC3339, s)
+	return err == nil
+}
+
+// unknownKeys returns the keys of raw, a decoded JSON or YAML document, that
+// have no field with a matching tag in t. Nested structs, maps and slices
+// are checked recursively.
+func unknownKeys(raw interface{}, t reflect.Type, tag, prefix string) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	var unknown []string
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		fields := make(map[string]reflect.Type)
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name := strings.Split(f.Tag.Get(tag), ",")[0]
+			if f.PkgPath != "" || name == "" || name == "-" {
+				continue
+			}
+			fields[name] = f.Type
+		}
+		for _, k := range sortedKeys(m) {
+			ft, ok := fields[k]
+			if !ok {
+				unknown = append(unknown, prefix+k)
+				continue
+			}
+			unknown = append(unknown, unknownKeys(m[k], ft, tag, prefix+k+".")...)
+		}
+	case reflect.Map:
+		m, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		for _, k := range sortedKeys(m) {
+			unknown = append(unknown, unknownKeys(m[k], t.Elem(), tag, prefix+k+".")...)
+		}
+	case reflect.Slice:
+		items, ok := raw.([]interface{})
+		if !ok {
+			return nil
+		}
+		base := strings.TrimSuffix(prefix, ".")
+		for i, item := range items {
+			unknown = append(unknown, unknownKeys(item, t.Elem(), tag, fmt.Sprintf("%s[%d].", base, i))...)
+		}
+	}
+	return unknown
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
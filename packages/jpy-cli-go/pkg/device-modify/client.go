@@ -0,0 +1,325 @@
+package modify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	wsclient "jpy-cli/pkg/client/ws"
+	"jpy-cli/pkg/logger"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const DefaultHeartbeatInterval = 30 * time.Second
+
+// Client talks to the modify WebSocket service on top of wsclient.
+// Requests are correlated by seq; responses with seq 0 fall back to the oldest
+// pending request of the same function code.
+type Client struct {
+	URL               string
+	HeartbeatInterval time.Duration
+	Timeout           time.Duration // Wait for a response, and the handshake unless ConnectTimeout is set
+	ConnectTimeout    time.Duration
+
+	// OnPush receives messages that do not answer a pending request.
+	OnPush func(msg *Message)
+
+	ws      *wsclient.Client
+	seq     uint32
+	mu      sync.Mutex
+	pending []*pendingRequest
+}
+
+type pendingRequest struct {
+	f   int
+	seq int
+	ch  chan *Message
+}
+
+func NewClient(rawURL string) *Client {
+	return &Client{
+		URL:               rawURL,
+		HeartbeatInterval: DefaultHeartbeatInterval,
+		Timeout:           10 * time.Second,
+	}
+}
+
+func (c *Client) Connect() error {
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return fmt.Errorf("无效的地址: %v", err)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("无效的地址: %s", c.URL)
+	}
+
+	endpoint := u.Path
+	if endpoint == "" {
+		endpoint = "/"
+	}
+	base := url.URL{Scheme: u.Scheme, Host: u.Host}
+
+	ws := wsclient.NewClient(base.String(), "")
+	ws.Endpoint = endpoint
+	ws.Timeout = c.Timeout
+	if c.ConnectTimeout > 0 {
+		ws.Timeout = c.ConnectTimeout
+	}
+	if u.RawQuery != "" {
+		ws.Params = make(map[string]string)
+		for k, v := range u.Query() {
+			ws.Params[k] = v[0]
+		}
+	}
+	ws.OnRawMessage = c.handleFrame
+
+	if err := ws.Connect(); err != nil {
+		return err
+	}
+	c.ws = ws
+
+	go c.heartbeat()
+	return nil
+}
+
+func (c *Client) Close() {
+	if c.ws != nil {
+		c.ws.Close()
+	}
+}
+
+// Done is closed when the connection is lost or closed.
+func (c *Client) Done() <-chan struct{} {
+	if c.ws == nil {
+		ch := make(chan struct{})
+		close(ch)
+		return ch
+	}
+	return c.ws.Done()
+}
+
+// heartbeat sends an empty binary frame every HeartbeatInterval.
+func (c *Client) heartbeat() {
+	interval := c.HeartbeatInterval
+	if interval <= 0 {
+		interval = DefaultHeartbeatInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.ws.SendRaw([]byte{}); err != nil {
+				logger.Log.Debug("改机心跳发送失败", "error", err)
+				return
+			}
+		case <-c.ws.Done():
+			return
+		}
+	}
+}
+
+func (c *Client) handleFrame(frameType int, data []byte) {
+	if frameType != websocket.TextMessage || len(data) == 0 {
+		return
+	}
+	msg, err := DecodeMessage(data)
+	if err != nil {
+		logger.Log.Debug("解析改机消息失败", "error", err)
+		return
+	}
+
+	if p := c.takePending(msg); p != nil {
+		p.ch <- msg
+		return
+	}
+	if c.OnPush != nil {
+		c.OnPush(msg)
+	}
+}
+
+func (c *Client) takePending(msg *Message) *pendingRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	idx := -1
+	for i, p := range c.pending {
+		if msg.Seq != 0 && p.seq == msg.Seq {
+			idx = i
+			break
+		}
+		if idx < 0 && msg.F != 0 && p.f == msg.F {
+			idx = i
+		}
+	}
+	if idx < 0 {
+		return nil
+	}
+	p := c.pending[idx]
+	c.pending = append(c.pending[:idx], c.pending[idx+1:]...)
+	return p
+}
+
+func (c *Client) removePending(p *pendingRequest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, q := range c.pending {
+		if q == p {
+			c.pending = append(c.pending[:i], c.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+// DecodeMessage parses an envelope, or a bare message when the content field is absent.
+func DecodeMessage(data []byte) (*Message, error) {
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err == nil && env.Content != "" {
+		data = []byte(env.Content)
+	}
+	var msg Message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// Request sends f with data and waits for the matching response.
+func (c *Client) Request(f int, data interface{}) (*Message, error) {
+	if c.ws == nil {
+		return nil, errors.New("未连接")
+	}
+
+	seq := int(atomic.AddUint32(&c.seq, 1))
+	content, err := json.Marshal(map[string]interface{}{
+		"f":    f,
+		"data": data,
+		"req":  true,
+		"seq":  seq,
+	})
+	if err != nil {
+		return nil, err
+	}
+	frame, err := json.Marshal(Envelope{Type: "F", Content: string(content)})
+	if err != nil {
+		return nil, err
+	}
+
+	p := &pendingRequest{f: f, seq: seq, ch: make(chan *Message, 1)}
+	c.mu.Lock()
+	c.pending = append(c.pending, p)
+	c.mu.Unlock()
+	defer c.removePending(p)
+
+	if err := c.ws.SendText(frame); err != nil {
+		return nil, err
+	}
+
+	select {
+	case msg := <-p.ch:
+		if msg.Code != 0 && msg.Code != 200 {
+			if msg.Msg == "" {
+				msg.Msg = "未知错误"
+			}
+			return msg, fmt.Errorf("请求失败 (code %d): %s", msg.Code, msg.Msg)
+		}
+		return msg, nil
+	case <-time.After(c.Timeout):
+		return nil, errors.New("等待响应超时")
+	case <-c.ws.Done():
+		return nil, errors.New("连接已关闭")
+	}
+}
+
+// ListDevices returns the devices known to the modify service.
+func (c *Client) ListDevices() ([]Device, error) {
+	msg, err := c.Request(FuncDeviceList, map[string]interface{}{"flag": false})
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []Device
+	if err := json.Unmarshal(msg.Data, &devices); err == nil {
+		return devices, nil
+	}
+	var wrapper struct {
+		Data []Device `json:"data"`
+		List []Device `json:"list"`
+	}
+	if err := json.Unmarshal(msg.Data, &wrapper); err == nil {
+		if wrapper.List != nil {
+			return wrapper.List, nil
+		}
+		return wrapper.Data, nil
+	}
+	return nil, errors.New("解析设备列表失败")
+}
+
+// StartModify starts one main task covering deviceIDs and returns its ID.
+func (c *Client) StartModify(deviceIDs []int64) (string, error) {
+	if len(deviceIDs) == 0 {
+		return "", errors.New("没有指定设备")
+	}
+
+	params := make([]modifyParams, 0, len(deviceIDs))
+	for _, id := range deviceIDs {
+		params = append(params, modifyParams{
+			DeviceID: id,
+			Type:     "changeDevice",
+			Func:     1,
+			ParamsAll: map[string]interface{}{
+				"sdk":     map[string]interface{}{"value": 31},
+				"zhiding": map[string]string{"PKG1": "", "PKG2": ""},
+				"huanji":  map[string]interface{}{},
+			},
+		})
+	}
+
+	msg, err := c.Request(FuncModifyDevice, params)
+	if err != nil {
+		return "", err
+	}
+	return parseMainTaskID(msg.Data)
+}
+
+func parseMainTaskID(data json.RawMessage) (string, error) {
+	var id string
+	if err := json.Unmarshal(data, &id); err == nil && id != "" {
+		return id, nil
+	}
+	var wrapper struct {
+		MainTaskID json.RawMessage `json:"mainTaskId"`
+	}
+	if err := json.Unmarshal(data, &wrapper); err == nil && len(wrapper.MainTaskID) > 0 {
+		return strings.Trim(string(wrapper.MainTaskID), `"`), nil
+	}
+	return "", errors.New("响应中缺少 mainTaskId")
+}
+
+// QueryStatus returns the current status of a main task.
+func (c *Client) QueryStatus(mainTaskID string) (*TaskStatus, error) {
+	msg, err := c.Request(FuncModifyStatus, map[string]string{"mainTaskId": mainTaskID})
+	if err != nil {
+		return nil, err
+	}
+
+	var status TaskStatus
+	if err := json.Unmarshal(msg.Data, &status); err != nil {
+		// Accept a bare sub task list as well
+		var tasks []SubTask
+		if err2 := json.Unmarshal(msg.Data, &tasks); err2 != nil {
+			return nil, fmt.Errorf("解析改机状态失败: %v", err)
+		}
+		status.Tasks = tasks
+	}
+	if status.MainTaskID == "" {
+		status.MainTaskID = mainTaskID
+	}
+	return &status, nil
+}
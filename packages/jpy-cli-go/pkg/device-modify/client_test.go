@@ -0,0 +1,122 @@
+package modify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// newTestServer answers each request with reply(msg). A zero seq in the reply
+// exercises the function-code fallback.
+func newTestServer(t *testing.T, reply func(msg *Message) (int, interface{})) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		defer conn.Close()
+		for {
+			mt, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if mt != websocket.TextMessage {
+				continue // heartbeat
+			}
+			msg, err := DecodeMessage(data)
+			if err != nil {
+				t.Errorf("decode: %v", err)
+				return
+			}
+			seq, payload := reply(msg)
+			content, _ := json.Marshal(map[string]interface{}{"f": msg.F, "seq": seq, "code": 0, "data": payload})
+			out, _ := json.Marshal(Envelope{Type: "F", Content: string(content)})
+			if err := conn.WriteMessage(websocket.TextMessage, out); err != nil {
+				return
+			}
+		}
+	}))
+}
+
+func connectTest(t *testing.T, srv *httptest.Server) *Client {
+	c := NewClient("ws" + strings.TrimPrefix(srv.URL, "http") + "/ws")
+	c.Timeout = 2 * time.Second
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestClientRequests(t *testing.T) {
+	srv := newTestServer(t, func(msg *Message) (int, interface{}) {
+		switch msg.F {
+		case FuncDeviceList:
+			return 0, []map[string]interface{}{
+				{"deviceId": 101, "seat": 1, "uuid": "A", "online": true},
+				{"id": 102, "seat": 2, "uuid": "B"},
+			}
+		case FuncModifyDevice:
+			var params []modifyParams
+			if err := json.Unmarshal(msg.Data, &params); err != nil || len(params) != 2 || params[1].DeviceID != 102 {
+				t.Errorf("unexpected modify params: %s", msg.Data)
+			}
+			return msg.Seq, map[string]interface{}{"mainTaskId": "task-1"}
+		case FuncModifyStatus:
+			return msg.Seq, map[string]interface{}{
+				"mainTaskId": "task-1",
+				"status":     1,
+				"tasks": []map[string]interface{}{
+					{"deviceId": 101, "status": 2, "progress": 100},
+					{"deviceId": 102, "status": "running", "progress": 40},
+				},
+			}
+		}
+		return msg.Seq, nil
+	})
+	defer srv.Close()
+
+	c := connectTest(t, srv)
+	defer c.Close()
+
+	devices, err := c.ListDevices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 2 || devices[1].ID != 102 || !devices[0].Online {
+		t.Fatalf("unexpected devices: %+v", devices)
+	}
+
+	id, err := c.StartModify([]int64{101, 102})
+	if err != nil || id != "task-1" {
+		t.Fatalf("StartModify = %q, %v", id, err)
+	}
+
+	status, err := c.QueryStatus(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Finished() {
+		t.Fatal("task should not be finished")
+	}
+	counts := status.Counts()
+	if counts[StateSuccess] != 1 || counts[StateRunning] != 1 {
+		t.Fatalf("unexpected counts: %v", counts)
+	}
+}
+
+func TestDecodeMessageBare(t *testing.T) {
+	msg, err := DecodeMessage([]byte(`{"f":612,"seq":3,"code":0,"data":{"mainTaskId":"x"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.F != FuncModifyStatus || msg.Seq != 3 {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+}
@@ -0,0 +1,163 @@
+package modify
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Function codes of the modify service
+const (
+	FuncDeviceList   = 5
+	FuncModifyDevice = 515
+	FuncModifyStatus = 612
+)
+
+// Envelope wraps every request and response: {"type":"F","content":"<json Message>"}.
+type Envelope struct {
+	Type    string `json:"type"`
+	Content string `json:"content"`
+}
+
+// Message is the decoded content of an envelope.
+type Message struct {
+	F    int             `json:"f"`
+	Req  bool            `json:"req"`
+	Seq  int             `json:"seq"`
+	Code int             `json:"code"`
+	Msg  string          `json:"msg,omitempty"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// Device is one entry of the modify service device list.
+type Device struct {
+	ID      int64  `json:"deviceId"`
+	Seat    int    `json:"seat"`
+	UUID    string `json:"uuid"`
+	Model   string `json:"model"`
+	IP      string `json:"ip"`
+	Online  bool   `json:"online"`
+	Version string `json:"version,omitempty"`
+}
+
+// UnmarshalJSON accepts "id" when "deviceId" is missing.
+func (d *Device) UnmarshalJSON(b []byte) error {
+	type alias Device
+	var raw struct {
+		alias
+		AltID *int64 `json:"id"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	*d = Device(raw.alias)
+	if d.ID == 0 && raw.AltID != nil {
+		d.ID = *raw.AltID
+	}
+	return nil
+}
+
+// modifyParams is the per-device payload of f=515.
+type modifyParams struct {
+	DeviceID  int64       `json:"deviceId"`
+	Type      string      `json:"type"`
+	Func      int         `json:"func"`
+	ParamsAll interface{} `json:"paramsAll"`
+}
+
+// TaskState is the state of a modify task as reported by the service.
+type TaskState int
+
+const (
+	StatePending TaskState = iota
+	StateRunning
+	StateSuccess
+	StateFailed
+)
+
+func (s TaskState) String() string {
+	switch s {
+	case StatePending:
+		return "等待中"
+	case StateRunning:
+		return "执行中"
+	case StateSuccess:
+		return "成功"
+	case StateFailed:
+		return "失败"
+	}
+	return fmt.Sprintf("未知(%d)", int(s))
+}
+
+// Finished reports whether the state is terminal.
+func (s TaskState) Finished() bool {
+	return s == StateSuccess || s == StateFailed
+}
+
+// UnmarshalJSON accepts numbers, numeric strings and state names.
+func (s *TaskState) UnmarshalJSON(b []byte) error {
+	var n int
+	if err := json.Unmarshal(b, &n); err == nil {
+		*s = TaskState(n)
+		return nil
+	}
+	var str string
+	if err := json.Unmarshal(b, &str); err != nil {
+		return err
+	}
+	if n, err := strconv.Atoi(str); err == nil {
+		*s = TaskState(n)
+		return nil
+	}
+	switch str {
+	case "pending", "waiting":
+		*s = StatePending
+	case "running", "processing":
+		*s = StateRunning
+	case "success", "done", "finished":
+		*s = StateSuccess
+	case "failed", "fail", "error":
+		*s = StateFailed
+	default:
+		return fmt.Errorf("unknown task state %q", str)
+	}
+	return nil
+}
+
+// SubTask is the modify progress of a single device.
+type SubTask struct {
+	DeviceID int64     `json:"deviceId"`
+	TaskID   string    `json:"taskId,omitempty"`
+	Status   TaskState `json:"status"`
+	Progress int       `json:"progress"`
+	Msg      string    `json:"msg,omitempty"`
+}
+
+// TaskStatus is the f=612 response for a main task.
+type TaskStatus struct {
+	MainTaskID string    `json:"mainTaskId"`
+	Status     TaskState `json:"status"`
+	Tasks      []SubTask `json:"tasks"`
+}
+
+// Finished reports whether the main task and every sub task reached a terminal state.
+func (t *TaskStatus) Finished() bool {
+	if len(t.Tasks) == 0 {
+		return t.Status.Finished()
+	}
+	for _, st := range t.Tasks {
+		if !st.Status.Finished() {
+			return false
+		}
+	}
+	return true
+}
+
+// Counts returns the number of sub tasks in each state.
+func (t *TaskStatus) Counts() map[TaskState]int {
+	counts := make(map[TaskState]int)
+	for _, st := range t.Tasks {
+		counts[st.Status]++
+	}
+	return counts
+}
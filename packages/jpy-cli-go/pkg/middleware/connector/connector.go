@@ -24,12 +24,8 @@ func NewConnectorService(cfg *config.Config) *ConnectorService {
 func (s *ConnectorService) Connect(server config.LocalServerConfig) (*wsclient.Client, error) {
 	ws := wsclient.NewClient(server.URL, server.Token)
 
-	// Apply global timeout setting if available
-	if config.GlobalSettings.ConnectTimeout > 0 {
-		ws.Timeout = time.Duration(config.GlobalSettings.ConnectTimeout) * time.Second
-	} else {
-		ws.Timeout = 3 * time.Second // Default fallback
-	}
+	ws.Timeout = config.GlobalSettings.ConnectTimeoutDuration()
+	ws.RequestTimeout = config.GlobalSettings.RequestTimeoutDuration()
 
 	err := ws.Connect()
 	if err == nil {
@@ -75,12 +71,8 @@ func (s *ConnectorService) ConnectDeviceTerminal(server config.LocalServerConfig
 	ws.Endpoint = "/box/guard"
 	ws.Params = map[string]string{"id": fmt.Sprintf("%d", deviceID)}
 
-	// Apply global timeout setting if available
-	if config.GlobalSettings.ConnectTimeout > 0 {
-		ws.Timeout = time.Duration(config.GlobalSettings.ConnectTimeout) * time.Second
-	} else {
-		ws.Timeout = 5 * time.Second
-	}
+	ws.Timeout = config.GlobalSettings.ConnectTimeoutDuration()
+	ws.RequestTimeout = config.GlobalSettings.RequestTimeoutDuration()
 
 	err := ws.Connect()
 	if err == nil {
@@ -122,12 +114,8 @@ func (s *ConnectorService) ConnectGuard(server config.LocalServerConfig) (*wscli
 	ws.Endpoint = "/box/guard"
 	ws.Params = map[string]string{"id": "0"}
 
-	// Apply global timeout setting if available
-	if config.GlobalSettings.ConnectTimeout > 0 {
-		ws.Timeout = time.Duration(config.GlobalSettings.ConnectTimeout) * time.Second
-	} else {
-		ws.Timeout = 3 * time.Second // Default fallback
-	}
+	ws.Timeout = config.GlobalSettings.ConnectTimeoutDuration()
+	ws.RequestTimeout = config.GlobalSettings.RequestTimeoutDuration()
 
 	err := ws.Connect()
 	if err == nil {
@@ -173,12 +161,8 @@ func (s *ConnectorService) ConnectMirror(server config.LocalServerConfig, seat i
 	ws.Endpoint = "/box/mirror"
 	ws.Params = map[string]string{"id": fmt.Sprintf("%d", seat)}
 
-	// Apply global timeout setting if available
-	if config.GlobalSettings.ConnectTimeout > 0 {
-		ws.Timeout = time.Duration(config.GlobalSettings.ConnectTimeout) * time.Second
-	} else {
-		ws.Timeout = 3 * time.Second // Default fallback
-	}
+	ws.Timeout = config.GlobalSettings.ConnectTimeoutDuration()
+	ws.RequestTimeout = config.GlobalSettings.RequestTimeoutDuration()
 
 	err := ws.Connect()
 	if err == nil {
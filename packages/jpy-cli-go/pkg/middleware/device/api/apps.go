@@ -0,0 +1,77 @@
+package api
+
+import (
+	"jpy-cli/pkg/middleware/model"
+)
+
+// iOS app list types (f=290)
+const (
+	AppListAny      = "any"
+	AppListSystem   = "system"
+	AppListInternal = "internal"
+	AppListUser     = "user"
+)
+
+// ListApps returns the installed apps. appType is only honoured by iOS devices.
+func (api *DeviceAPI) ListApps(seat int, appType string) (interface{}, error) {
+	var data interface{}
+	if appType != "" {
+		data = map[string]interface{}{"seat": seat, "type": appType}
+	}
+	return api.sendSeatRequest(seat, model.FuncGetAppList, data)
+}
+
+// StartApp launches an app by package name (Android) or bundle ID (iOS).
+func (api *DeviceAPI) StartApp(seat int, packageName string) error {
+	_, err := api.sendSeatRequest(seat, model.FuncStartApp, map[string]interface{}{"packageName": packageName})
+	return err
+}
+
+// KillApp stops a running app by bundle ID (iOS).
+func (api *DeviceAPI) KillApp(seat int, packageName string) error {
+	_, err := api.sendSeatRequest(seat, model.FuncKillApp, map[string]interface{}{"packageName": packageName})
+	return err
+}
+
+// UninstallApp removes an app by bundle ID (iOS).
+func (api *DeviceAPI) UninstallApp(seat int, bundleID string) error {
+	_, err := api.sendSeatRequest(seat, model.FuncUninstallApp, map[string]interface{}{"seat": seat, "bundleId": bundleID})
+	return err
+}
+
+// SetLanguageAndLocale sets the system language and region, e.g. "zh-Hans" and "zh_CN" (iOS).
+func (api *DeviceAPI) SetLanguageAndLocale(seat int, language, locale string) error {
+	_, err := api.sendSeatRequest(seat, model.FuncSetLanguageLocale, map[string]interface{}{
+		"seat":     seat,
+		"language": language,
+		"locale":   locale,
+	})
+	return err
+}
+
+// CopyToPhotos copies a file on the device into the photo library (iOS).
+func (api *DeviceAPI) CopyToPhotos(seat int, path string) error {
+	_, err := api.sendSeatRequest(seat, model.FuncCopyToPhotos, map[string]interface{}{"path": path})
+	return err
+}
+
+// WipeDevice erases all content and settings (iOS).
+func (api *DeviceAPI) WipeDevice(seat int) error {
+	_, err := api.sendSeatRequest(seat, model.FuncWipeDevice, map[string]interface{}{"seat": seat})
+	return err
+}
+
+// ExecuteShell runs a shell command on the device (Android).
+func (api *DeviceAPI) ExecuteShell(seat int, shell string) (interface{}, error) {
+	return api.sendSeatRequest(seat, model.FuncExecuteShell, map[string]interface{}{"shell": shell})
+}
+
+// ControlADBMirror enables or disables ADB through the mirror channel (Android).
+func (api *DeviceAPI) ControlADBMirror(seat int, enable bool) error {
+	mode := 0
+	if enable {
+		mode = 1
+	}
+	_, err := api.sendSeatRequest(seat, model.FuncControlADBMirror, map[string]interface{}{"mode": mode})
+	return err
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"jpy-cli/pkg/config"
+	"jpy-cli/pkg/middleware/model"
+	"sync"
+)
+
+// Power control modes (f=107)
+const (
+	PowerModeOff    = 0
+	PowerModeOn     = 1
+	PowerModeReboot = 2
+)
+
+// BatchSwitchUSBMode switches the USB mode of multiple seats (true for Host/OTG, false for Device/USB).
+func (api *DeviceAPI) BatchSwitchUSBMode(seats []int, otg bool) []model.BatchOperationResult {
+	mode := 1 // USB
+	if otg {
+		mode = 0 // OTG
+	}
+	return api.sendBatchRequest(model.FuncSwitchUSBGuard, seats, mode)
+}
+
+// BatchPowerControl sends a power control command to multiple seats.
+func (api *DeviceAPI) BatchPowerControl(seats []int, mode int) []model.BatchOperationResult {
+	return api.sendBatchRequest(model.FuncPowerControl, seats, mode)
+}
+
+// BatchRebootDevice reboots multiple seats.
+func (api *DeviceAPI) BatchRebootDevice(seats []int) []model.BatchOperationResult {
+	return api.BatchPowerControl(seats, PowerModeReboot)
+}
+
+// BatchEnableADB enables or disables ADB on multiple seats.
+func (api *DeviceAPI) BatchEnableADB(seats []int, enable bool) []model.BatchOperationResult {
+	mode := 0
+	if enable {
+		mode = 2
+	}
+	return api.sendBatchRequest(model.FuncEnableADB, seats, mode)
+}
+
+// sendBatchRequest sends the single-seat request of code to every seat, as the
+// guard has no multi-seat form. Requests share the connection and run
+// concurrently, bounded by max_concurrency. Results keep the order of seats.
+func (api *DeviceAPI) sendBatchRequest(code int, seats []int, mode int) []model.BatchOperationResult {
+	results := make([]model.BatchOperationResult, len(seats))
+
+	concurrency := config.GlobalSettings.MaxConcurrency
+	if concurrency <= 0 {
+		concurrency = 5
+	}
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, concurrency)
+
+	for i, seat := range seats {
+		wg.Add(1)
+		go func(i, seat int) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			err := api.sendControlRequest(code, map[string]interface{}{
+				"seat": seat,
+				"mode": mode,
+			})
+			results[i] = newBatchResult(seat, err)
+		}(i, seat)
+	}
+	wg.Wait()
+	return results
+}
+
+func newBatchResult(seat int, err error) model.BatchOperationResult {
+	if err != nil {
+		return model.BatchOperationResult{Seat: seat, Success: false, Error: err.Error()}
+	}
+	return model.BatchOperationResult{Seat: seat, Success: true}
+}
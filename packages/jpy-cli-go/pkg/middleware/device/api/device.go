@@ -7,11 +7,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"jpy-cli/pkg/config"
 	"jpy-cli/pkg/middleware/model"
 	"jpy-cli/pkg/middleware/protocol"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/vmihailenco/msgpack/v5"
 )
@@ -34,7 +34,7 @@ func NewDeviceAPI(t protocol.Transport, baseURL, token string) *DeviceAPI {
 	}
 	client := &http.Client{
 		Transport: tr,
-		Timeout:   5 * time.Second,
+		Timeout:   config.GlobalSettings.HTTPTimeoutDuration(),
 	}
 
 	return &DeviceAPI{
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"jpy-cli/pkg/middleware/model"
 	"jpy-cli/pkg/middleware/protocol"
+	"sync"
 	"testing"
 )
 
@@ -67,3 +68,166 @@ func TestFetchDeviceList_Error(t *testing.T) {
 		t.Errorf("Expected 'connection failed', got '%v'", err)
 	}
 }
+
+// MockBatchTransport records the device IDs of addressed requests.
+type MockBatchTransport struct {
+	MockTransport
+	Calls [][]uint64
+}
+
+func (m *MockBatchTransport) SendRequestTo(f int, data interface{}, deviceIds []uint64) (*model.WSResponse, error) {
+	m.Calls = append(m.Calls, deviceIds)
+	return m.SendRequest(f, data)
+}
+
+var _ protocol.BatchTransport = &MockBatchTransport{}
+
+// SeatTransport answers each single-seat request like the guard: a bare
+// code 0, or code 1 for seats in Fail. It is safe for concurrent use.
+type SeatTransport struct {
+	Fail map[int]bool
+
+	mu       sync.Mutex
+	Requests []map[string]interface{}
+}
+
+func (m *SeatTransport) SendRequest(f int, data interface{}) (*model.WSResponse, error) {
+	req := data.(map[string]interface{})
+	m.mu.Lock()
+	m.Requests = append(m.Requests, req)
+	m.mu.Unlock()
+
+	code, msg := 0, ""
+	if m.Fail[req["seat"].(int)] {
+		code, msg = 1, "offline"
+	}
+	return &model.WSResponse{Code: &code, Msg: &msg}, nil
+}
+
+func TestBatchSwitchUSBMode_PerSeat(t *testing.T) {
+	transport := &SeatTransport{}
+	api := NewDeviceAPI(transport, "http://mock", "token")
+
+	seats := make([]int, 65)
+	for i := range seats {
+		seats[i] = i + 1
+	}
+
+	results := api.BatchSwitchUSBMode(seats, true)
+	if len(transport.Requests) != 65 {
+		t.Fatalf("Expected one request per seat, got %d", len(transport.Requests))
+	}
+	for _, req := range transport.Requests {
+		if req["mode"] != 0 {
+			t.Errorf("Expected OTG mode 0, got %v", req)
+		}
+	}
+	if len(results) != 65 {
+		t.Fatalf("Expected 65 results, got %d", len(results))
+	}
+	for i, r := range results {
+		if r.Seat != seats[i] || !r.Success {
+			t.Errorf("Unexpected result %d: %+v", i, r)
+		}
+	}
+}
+
+func TestBatchRebootDevice_PerSeatResults(t *testing.T) {
+	transport := &SeatTransport{Fail: map[int]bool{2: true}}
+	api := NewDeviceAPI(transport, "http://mock", "token")
+
+	results := api.BatchRebootDevice([]int{1, 2, 3})
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+	if !results[0].Success || results[1].Success || !results[2].Success {
+		t.Errorf("Unexpected results: %+v", results)
+	}
+	if results[1].Error != "operation failed (code 1): offline" {
+		t.Errorf("Unexpected error: %v", results[1].Error)
+	}
+	for _, req := range transport.Requests {
+		if req["mode"] != PowerModeReboot {
+			t.Errorf("Expected reboot mode, got %v", req)
+		}
+	}
+}
+
+func TestBatchEnableADB_TransportError(t *testing.T) {
+	transport := &MockTransport{Error: errors.New("connection failed")}
+	api := NewDeviceAPI(transport, "http://mock", "token")
+
+	results := api.BatchEnableADB([]int{4, 5}, true)
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	for _, r := range results {
+		if r.Success || r.Error != "connection failed" {
+			t.Errorf("Unexpected result: %+v", r)
+		}
+	}
+}
+
+func TestExecuteScript_AddressesSeat(t *testing.T) {
+	code := 0
+	resp := &model.WSResponse{Code: &code, Data: map[string]interface{}{"ok": true}}
+	transport := &MockBatchTransport{MockTransport: MockTransport{Response: resp}}
+	api := NewDeviceAPI(transport, "http://mock", "token")
+
+	data, err := api.ExecuteScript(37, "toast('hi')")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(transport.Calls) != 1 || len(transport.Calls[0]) != 1 || transport.Calls[0][0] != 37 {
+		t.Errorf("Expected request addressed to seat 37, got %v", transport.Calls)
+	}
+	if m, ok := data.(map[string]interface{}); !ok || m["ok"] != true {
+		t.Errorf("Unexpected result: %v", data)
+	}
+}
+
+func TestExecuteScript_ErrorCode(t *testing.T) {
+	code := 500
+	msg := "script error"
+	transport := &MockTransport{Response: &model.WSResponse{Code: &code, Msg: &msg}}
+	api := NewDeviceAPI(transport, "http://mock", "token")
+
+	if _, err := api.ExecuteScript(1, "throw 1"); err == nil {
+		t.Error("Expected error for non-zero code")
+	}
+}
+
+func TestGetWebviewURL_Shapes(t *testing.T) {
+	code := 0
+	for _, data := range []interface{}{"https://a.example", map[string]interface{}{"url": "https://a.example"}} {
+		transport := &MockTransport{Response: &model.WSResponse{Code: &code, Data: data}}
+		api := NewDeviceAPI(transport, "http://mock", "token")
+
+		u, err := api.GetWebviewURL(1)
+		if err != nil || u != "https://a.example" {
+			t.Errorf("Data %v: got %q, %v", data, u, err)
+		}
+	}
+}
+
+func TestNewDevice_PlatformAware(t *testing.T) {
+	code := 0
+	transport := &MockTransport{Response: &model.WSResponse{Code: &code}}
+	api := NewDeviceAPI(transport, "http://mock", "token")
+
+	ios := NewDevice(api, model.DeviceInfo{Seat: 1, Platform: model.PlatformIOS})
+	if err := ios.SetADB(true); !errors.Is(err, ErrUnsupportedPlatform) {
+		t.Errorf("Expected ErrUnsupportedPlatform for ADB on iOS, got %v", err)
+	}
+	if _, err := AsIOS(ios, "test"); err != nil {
+		t.Errorf("Expected iOS device, got %v", err)
+	}
+
+	android := NewDevice(api, model.DeviceInfo{Seat: 2, Platform: model.PlatformUnknown})
+	if err := android.SetADB(true); err != nil {
+		t.Errorf("Unexpected error for ADB on Android: %v", err)
+	}
+	if _, err := AsIOS(android, "test"); !errors.Is(err, ErrUnsupportedPlatform) {
+		t.Errorf("Expected ErrUnsupportedPlatform, got %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"fmt"
+	"jpy-cli/pkg/middleware/model"
+)
+
+// Toast shows a toast on Android, or a notification on iOS (f=325).
+func (api *DeviceAPI) Toast(seat int, text string) error {
+	_, err := api.sendSeatRequest(seat, model.FuncToast, map[string]interface{}{"text": text})
+	return err
+}
+
+// Speak reads text aloud via TTS, or Siri on iOS (f=324).
+func (api *DeviceAPI) Speak(seat int, text string) error {
+	_, err := api.sendSeatRequest(seat, model.FuncTTS, map[string]interface{}{"text": text})
+	return err
+}
+
+// OpenURL opens a URL or deep link in the built-in browser (f=323).
+func (api *DeviceAPI) OpenURL(seat int, url string) error {
+	_, err := api.sendSeatRequest(seat, model.FuncOpenURL, map[string]interface{}{"url": url})
+	return err
+}
+
+// GetWebviewURL returns the current URL of the app's webview (f=326).
+func (api *DeviceAPI) GetWebviewURL(seat int) (string, error) {
+	data, err := api.sendSeatRequest(seat, model.FuncGetAppWebviewURL, map[string]interface{}{})
+	if err != nil {
+		return "", err
+	}
+
+	switch v := data.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case map[string]interface{}:
+		if u, ok := v["url"].(string); ok {
+			return u, nil
+		}
+	}
+	return fmt.Sprintf("%v", data), nil
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"jpy-cli/pkg/middleware/model"
+)
+
+// ErrUnsupportedPlatform is returned when an operation is not available on the device's platform.
+var ErrUnsupportedPlatform = errors.New("当前设备平台不支持此操作")
+
+func unsupported(p model.Platform, op string) error {
+	return fmt.Errorf("%w: %s 设备不支持 %s", ErrUnsupportedPlatform, p, op)
+}
+
+// Device is a platform-aware handle to a single seat. It is usually backed by
+// a mirror channel connection for that seat.
+type Device interface {
+	Info() model.DeviceInfo
+	Platform() model.Platform
+
+	ListApps() (interface{}, error)
+	StartApp(id string) error
+
+	Toast(text string) error
+	Speak(text string) error
+	OpenURL(url string) error
+	GetWebviewURL() (string, error)
+
+	ExecuteScript(text string) (interface{}, error)
+	ExecuteScriptFile(path string) (interface{}, error)
+	HTTPRequest(opts HTTPRequestOptions) (interface{}, error)
+
+	// SetADB is Android only.
+	SetADB(enable bool) error
+}
+
+// NewDevice returns the Device implementation for the device's platform.
+// Devices of unknown platform are treated as Android.
+func NewDevice(api *DeviceAPI, info model.DeviceInfo) Device {
+	base := baseDevice{api: api, info: info}
+	if info.Platform == model.PlatformIOS {
+		return &IOSDevice{base}
+	}
+	return &AndroidDevice{base}
+}
+
+// AsAndroid returns the Android implementation, or ErrUnsupportedPlatform for op.
+func AsAndroid(d Device, op string) (*AndroidDevice, error) {
+	if a, ok := d.(*AndroidDevice); ok {
+		return a, nil
+	}
+	return nil, unsupported(d.Platform(), op)
+}
+
+// AsIOS returns the iOS implementation, or ErrUnsupportedPlatform for op.
+func AsIOS(d Device, op string) (*IOSDevice, error) {
+	if i, ok := d.(*IOSDevice); ok {
+		return i, nil
+	}
+	return nil, unsupported(d.Platform(), op)
+}
+
+type baseDevice struct {
+	api  *DeviceAPI
+	info model.DeviceInfo
+}
+
+func (d *baseDevice) Info() model.DeviceInfo   { return d.info }
+func (d *baseDevice) Platform() model.Platform { return d.info.Platform }
+
+func (d *baseDevice) StartApp(id string) error { return d.api.StartApp(d.info.Seat, id) }
+func (d *baseDevice) Toast(text string) error  { return d.api.Toast(d.info.Seat, text) }
+func (d *baseDevice) Speak(text string) error  { return d.api.Speak(d.info.Seat, text) }
+func (d *baseDevice) OpenURL(url string) error { return d.api.OpenURL(d.info.Seat, url) }
+
+func (d *baseDevice) GetWebviewURL() (string, error) {
+	return d.api.GetWebviewURL(d.info.Seat)
+}
+
+func (d *baseDevice) ExecuteScript(text string) (interface{}, error) {
+	return d.api.ExecuteScript(d.info.Seat, text)
+}
+
+func (d *baseDevice) ExecuteScriptFile(path string) (interface{}, error) {
+	return d.api.ExecuteScriptFile(d.info.Seat, path)
+}
+
+func (d *baseDevice) HTTPRequest(opts HTTPRequestOptions) (interface{}, error) {
+	return d.api.HTTPRequest(d.info.Seat, opts)
+}
+
+// AndroidDevice implements Device for Android phones.
+type AndroidDevice struct {
+	baseDevice
+}
+
+func (d *AndroidDevice) ListApps() (interface{}, error) {
+	return d.api.ListApps(d.info.Seat, "")
+}
+
+func (d *AndroidDevice) SetADB(enable bool) error {
+	return d.api.ControlADBMirror(d.info.Seat, enable)
+}
+
+// ExecuteShell runs a shell command on the device.
+func (d *AndroidDevice) ExecuteShell(shell string) (interface{}, error) {
+	return d.api.ExecuteShell(d.info.Seat, shell)
+}
+
+// IOSDevice implements Device for iPhones and iPads.
+type IOSDevice struct {
+	baseDevice
+}
+
+func (d *IOSDevice) ListApps() (interface{}, error) {
+	return d.ListAppsOfType(AppListUser)
+}
+
+func (d *IOSDevice) SetADB(enable bool) error {
+	return unsupported(d.Platform(), "ADB")
+}
+
+// ListAppsOfType lists apps of the given type (any/system/internal/user).
+func (d *IOSDevice) ListAppsOfType(appType string) (interface{}, error) {
+	return d.api.ListApps(d.info.Seat, appType)
+}
+
+func (d *IOSDevice) KillApp(bundleID string) error {
+	return d.api.KillApp(d.info.Seat, bundleID)
+}
+
+func (d *IOSDevice) UninstallApp(bundleID string) error {
+	return d.api.UninstallApp(d.info.Seat, bundleID)
+}
+
+func (d *IOSDevice) SetLanguageAndLocale(language, locale string) error {
+	return d.api.SetLanguageAndLocale(d.info.Seat, language, locale)
+}
+
+func (d *IOSDevice) CopyToPhotos(path string) error {
+	return d.api.CopyToPhotos(d.info.Seat, path)
+}
+
+func (d *IOSDevice) Wipe() error {
+	return d.api.WipeDevice(d.info.Seat)
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"fmt"
+	"jpy-cli/pkg/middleware/model"
+	"jpy-cli/pkg/middleware/protocol"
+)
+
+// HTTPRequestOptions describes an HTTP request made from the device (f=500).
+type HTTPRequestOptions struct {
+	URL        string      `json:"url"`
+	Method     string      `json:"method,omitempty"` // GET/POST/PUT/DELETE
+	Proxy      string      `json:"proxy,omitempty"`
+	Headers    []string    `json:"headers,omitempty"` // "Key: Value"
+	Body       interface{} `json:"body,omitempty"`
+	Timeout    int         `json:"timeout,omitempty"` // Seconds
+	SkipVerify bool        `json:"skipVerify,omitempty"`
+}
+
+// HTTPRequest makes an HTTP request from the device and returns the raw result.
+func (api *DeviceAPI) HTTPRequest(seat int, opts HTTPRequestOptions) (interface{}, error) {
+	return api.sendSeatRequest(seat, model.FuncHTTPRequest, opts)
+}
+
+// ExecuteScript runs JavaScript source on the device and returns its result.
+func (api *DeviceAPI) ExecuteScript(seat int, text string) (interface{}, error) {
+	return api.sendSeatRequest(seat, model.FuncExecuteJSScript, map[string]interface{}{"text": text})
+}
+
+// ExecuteScriptFile runs a JavaScript file already present on the device.
+func (api *DeviceAPI) ExecuteScriptFile(seat int, path string) (interface{}, error) {
+	return api.sendSeatRequest(seat, model.FuncExecuteJSScriptFile, map[string]interface{}{"path": path})
+}
+
+// sendSeatRequest sends a request addressed to a single seat and returns the response data.
+func (api *DeviceAPI) sendSeatRequest(seat int, code int, data interface{}) (interface{}, error) {
+	var resp *model.WSResponse
+	var err error
+	if bt, ok := api.transport.(protocol.BatchTransport); ok {
+		resp, err = bt.SendRequestTo(code, data, []uint64{uint64(seat)})
+	} else {
+		resp, err = api.transport.SendRequest(code, data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.Code != nil && *resp.Code != 0 {
+		msg := "unknown error"
+		if resp.Msg != nil {
+			msg = *resp.Msg
+		}
+		return nil, fmt.Errorf("operation failed (code %d): %s", *resp.Code, msg)
+	}
+	return resp.Data, nil
+}
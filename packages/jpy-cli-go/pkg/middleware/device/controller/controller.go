@@ -25,6 +25,22 @@ func NewDeviceController(cfg *config.Config) *DeviceController {
 
 // ExecuteBatch executes a function on a list of devices using shared server connections.
 func (c *DeviceController) ExecuteBatch(devices []model.DeviceInfo, action func(seat int, api *api.DeviceAPI) error) error {
+	return c.executeSeatBatch(devices, func(deviceAPI *api.DeviceAPI, seats []int) []model.BatchOperationResult {
+		results := make([]model.BatchOperationResult, 0, len(seats))
+		for _, seat := range seats {
+			r := model.BatchOperationResult{Seat: seat, Success: true}
+			if err := action(seat, deviceAPI); err != nil {
+				r = model.BatchOperationResult{Seat: seat, Success: false, Error: err.Error()}
+			}
+			results = append(results, r)
+		}
+		return results
+	})
+}
+
+// executeSeatBatch groups devices by server, connects to the guard of each
+// server once and reports the per-seat results of action.
+func (c *DeviceController) executeSeatBatch(devices []model.DeviceInfo, action func(api *api.DeviceAPI, seats []int) []model.BatchOperationResult) error {
 	// Group devices by server
 	devicesByServer := make(map[string][]model.DeviceInfo)
 	for _, d := range devices {
@@ -68,27 +84,32 @@ func (c *DeviceController) ExecuteBatch(devices []model.DeviceInfo, action func(
 
 		deviceAPI := api.NewDeviceAPI(ws, server.URL, server.Token)
 
-		for _, d := range serverDevices {
+		seats := make([]int, len(serverDevices))
+		bySeat := make(map[int]model.DeviceInfo, len(serverDevices))
+		for i, d := range serverDevices {
+			seats[i] = d.Seat
+			bySeat[d.Seat] = d
+		}
+
+		for _, r := range action(deviceAPI, seats) {
 			processedCount++
-			err := action(d.Seat, deviceAPI)
+			d := bySeat[r.Seat]
 
-			// Console Output (Top 10 detailed, others summary)
 			if processedCount <= 10 {
-				if err != nil {
-					fmt.Printf("[%d/%d] ❌ %s (机位 %d): %v\n", processedCount, totalDevices, d.UUID, d.Seat, err)
+				if !r.Success {
+					fmt.Printf("[%d/%d] ❌ %s (机位 %d): %v\n", processedCount, totalDevices, d.UUID, r.Seat, r.Error)
 				} else {
-					fmt.Printf("[%d/%d] ✅ %s (机位 %d): 成功\n", processedCount, totalDevices, d.UUID, d.Seat)
+					fmt.Printf("[%d/%d] ✅ %s (机位 %d): 成功\n", processedCount, totalDevices, d.UUID, r.Seat)
 				}
 			} else {
-				// Update progress line
 				fmt.Printf("\r正在处理... %d/%d (成功: %d, 失败: %d)", processedCount, totalDevices, successCount, failCount)
 			}
 
-			if err != nil {
-				logger.Errorf("控制设备失败 %s (机位 %d): %v", d.UUID, d.Seat, err)
+			if !r.Success {
+				logger.Errorf("控制设备失败 %s (机位 %d): %v", d.UUID, r.Seat, r.Error)
 				failCount++
 			} else {
-				logger.Infof("成功控制设备 %s (机位 %d)", d.UUID, d.Seat)
+				logger.Infof("成功控制设备 %s (机位 %d)", d.UUID, r.Seat)
 				successCount++
 			}
 		}
@@ -118,34 +139,63 @@ func (c *DeviceController) findServerConfig(url string) (config.LocalServerConfi
 
 // RebootBatch executes the reboot command on multiple devices.
 func (c *DeviceController) RebootBatch(devices []model.DeviceInfo) error {
-	return c.ExecuteBatch(devices, func(seat int, api *api.DeviceAPI) error {
-		return api.RebootDevice(seat)
+	return c.executeSeatBatch(devices, func(api *api.DeviceAPI, seats []int) []model.BatchOperationResult {
+		return api.BatchRebootDevice(seats)
 	})
 }
 
 // SwitchUSBBatch executes the USB switch command on multiple devices.
 func (c *DeviceController) SwitchUSBBatch(devices []model.DeviceInfo, otg bool) error {
-	return c.ExecuteBatch(devices, func(seat int, api *api.DeviceAPI) error {
-		return api.SwitchUSBMode(seat, otg)
+	return c.executeSeatBatch(devices, func(api *api.DeviceAPI, seats []int) []model.BatchOperationResult {
+		return api.BatchSwitchUSBMode(seats, otg)
 	})
 }
 
 // ControlADBBatch executes the ADB control command on multiple devices.
 func (c *DeviceController) ControlADBBatch(devices []model.DeviceInfo, enable bool) error {
+	devices, skipped := splitAndroidOnly(devices, "ADB")
+	if len(devices) == 0 {
+		fmt.Printf("所选 %d 台设备均为 iOS，不支持 ADB，已全部跳过\n", skipped)
+		return nil
+	}
+
+	var err error
 	if enable {
-		return c.ExecuteBatch(devices, func(seat int, api *api.DeviceAPI) error {
-			return api.ControlADB(seat, enable)
+		err = c.executeSeatBatch(devices, func(api *api.DeviceAPI, seats []int) []model.BatchOperationResult {
+			return api.BatchEnableADB(seats, enable)
+		})
+	} else {
+		// For disabling ADB, we must use terminal connection
+		err = c.executeTerminalBatch(devices, func(seat int, term *terminal.TerminalSession) error {
+			// Send shell command to disable ADB
+			if err := term.Exec("settings put global adb_enabled 0"); err != nil {
+				return fmt.Errorf("发送关闭指令失败: %v", err)
+			}
+			return nil
 		})
 	}
 
-	// For disabling ADB, we must use terminal connection
-	return c.executeTerminalBatch(devices, func(seat int, term *terminal.TerminalSession) error {
-		// Send shell command to disable ADB
-		if err := term.Exec("settings put global adb_enabled 0"); err != nil {
-			return fmt.Errorf("发送关闭指令失败: %v", err)
+	if skipped > 0 {
+		fmt.Printf("已跳过 %d 台不支持 ADB 的 iOS 设备\n", skipped)
+	}
+	return err
+}
+
+// splitAndroidOnly drops devices that cannot run an Android-only operation,
+// reporting each one, and returns the remaining devices and the skipped count.
+func splitAndroidOnly(devices []model.DeviceInfo, op string) ([]model.DeviceInfo, int) {
+	var supported []model.DeviceInfo
+	skipped := 0
+	for _, d := range devices {
+		if d.Platform.IsAndroid() {
+			supported = append(supported, d)
+			continue
 		}
-		return nil
-	})
+		skipped++
+		fmt.Printf("⏭️  %s (机位 %d): %s 设备不支持 %s，已跳过\n", d.UUID, d.Seat, d.Platform, op)
+		logger.Warnf("跳过 %s 设备 %s (机位 %d): 不支持 %s", d.Platform, d.UUID, d.Seat, op)
+	}
+	return supported, skipped
 }
 
 // executeTerminalBatch executes a function using terminal connection for each device
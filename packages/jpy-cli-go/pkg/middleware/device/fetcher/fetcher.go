@@ -105,47 +105,56 @@ func ProcessResults(rawResults []interface{}) ([]model.DeviceInfo, int) {
 			continue
 		}
 
-		statusMap := make(map[int]model.OnlineStatus)
-		for _, s := range res.Statuses {
-			statusMap[s.Seat] = s
+		allDevices = append(allDevices, BuildDevices(res)...)
+	}
+	return allDevices, errorCount
+}
+
+// BuildDevices merges the device list and online statuses of one server
+func BuildDevices(res ServerResult) []model.DeviceInfo {
+	statusMap := make(map[int]model.OnlineStatus)
+	for _, s := range res.Statuses {
+		statusMap[s.Seat] = s
+	}
+
+	devices := make([]model.DeviceInfo, 0, len(res.Devices))
+	for _, d := range res.Devices {
+		androidVer := ""
+		if d.AndroidVersion != nil {
+			androidVer = *d.AndroidVersion
 		}
 
-		for _, d := range res.Devices {
-			androidVer := ""
-			if d.AndroidVersion != nil {
-				androidVer = *d.AndroidVersion
-			}
+		info := model.DeviceInfo{
+			ServerURL:   res.ServerURL,
+			Seat:        d.Seat,
+			UUID:        d.UUID,
+			Model:       d.Model,
+			Platform:    model.DetectPlatform(d.Type, d.OSVersion, androidVer, d.Model),
+			OSVersion:   d.OSVersion,
+			Android:     androidVer,
+			IsOnline:    false,
+			ServerIndex: res.OrderIndex,
+		}
 
-			info := model.DeviceInfo{
-				ServerURL:   res.ServerURL,
-				Seat:        d.Seat,
-				UUID:        d.UUID,
-				Model:       d.Model,
-				Android:     androidVer,
-				IsOnline:    false,
-				ServerIndex: res.OrderIndex,
+		if s, ok := statusMap[d.Seat]; ok {
+			s.Parse()
+			if s.IsBusinessOnline && s.IsManagementOnline {
+				info.IsOnline = true
 			}
-
-			if s, ok := statusMap[d.Seat]; ok {
-				s.Parse()
-				if s.IsBusinessOnline && s.IsManagementOnline {
-					info.IsOnline = true
-				}
-				if s.IP != "" {
-					info.IP = s.IP
-				}
-				if s.IsManagementOnline {
-					info.BizOnline = true
-				}
-				if s.IsADBEnabled {
-					info.ADBEnabled = true
-				}
-				if s.IsUSBMode {
-					info.USBMode = true
-				}
+			if s.IP != "" {
+				info.IP = s.IP
+			}
+			if s.IsManagementOnline {
+				info.BizOnline = true
+			}
+			if s.IsADBEnabled {
+				info.ADBEnabled = true
+			}
+			if s.IsUSBMode {
+				info.USBMode = true
 			}
-			allDevices = append(allDevices, info)
 		}
+		devices = append(devices, info)
 	}
-	return allDevices, errorCount
+	return devices
 }
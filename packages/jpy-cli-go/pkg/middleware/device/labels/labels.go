@@ -0,0 +1,191 @@
+package labels
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"jpy-cli/pkg/config"
+	"jpy-cli/pkg/middleware/model"
+)
+
+var keyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.\-/]*$`)
+
+// DeviceKey is the key labels and pinned sets are stored under: the UUID,
+// or server+seat for devices that have not reported one.
+func DeviceKey(d model.DeviceInfo) string {
+	if d.UUID != "" {
+		return d.UUID
+	}
+	return SeatKey(d)
+}
+
+// SeatKey identifies a device by its server and seat.
+func SeatKey(d model.DeviceInfo) string {
+	return fmt.Sprintf("%s#%d", d.ServerURL, d.Seat)
+}
+
+// Keys returns every key a device may be stored under, most specific first.
+func Keys(d model.DeviceInfo) []string {
+	if d.UUID != "" {
+		return []string{d.UUID, SeatKey(d)}
+	}
+	return []string{SeatKey(d)}
+}
+
+// Lookup returns the effective labels of a device: server labels overridden by
+// server+seat labels, overridden by UUID labels.
+func Lookup(cfg *config.Config, d model.DeviceInfo) map[string]string {
+	res := make(map[string]string)
+	for k, v := range cfg.ServerLabels[d.ServerURL] {
+		res[k] = v
+	}
+	keys := Keys(d)
+	for i := len(keys) - 1; i >= 0; i-- {
+		for k, v := range cfg.DeviceLabels[keys[i]] {
+			res[k] = v
+		}
+	}
+	return res
+}
+
+// ParseAssignments parses "key=value" arguments.
+func ParseAssignments(args []string) (map[string]string, error) {
+	res := make(map[string]string)
+	for _, arg := range args {
+		k, v, ok := strings.Cut(arg, "=")
+		k = strings.TrimSpace(k)
+		if !ok || v == "" {
+			return nil, fmt.Errorf("无效标签 '%s' (格式: key=value)", arg)
+		}
+		if !keyPattern.MatchString(k) {
+			return nil, fmt.Errorf("无效标签名 '%s' (仅支持字母、数字及 _ . - /)", k)
+		}
+		res[k] = strings.TrimSpace(v)
+	}
+	return res, nil
+}
+
+// SetDevice adds or overwrites labels on a device.
+func SetDevice(cfg *config.Config, d model.DeviceInfo, kv map[string]string) {
+	if cfg.DeviceLabels == nil {
+		cfg.DeviceLabels = make(map[string]map[string]string)
+	}
+	set(cfg.DeviceLabels, DeviceKey(d), kv)
+}
+
+// RemoveDevice removes label keys from a device under all of its keys.
+func RemoveDevice(cfg *config.Config, d model.DeviceInfo, keys []string) int {
+	removed := 0
+	for _, k := range Keys(d) {
+		removed += remove(cfg.DeviceLabels, k, keys)
+	}
+	return removed
+}
+
+// SetServer adds or overwrites labels on a server.
+func SetServer(cfg *config.Config, url string, kv map[string]string) {
+	if cfg.ServerLabels == nil {
+		cfg.ServerLabels = make(map[string]map[string]string)
+	}
+	set(cfg.ServerLabels, url, kv)
+}
+
+// RemoveServer removes label keys from a server.
+func RemoveServer(cfg *config.Config, url string, keys []string) int {
+	return remove(cfg.ServerLabels, url, keys)
+}
+
+func set(store map[string]map[string]string, key string, kv map[string]string) {
+	labels := store[key]
+	if labels == nil {
+		labels = make(map[string]string)
+		store[key] = labels
+	}
+	for k, v := range kv {
+		labels[k] = v
+	}
+}
+
+func remove(store map[string]map[string]string, key string, keys []string) int {
+	labels := store[key]
+	removed := 0
+	for _, k := range keys {
+		if _, ok := labels[k]; ok {
+			delete(labels, k)
+			removed++
+		}
+	}
+	if labels != nil && len(labels) == 0 {
+		delete(store, key)
+	}
+	return removed
+}
+
+// Format renders labels as sorted "k=v" pairs.
+func Format(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Selector is one --label predicate.
+type Selector struct {
+	Key    string
+	Value  string
+	Negate bool // key!=value, or !key when Value is empty
+}
+
+// ParseSelectors parses --label values: "k=v", "k!=v", "k" (present) and "!k" (absent).
+// A value may list alternatives separated by "|", e.g. "project=a|b".
+func ParseSelectors(args []string) ([]Selector, error) {
+	var res []Selector
+	for _, arg := range args {
+		arg = strings.TrimSpace(arg)
+		var s Selector
+		switch {
+		case strings.Contains(arg, "!="):
+			s.Key, s.Value, _ = strings.Cut(arg, "!=")
+			s.Negate = true
+		case strings.Contains(arg, "="):
+			s.Key, s.Value, _ = strings.Cut(arg, "=")
+		case strings.HasPrefix(arg, "!"):
+			s.Key, s.Negate = arg[1:], true
+		default:
+			s.Key = arg
+		}
+		s.Key = strings.TrimSpace(s.Key)
+		s.Value = strings.TrimSpace(s.Value)
+		if !keyPattern.MatchString(s.Key) {
+			return nil, fmt.Errorf("无效标签筛选 '%s' (格式: key=value、key!=value、key 或 !key)", arg)
+		}
+		res = append(res, s)
+	}
+	return res, nil
+}
+
+// Match reports whether labels satisfy every selector.
+func Match(labels map[string]string, selectors []Selector) bool {
+	for _, s := range selectors {
+		v, ok := labels[s.Key]
+		var hit bool
+		if s.Value == "" {
+			hit = ok
+		} else {
+			for _, want := range strings.Split(s.Value, "|") {
+				if ok && v == want {
+					hit = true
+					break
+				}
+			}
+		}
+		if hit == s.Negate {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+package labels
+
+import (
+	"testing"
+
+	"jpy-cli/pkg/config"
+	"jpy-cli/pkg/middleware/model"
+)
+
+func TestLookupPrecedence(t *testing.T) {
+	cfg := &config.Config{}
+	withUUID := model.DeviceInfo{ServerURL: "http://a", Seat: 3, UUID: "U1"}
+	noUUID := model.DeviceInfo{ServerURL: "http://a", Seat: 4}
+
+	SetServer(cfg, "http://a", map[string]string{"project": "alpha", "rack": "r1"})
+	SetDevice(cfg, withUUID, map[string]string{"project": "beta"})
+	SetDevice(cfg, noUUID, map[string]string{"state": "stuck"})
+
+	got := Lookup(cfg, withUUID)
+	if got["project"] != "beta" || got["rack"] != "r1" {
+		t.Errorf("withUUID labels = %v", got)
+	}
+	if _, ok := cfg.DeviceLabels["http://a#4"]; !ok {
+		t.Errorf("device without UUID should be keyed by server#seat: %v", cfg.DeviceLabels)
+	}
+	if Format(Lookup(cfg, noUUID)) != "project=alpha,rack=r1,state=stuck" {
+		t.Errorf("noUUID labels = %v", Format(Lookup(cfg, noUUID)))
+	}
+
+	if n := RemoveDevice(cfg, withUUID, []string{"project"}); n != 1 {
+		t.Errorf("removed = %d", n)
+	}
+	if _, ok := cfg.DeviceLabels["U1"]; ok {
+		t.Error("empty label map should be deleted")
+	}
+	if Lookup(cfg, withUUID)["project"] != "alpha" {
+		t.Error("server label should show through after removal")
+	}
+}
+
+func TestSelectors(t *testing.T) {
+	labels := map[string]string{"project": "alpha", "state": "stuck"}
+	cases := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"project=alpha"}, true},
+		{[]string{"project=beta|alpha"}, true},
+		{[]string{"project!=alpha"}, false},
+		{[]string{"state"}, true},
+		{[]string{"!state"}, false},
+		{[]string{"!owner", "project=alpha"}, true},
+		{[]string{"owner=x"}, false},
+		{[]string{"owner!=x"}, true},
+	}
+	for _, c := range cases {
+		sels, err := ParseSelectors(c.args)
+		if err != nil {
+			t.Fatalf("ParseSelectors(%v): %v", c.args, err)
+		}
+		if got := Match(labels, sels); got != c.want {
+			t.Errorf("Match(%v) = %v, want %v", c.args, got, c.want)
+		}
+	}
+	if _, err := ParseSelectors([]string{"=x"}); err == nil {
+		t.Error("expected error for empty key")
+	}
+}
+
+func TestParseAssignments(t *testing.T) {
+	kv, err := ParseAssignments([]string{"project=alpha", "team/owner=ops"})
+	if err != nil || kv["project"] != "alpha" || kv["team/owner"] != "ops" {
+		t.Fatalf("ParseAssignments = %v, %v", kv, err)
+	}
+	for _, bad := range []string{"project", "project=", "bad key=x"} {
+		if _, err := ParseAssignments([]string{bad}); err == nil {
+			t.Errorf("ParseAssignments(%q): expected error", bad)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package mirror
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/rand"
+)
+
+// Audio codecs
+const (
+	CodecUnknown = ""
+	CodecPCM     = "pcm" // 16-bit little-endian PCM
+	CodecAAC     = "aac" // ADTS framed AAC
+	CodecOpus    = "opus"
+)
+
+// DetectAudioCodec guesses the codec from the first audio frame. ADTS and
+// OpusHead frames are recognised; any other frame holding whole 16-bit
+// samples is taken as PCM, the default stream format. Bare Opus packets
+// cannot be told apart from PCM and must be set explicitly.
+func DetectAudioCodec(frame []byte) string {
+	if len(frame) >= 2 && frame[0] == 0xFF && frame[1]&0xF6 == 0xF0 {
+		return CodecAAC
+	}
+	if len(frame) >= 8 && string(frame[:8]) == "OpusHead" {
+		return CodecOpus
+	}
+	if len(frame) > 0 && len(frame)%2 == 0 {
+		return CodecPCM
+	}
+	return CodecUnknown
+}
+
+// WAVWriter writes 16-bit PCM into a WAV container. The header sizes are
+// patched on Close, so the underlying writer must be seekable.
+type WAVWriter struct {
+	w          io.WriteSeeker
+	sampleRate int
+	channels   int
+	dataSize   uint32
+}
+
+func NewWAVWriter(w io.WriteSeeker, sampleRate, channels int) (*WAVWriter, error) {
+	ww := &WAVWriter{w: w, sampleRate: sampleRate, channels: channels}
+	if err := ww.writeHeader(); err != nil {
+		return nil, err
+	}
+	return ww, nil
+}
+
+func (ww *WAVWriter) Write(p []byte) (int, error) {
+	n, err := ww.w.Write(p)
+	ww.dataSize += uint32(n)
+	return n, err
+}
+
+// Close patches the RIFF and data chunk sizes. It does not close the underlying writer.
+func (ww *WAVWriter) Close() error {
+	if _, err := ww.w.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := ww.writeHeader(); err != nil {
+		return err
+	}
+	_, err := ww.w.Seek(0, io.SeekEnd)
+	return err
+}
+
+func (ww *WAVWriter) writeHeader() error {
+	const bitsPerSample = 16
+	blockAlign := ww.channels * bitsPerSample / 8
+
+	h := make([]byte, 44)
+	copy(h[0:], "RIFF")
+	binary.LittleEndian.PutUint32(h[4:], 36+ww.dataSize)
+	copy(h[8:], "WAVE")
+	copy(h[12:], "fmt ")
+	binary.LittleEndian.PutUint32(h[16:], 16)
+	binary.LittleEndian.PutUint16(h[20:], 1) // PCM
+	binary.LittleEndian.PutUint16(h[22:], uint16(ww.channels))
+	binary.LittleEndian.PutUint32(h[24:], uint32(ww.sampleRate))
+	binary.LittleEndian.PutUint32(h[28:], uint32(ww.sampleRate*blockAlign))
+	binary.LittleEndian.PutUint16(h[32:], uint16(blockAlign))
+	binary.LittleEndian.PutUint16(h[34:], bitsPerSample)
+	copy(h[36:], "data")
+	binary.LittleEndian.PutUint32(h[40:], ww.dataSize)
+
+	_, err := ww.w.Write(h)
+	return err
+}
+
+// OggOpusWriter muxes bare Opus packets into an Ogg Opus stream (RFC 7845).
+// Each Write is one Opus packet.
+type OggOpusWriter struct {
+	w       io.Writer
+	serial  uint32
+	seq     uint32
+	granule uint64
+	pending []byte
+}
+
+func NewOggOpusWriter(w io.Writer, sampleRate, channels int) (*OggOpusWriter, error) {
+	ow := &OggOpusWriter{w: w, serial: rand.Uint32()}
+
+	head := make([]byte, 19)
+	copy(head, "OpusHead")
+	head[8] = 1 // version
+	head[9] = byte(channels)
+	binary.LittleEndian.PutUint16(head[10:], 312) // pre-skip
+	binary.LittleEndian.PutUint32(head[12:], uint32(sampleRate))
+	if err := ow.writePage(head, 0, 0x02); err != nil {
+		return nil, err
+	}
+
+	vendor := "jpy-cli"
+	tags := make([]byte, 8+4+len(vendor)+4)
+	copy(tags, "OpusTags")
+	binary.LittleEndian.PutUint32(tags[8:], uint32(len(vendor)))
+	copy(tags[12:], vendor)
+	if err := ow.writePage(tags, 0, 0); err != nil {
+		return nil, err
+	}
+	return ow, nil
+}
+
+// Write queues one Opus packet. The previous packet is flushed so the last
+// one can carry the end-of-stream flag on Close.
+func (ow *OggOpusWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if len(p) >= 255*255 {
+		return 0, errors.New("opus packet too large")
+	}
+	if err := ow.flush(0); err != nil {
+		return 0, err
+	}
+	ow.pending = append([]byte(nil), p...)
+	return len(p), nil
+}
+
+// Close writes the last packet with the end-of-stream flag. It does not close the underlying writer.
+func (ow *OggOpusWriter) Close() error {
+	return ow.flush(0x04)
+}
+
+func (ow *OggOpusWriter) flush(flags byte) error {
+	if ow.pending == nil {
+		return nil
+	}
+	ow.granule += uint64(opusPacketSamples(ow.pending))
+	err := ow.writePage(ow.pending, ow.granule, flags)
+	ow.pending = nil
+	return err
+}
+
+func (ow *OggOpusWriter) writePage(packet []byte, granule uint64, flags byte) error {
+	segments := len(packet)/255 + 1
+
+	page := make([]byte, 27+segments, 27+segments+len(packet))
+	copy(page, "OggS")
+	page[5] = flags
+	binary.LittleEndian.PutUint64(page[6:], granule)
+	binary.LittleEndian.PutUint32(page[14:], ow.serial)
+	binary.LittleEndian.PutUint32(page[18:], ow.seq)
+	page[26] = byte(segments)
+	for i := 0; i < segments-1; i++ {
+		page[27+i] = 255
+	}
+	page[27+segments-1] = byte(len(packet) % 255)
+	page = append(page, packet...)
+
+	binary.LittleEndian.PutUint32(page[22:], oggCRC(page))
+	ow.seq++
+
+	_, err := ow.w.Write(page)
+	return err
+}
+
+// opusPacketSamples returns the duration of an Opus packet in 48 kHz samples (RFC 6716 3.1).
+func opusPacketSamples(packet []byte) int {
+	toc := packet[0]
+	config := int(toc >> 3)
+
+	var frameSize int
+	switch {
+	case config < 12: // SILK
+		frameSize = []int{480, 960, 1920, 2880}[config%4]
+	case config < 16: // Hybrid
+		frameSize = []int{480, 960}[config%2]
+	default: // CELT
+		frameSize = []int{120, 240, 480, 960}[config%4]
+	}
+
+	frames := 1
+	switch toc & 0x03 {
+	case 1, 2:
+		frames = 2
+	case 3:
+		if len(packet) > 1 {
+			frames = int(packet[1] & 0x3f)
+		}
+	}
+	return frameSize * frames
+}
+
+var oggCRCTable = func() [256]uint32 {
+	var table [256]uint32
+	for i := range table {
+		r := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if r&0x80000000 != 0 {
+				r = r<<1 ^ 0x04c11db7
+			} else {
+				r <<= 1
+			}
+		}
+		table[i] = r
+	}
+	return table
+}()
+
+func oggCRC(data []byte) uint32 {
+	var crc uint32
+	for _, b := range data {
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^b]
+	}
+	return crc
+}
@@ -0,0 +1,89 @@
+package mirror
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWAVWriter_PatchesSizes(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "out.wav"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	w, err := NewWAVWriter(f, 48000, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(make([]byte, 100))
+	w.Write(make([]byte, 28))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 44+128 {
+		t.Fatalf("Expected %d bytes, got %d", 44+128, len(data))
+	}
+	if got := binary.LittleEndian.Uint32(data[4:]); got != 36+128 {
+		t.Errorf("Expected RIFF size %d, got %d", 36+128, got)
+	}
+	if got := binary.LittleEndian.Uint32(data[40:]); got != 128 {
+		t.Errorf("Expected data size 128, got %d", got)
+	}
+}
+
+func TestOggOpusWriter_Pages(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewOggOpusWriter(&buf, 48000, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// TOC 0xf8: CELT 20ms, one frame
+	w.Write([]byte{0xf8, 1, 2, 3})
+	w.Write([]byte{0xf8, 4, 5, 6})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	pages := bytes.Split(buf.Bytes(), []byte("OggS"))[1:]
+	if len(pages) != 4 {
+		t.Fatalf("Expected 4 pages (head, tags, 2 packets), got %d", len(pages))
+	}
+
+	last := append([]byte("OggS"), pages[3]...)
+	if last[5] != 0x04 {
+		t.Errorf("Expected EOS flag on last page, got %x", last[5])
+	}
+	if got := binary.LittleEndian.Uint64(last[6:]); got != 1920 {
+		t.Errorf("Expected granule 1920, got %d", got)
+	}
+
+	crc := binary.LittleEndian.Uint32(last[22:])
+	binary.LittleEndian.PutUint32(last[22:], 0)
+	if oggCRC(last) != crc {
+		t.Errorf("Page CRC mismatch")
+	}
+}
+
+func TestDetectAudioCodec(t *testing.T) {
+	if got := DetectAudioCodec([]byte{0xFF, 0xF1, 0x50, 0x80}); got != CodecAAC {
+		t.Errorf("Expected aac, got %q", got)
+	}
+	if got := DetectAudioCodec([]byte("OpusHead\x01\x02")); got != CodecOpus {
+		t.Errorf("Expected opus, got %q", got)
+	}
+	if got := DetectAudioCodec([]byte{0x01, 0x02, 0x03, 0x04}); got != CodecPCM {
+		t.Errorf("Expected pcm, got %q", got)
+	}
+	if got := DetectAudioCodec([]byte{0x01, 0x02, 0x03}); got != CodecUnknown {
+		t.Errorf("Expected unknown, got %q", got)
+	}
+}
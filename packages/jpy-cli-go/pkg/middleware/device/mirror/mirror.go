@@ -0,0 +1,186 @@
+package mirror
+
+import (
+	"bytes"
+	"fmt"
+	wsclient "jpy-cli/pkg/client/ws"
+	"jpy-cli/pkg/logger"
+	"jpy-cli/pkg/middleware/model"
+	"jpy-cli/pkg/middleware/protocol"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// VideoOptions configures the screen stream (f=251).
+type VideoOptions struct {
+	FPS     int // Frame rate
+	Bitrate int // Bits per second
+	Quality int
+	Width   int // Output width, height follows the screen ratio
+}
+
+// DefaultVideoOptions matches the defaults of the TS SDK.
+func DefaultVideoOptions() VideoOptions {
+	return VideoOptions{FPS: 30, Bitrate: 400000, Quality: 10, Width: 540}
+}
+
+// AudioOptions configures the audio stream (f=253).
+type AudioOptions struct {
+	SampleRate int
+	Bitrate    int
+}
+
+// DefaultAudioOptions matches the defaults of the TS SDK.
+func DefaultAudioOptions() AudioOptions {
+	return AudioOptions{SampleRate: 48000, Bitrate: 128000}
+}
+
+// MirrorSession wraps a /box/mirror connection for a single seat.
+type MirrorSession struct {
+	Client *wsclient.Client
+	Seat   int
+	Video  chan []byte
+	Audio  chan []byte
+	Closed chan struct{}
+}
+
+func NewMirrorSession(client *wsclient.Client, seat int) *MirrorSession {
+	m := &MirrorSession{
+		Client: client,
+		Seat:   seat,
+		Video:  make(chan []byte, 256),
+		Audio:  make(chan []byte, 256),
+		Closed: make(chan struct{}),
+	}
+
+	client.OnMessage = m.handleMessage
+	client.OnStream = m.handleStream
+	go func() {
+		<-client.Done()
+		m.Close()
+	}()
+	return m
+}
+
+// StartVideo asks the device to push its screen as H.264 frames.
+func (m *MirrorSession) StartVideo(opts VideoOptions) error {
+	return m.sendCommand(model.FuncVideoStreamStart, map[string]interface{}{
+		"fps":     opts.FPS,
+		"bit":     opts.Bitrate,
+		"quality": opts.Quality,
+		"width":   opts.Width,
+	})
+}
+
+// StopVideo stops the screen stream.
+func (m *MirrorSession) StopVideo() error {
+	return m.sendCommand(model.FuncVideoStreamStop, nil)
+}
+
+// StartAudio asks the device to push its audio output.
+func (m *MirrorSession) StartAudio(opts AudioOptions) error {
+	return m.sendCommand(model.FuncAudioStreamStart, map[string]interface{}{
+		"sampleRate":   opts.SampleRate,
+		"audioBitRate": opts.Bitrate,
+	})
+}
+
+// StopAudio stops the audio stream.
+func (m *MirrorSession) StopAudio() error {
+	return m.sendCommand(model.FuncAudioStreamStop, nil)
+}
+
+func (m *MirrorSession) Close() {
+	select {
+	case <-m.Closed:
+		return
+	default:
+		close(m.Closed)
+		m.Client.Close()
+	}
+}
+
+func (m *MirrorSession) sendCommand(f int, data interface{}) error {
+	resp, err := m.Client.SendRequestTo(f, data, []uint64{uint64(m.Seat)})
+	if err != nil {
+		return err
+	}
+	if resp.Code != nil && *resp.Code != 0 {
+		msg := "unknown error"
+		if resp.Msg != nil {
+			msg = *resp.Msg
+		}
+		return fmt.Errorf("operation failed (code %d): %s", *resp.Code, msg)
+	}
+	return nil
+}
+
+// handleStream forwards the H.264 frames of the screen stream.
+func (m *MirrorSession) handleStream(frame *protocol.StreamFrame) {
+	if frame.Type != protocol.TypeVideo || len(frame.Data) == 0 {
+		return
+	}
+	select {
+	case m.Video <- frame.Data:
+	default:
+		logger.Log.Debug("视频帧缓冲已满，丢弃帧", "seat", m.Seat)
+	}
+}
+
+func (m *MirrorSession) handleMessage(msgType int, data []byte) {
+	// The server drops the connection when pings go unanswered
+	if msgType == protocol.TypePing {
+		m.pong()
+		return
+	}
+	if len(data) == 0 {
+		return
+	}
+
+	switch msgType {
+	case protocol.TypeMsgpack:
+		// Audio frames are pushed as f=253 messages with a binary payload
+		frame := decodeAudioPush(data)
+		if frame == nil {
+			return
+		}
+		select {
+		case m.Audio <- frame:
+		default:
+			logger.Log.Debug("音频帧缓冲已满，丢弃帧", "seat", m.Seat)
+		}
+	}
+}
+
+// pong answers a ping, addressed to the seat like the TS SDK does.
+func (m *MirrorSession) pong() {
+	msg, err := protocol.EncodeHeader(protocol.TypePong, []uint64{uint64(m.Seat)})
+	if err != nil {
+		return
+	}
+	if err := m.Client.SendRaw(msg); err != nil {
+		logger.Log.Debug("发送 pong 失败", "seat", m.Seat, "error", err)
+	}
+}
+
+// decodeAudioPush returns the payload of an audio push message, or nil for anything else.
+func decodeAudioPush(body []byte) []byte {
+	var msg struct {
+		F    int         `json:"f"`
+		Req  bool        `json:"req"`
+		Data interface{} `json:"data"`
+	}
+	dec := msgpack.NewDecoder(bytes.NewReader(body))
+	dec.SetCustomStructTag("json")
+	if err := dec.Decode(&msg); err != nil {
+		return nil
+	}
+	if msg.F != model.FuncAudioStreamStart || msg.Req {
+		return nil
+	}
+	frame, ok := msg.Data.([]byte)
+	if !ok || len(frame) == 0 {
+		return nil
+	}
+	return frame
+}
@@ -0,0 +1,75 @@
+package mirror
+
+import (
+	"bytes"
+	wsclient "jpy-cli/pkg/client/ws"
+	"jpy-cli/pkg/middleware/protocol"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestMirrorSession_PongAndVideo(t *testing.T) {
+	const seat = 7
+	pongs := make(chan []byte, 1)
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		// Wait until the session is set up before pushing
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+		conn.WriteMessage(websocket.BinaryMessage, []byte{protocol.TypePing})
+		video := append([]byte{protocol.TypeVideo, 8, seat, 0, 0, 0, 0, 0, 0, 0}, 0, 0, 0, 1, 0x65)
+		conn.WriteMessage(websocket.BinaryMessage, video)
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if len(data) > 0 && data[0] == protocol.TypePong {
+				pongs <- data
+			}
+		}
+	}))
+	defer srv.Close()
+
+	client := wsclient.NewClient(srv.URL, "")
+	client.Endpoint = "/box/mirror"
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	session := NewMirrorSession(client, seat)
+	defer session.Close()
+	if err := client.SendRaw([]byte{protocol.TypePong}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case pong := <-pongs:
+		want := []byte{protocol.TypePong, 8, seat, 0, 0, 0, 0, 0, 0, 0}
+		if !bytes.Equal(pong, want) {
+			t.Errorf("Expected pong %x, got %x", want, pong)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("No pong for ping")
+	}
+
+	select {
+	case frame := <-session.Video:
+		if !bytes.Equal(frame, []byte{0, 0, 0, 1, 0x65}) {
+			t.Errorf("Unexpected video frame %x", frame)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("No video frame")
+	}
+}
@@ -0,0 +1,131 @@
+package mirror
+
+import (
+	"bytes"
+	"sync"
+)
+
+// H.264 NAL unit types we care about
+const (
+	nalIDR = 5
+	nalSPS = 7
+	nalPPS = 8
+)
+
+// Relay fans out an Annex-B H.264 stream to any number of subscribers.
+// Late subscribers first receive the cached SPS/PPS and last key frame so
+// decoders can start without waiting for the next IDR.
+type Relay struct {
+	mu       sync.Mutex
+	subs     map[chan []byte]struct{}
+	sps      []byte
+	pps      []byte
+	keyFrame []byte
+}
+
+func NewRelay() *Relay {
+	return &Relay{subs: make(map[chan []byte]struct{})}
+}
+
+// Publish sends a frame to all subscribers. Slow subscribers drop frames.
+func (r *Relay) Publish(frame []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cacheParams(frame)
+
+	for ch := range r.subs {
+		select {
+		case ch <- frame:
+		default:
+		}
+	}
+}
+
+// Subscribe registers a new subscriber. Call Unsubscribe when done.
+func (r *Relay) Subscribe() chan []byte {
+	ch := make(chan []byte, 256)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, cached := range [][]byte{r.sps, r.pps, r.keyFrame} {
+		if len(cached) > 0 {
+			ch <- cached
+		}
+	}
+	r.subs[ch] = struct{}{}
+	return ch
+}
+
+func (r *Relay) Unsubscribe(ch chan []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.subs[ch]; ok {
+		delete(r.subs, ch)
+		close(ch)
+	}
+}
+
+// Close disconnects all subscribers.
+func (r *Relay) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for ch := range r.subs {
+		delete(r.subs, ch)
+		close(ch)
+	}
+}
+
+func (r *Relay) cacheParams(frame []byte) {
+	for _, nal := range SplitNALUnits(frame) {
+		switch nalType(nal) {
+		case nalSPS:
+			r.sps = nal
+		case nalPPS:
+			r.pps = nal
+		case nalIDR:
+			r.keyFrame = frame
+		}
+	}
+}
+
+// SplitNALUnits splits an Annex-B buffer into NAL units, keeping their start codes.
+// A buffer without start codes is returned as a single unit.
+func SplitNALUnits(data []byte) [][]byte {
+	var starts []int
+	for i := 0; i+3 <= len(data); i++ {
+		if data[i] == 0 && data[i+1] == 0 && data[i+2] == 1 {
+			start := i
+			if i > 0 && data[i-1] == 0 {
+				start = i - 1
+			}
+			starts = append(starts, start)
+			i += 2
+		}
+	}
+	if len(starts) == 0 {
+		return [][]byte{data}
+	}
+
+	units := make([][]byte, 0, len(starts))
+	for i, start := range starts {
+		end := len(data)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		units = append(units, data[start:end])
+	}
+	return units
+}
+
+// nalType returns the type of a NAL unit that still has its start code.
+func nalType(nal []byte) int {
+	i := bytes.Index(nal, []byte{0, 0, 1})
+	if i < 0 || i+3 >= len(nal) {
+		return -1
+	}
+	return int(nal[i+3] & 0x1f)
+}
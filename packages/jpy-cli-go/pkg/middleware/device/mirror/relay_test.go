@@ -0,0 +1,45 @@
+package mirror
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSplitNALUnits(t *testing.T) {
+	sps := []byte{0, 0, 0, 1, 0x67, 0x42}
+	pps := []byte{0, 0, 0, 1, 0x68, 0xce}
+	idr := []byte{0, 0, 1, 0x65, 0x88, 0x84}
+	frame := append(append(append([]byte{}, sps...), pps...), idr...)
+
+	units := SplitNALUnits(frame)
+	if len(units) != 3 {
+		t.Fatalf("Expected 3 units, got %d", len(units))
+	}
+	for i, want := range [][]byte{sps, pps, idr} {
+		if !bytes.Equal(units[i], want) {
+			t.Errorf("Unit %d: expected %x, got %x", i, want, units[i])
+		}
+	}
+	if nalType(units[0]) != nalSPS || nalType(units[1]) != nalPPS || nalType(units[2]) != nalIDR {
+		t.Errorf("Unexpected NAL types")
+	}
+}
+
+func TestRelay_LateSubscriberGetsParams(t *testing.T) {
+	r := NewRelay()
+	key := []byte{0, 0, 0, 1, 0x67, 0x42, 0, 0, 0, 1, 0x68, 0xce, 0, 0, 0, 1, 0x65, 0x88}
+	r.Publish(key)
+	r.Publish([]byte{0, 0, 0, 1, 0x41, 0x9a}) // P frame
+
+	ch := r.Subscribe()
+	defer r.Unsubscribe(ch)
+
+	if got := len(ch); got != 3 {
+		t.Fatalf("Expected 3 cached frames (SPS, PPS, key frame), got %d", got)
+	}
+	<-ch
+	<-ch
+	if got := <-ch; !bytes.Equal(got, key) {
+		t.Errorf("Expected key frame %x, got %x", key, got)
+	}
+}
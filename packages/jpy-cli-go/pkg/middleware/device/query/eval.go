@@ -0,0 +1,166 @@
+package query
+
+import (
+	"regexp"
+	"time"
+)
+
+// timeNow is replaced in tests.
+var timeNow = time.Now
+
+type node interface {
+	kind() kind
+	position() int
+	eval(r Record) value
+}
+
+type literalNode struct {
+	val value
+	pos int
+}
+
+func (n *literalNode) kind() kind        { return n.val.kind }
+func (n *literalNode) position() int     { return n.pos }
+func (n *literalNode) eval(Record) value { return n.val }
+
+type fieldNode struct {
+	name  string
+	field field
+	pos   int
+}
+
+func (n *fieldNode) kind() kind          { return n.field.kind }
+func (n *fieldNode) position() int       { return n.pos }
+func (n *fieldNode) eval(r Record) value { return n.field.get(r) }
+
+type notNode struct {
+	operand node
+	pos     int
+}
+
+func (n *notNode) kind() kind          { return kindBool }
+func (n *notNode) position() int       { return n.pos }
+func (n *notNode) eval(r Record) value { return boolVal(!n.operand.eval(r).b) }
+
+type logicNode struct {
+	or          bool
+	left, right node
+	pos         int
+}
+
+func (n *logicNode) kind() kind    { return kindBool }
+func (n *logicNode) position() int { return n.left.position() }
+func (n *logicNode) eval(r Record) value {
+	l := n.left.eval(r).b
+	if n.or {
+		return boolVal(l || n.right.eval(r).b)
+	}
+	return boolVal(l && n.right.eval(r).b)
+}
+
+type compareNode struct {
+	op          string
+	left, right node
+	pos         int
+}
+
+func (n *compareNode) kind() kind    { return kindBool }
+func (n *compareNode) position() int { return n.left.position() }
+func (n *compareNode) eval(r Record) value {
+	l, rv := n.left.eval(r), n.right.eval(r)
+	if l.null || rv.null {
+		return boolVal(false)
+	}
+	c := compare(l, rv)
+	switch n.op {
+	case "==":
+		return boolVal(c == 0)
+	case "!=":
+		return boolVal(c != 0)
+	case "<":
+		return boolVal(c < 0)
+	case "<=":
+		return boolVal(c <= 0)
+	case ">":
+		return boolVal(c > 0)
+	}
+	return boolVal(c >= 0)
+}
+
+type matchNode struct {
+	negate bool
+	left   node
+	re     *regexp.Regexp
+	pos    int
+}
+
+func (n *matchNode) kind() kind    { return kindBool }
+func (n *matchNode) position() int { return n.left.position() }
+func (n *matchNode) eval(r Record) value {
+	return boolVal(n.re.MatchString(n.left.eval(r).s) != n.negate)
+}
+
+type betweenNode struct {
+	value, lo, hi node
+	pos           int
+}
+
+func (n *betweenNode) kind() kind    { return kindBool }
+func (n *betweenNode) position() int { return n.value.position() }
+func (n *betweenNode) eval(r Record) value {
+	v, lo, hi := n.value.eval(r), n.lo.eval(r), n.hi.eval(r)
+	if v.null || lo.null || hi.null {
+		return boolVal(false)
+	}
+	return boolVal(compare(v, lo) >= 0 && compare(v, hi) <= 0)
+}
+
+type inNode struct {
+	value node
+	items []node
+	pos   int
+}
+
+func (n *inNode) kind() kind    { return kindBool }
+func (n *inNode) position() int { return n.value.position() }
+func (n *inNode) eval(r Record) value {
+	v := n.value.eval(r)
+	if v.null {
+		return boolVal(false)
+	}
+	for _, item := range n.items {
+		if compare(v, item.eval(r)) == 0 {
+			return boolVal(true)
+		}
+	}
+	return boolVal(false)
+}
+
+// compare orders two values of the same kind (checked at parse time).
+func compare(a, b value) int {
+	switch a.kind {
+	case kindBool:
+		if a.b == b.b {
+			return 0
+		}
+		if !a.b {
+			return -1
+		}
+		return 1
+	case kindNum:
+		switch {
+		case a.n < b.n:
+			return -1
+		case a.n > b.n:
+			return 1
+		}
+		return 0
+	}
+	switch {
+	case a.s < b.s:
+		return -1
+	case a.s > b.s:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,240 @@
+package query
+
+import (
+	"sort"
+	"strings"
+
+	"jpy-cli/pkg/middleware/license"
+	"jpy-cli/pkg/middleware/model"
+)
+
+type kind int
+
+const (
+	kindBool kind = iota
+	kindNum
+	kindStr
+)
+
+func (k kind) String() string {
+	switch k {
+	case kindBool:
+		return "布尔"
+	case kindNum:
+		return "数字"
+	}
+	return "字符串"
+}
+
+type scope int
+
+const (
+	scopeDevice scope = iota
+	scopeServer
+	scopeLicense
+)
+
+// ServerStats are the per-server aggregates an expression can refer to.
+type ServerStats struct {
+	URL       string
+	Devices   int
+	Online    int
+	BizOnline int
+	IP        int
+	UUID      int
+	ADB       int
+	USB       int
+	OTG       int
+}
+
+// Add counts one device into the aggregates.
+func (s *ServerStats) Add(d model.DeviceInfo) {
+	s.Devices++
+	if d.IsOnline {
+		s.Online++
+	}
+	if d.BizOnline {
+		s.BizOnline++
+	}
+	if d.IP != "" {
+		s.IP++
+	}
+	if d.UUID != "" {
+		s.UUID++
+	}
+	if d.ADBEnabled {
+		s.ADB++
+	}
+	if d.USBMode {
+		s.USB++
+	} else {
+		s.OTG++
+	}
+}
+
+// Aggregate groups devices by server and computes their stats.
+func Aggregate(devices []model.DeviceInfo) map[string]*ServerStats {
+	stats := make(map[string]*ServerStats)
+	for _, d := range devices {
+		s, ok := stats[d.ServerURL]
+		if !ok {
+			s = &ServerStats{URL: d.ServerURL}
+			stats[d.ServerURL] = s
+		}
+		s.Add(d)
+	}
+	return stats
+}
+
+// Record is what an expression is evaluated against: one device together with
+// the aggregates and license of its server. Missing parts evaluate to zero values.
+type Record struct {
+	Device  *model.DeviceInfo
+	Server  *ServerStats
+	License *license.CheckResult
+}
+
+// value is a field or literal value. A null value has no data (e.g. a
+// license without expiry) and makes every comparison false.
+type value struct {
+	kind kind
+	null bool
+	b    bool
+	n    float64
+	s    string
+}
+
+func boolVal(b bool) value  { return value{kind: kindBool, b: b} }
+func numVal(n int) value    { return value{kind: kindNum, n: float64(n)} }
+func strVal(s string) value { return value{kind: kindStr, s: s} }
+func nullVal(k kind) value  { return value{kind: k, null: true} }
+func (r Record) dev() model.DeviceInfo {
+	if r.Device == nil {
+		return model.DeviceInfo{}
+	}
+	return *r.Device
+}
+func (r Record) srv() ServerStats {
+	if r.Server == nil {
+		return ServerStats{}
+	}
+	return *r.Server
+}
+func (r Record) lic() *model.LicenseData {
+	if r.License == nil {
+		return nil
+	}
+	return r.License.License
+}
+
+type field struct {
+	kind  kind
+	scope scope
+	get   func(r Record) value
+}
+
+var fields = map[string]field{
+	// Device
+	"server":     {kindStr, scopeDevice, func(r Record) value { return strVal(r.dev().ServerURL) }},
+	"seat":       {kindNum, scopeDevice, func(r Record) value { return numVal(r.dev().Seat) }},
+	"uuid":       {kindStr, scopeDevice, func(r Record) value { return strVal(r.dev().UUID) }},
+	"model":      {kindStr, scopeDevice, func(r Record) value { return strVal(r.dev().Model) }},
+	"platform":   {kindStr, scopeDevice, func(r Record) value { return strVal(string(r.dev().Platform)) }},
+	"os":         {kindStr, scopeDevice, func(r Record) value { return strVal(r.dev().OSLabel()) }},
+	"os_version": {kindStr, scopeDevice, func(r Record) value { return strVal(r.dev().OSVersion) }},
+	"android":    {kindStr, scopeDevice, func(r Record) value { return strVal(r.dev().Android) }},
+	"online":     {kindBool, scopeDevice, func(r Record) value { return boolVal(r.dev().IsOnline) }},
+	"biz_online": {kindBool, scopeDevice, func(r Record) value { return boolVal(r.dev().BizOnline) }},
+	"ip":         {kindStr, scopeDevice, func(r Record) value { return strVal(r.dev().IP) }},
+	"adb":        {kindBool, scopeDevice, func(r Record) value { return boolVal(r.dev().ADBEnabled) }},
+	"usb":        {kindBool, scopeDevice, func(r Record) value { return boolVal(r.Device != nil && r.Device.USBMode) }},
+	"otg":        {kindBool, scopeDevice, func(r Record) value { return boolVal(r.Device != nil && !r.Device.USBMode) }},
+
+	// Server aggregates
+	"devices":          {kindNum, scopeServer, func(r Record) value { return numVal(r.srv().Devices) }},
+	"online_count":     {kindNum, scopeServer, func(r Record) value { return numVal(r.srv().Online) }},
+	"biz_online_count": {kindNum, scopeServer, func(r Record) value { return numVal(r.srv().BizOnline) }},
+	"ip_count":         {kindNum, scopeServer, func(r Record) value { return numVal(r.srv().IP) }},
+	"uuid_count":       {kindNum, scopeServer, func(r Record) value { return numVal(r.srv().UUID) }},
+	"adb_count":        {kindNum, scopeServer, func(r Record) value { return numVal(r.srv().ADB) }},
+	"usb_count":        {kindNum, scopeServer, func(r Record) value { return numVal(r.srv().USB) }},
+	"otg_count":        {kindNum, scopeServer, func(r Record) value { return numVal(r.srv().OTG) }},
+
+	// License of the server
+	"license": {kindStr, scopeLicense, func(r Record) value {
+		if r.License == nil {
+			return strVal("")
+		}
+		return strVal(r.License.Status.Key())
+	}},
+	"sn": {kindStr, scopeLicense, func(r Record) value {
+		if lic := r.lic(); lic != nil {
+			return strVal(lic.Sn)
+		}
+		return strVal("")
+	}},
+	"cluster": {kindStr, scopeLicense, func(r Record) value {
+		if lic := r.lic(); lic != nil {
+			return strVal(lic.C)
+		}
+		return strVal("")
+	}},
+	"days_left": {kindNum, scopeLicense, func(r Record) value {
+		report := license.Evaluate("", r.lic(), timeNow(), 0)
+		if !report.HasExpiry() {
+			return nullVal(kindNum)
+		}
+		return numVal(report.DaysLeft)
+	}},
+}
+
+// FieldNames returns the known field names, sorted.
+func FieldNames() []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// suggest returns the known field closest to name, or "" if none is close.
+func suggest(name string) string {
+	best, bestDist := "", 3
+	for _, candidate := range FieldNames() {
+		if d := editDistance(strings.ToLower(name), candidate); d < bestDist {
+			best, bestDist = candidate, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,155 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokOp // operators and punctuation
+)
+
+type token struct {
+	kind tokenKind
+	text string // operator, identifier or decoded string literal
+	num  float64
+	pos  int // byte offset in the source
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "表达式结尾"
+	case tokString:
+		return strconv.Quote(t.text)
+	}
+	return "'" + t.text + "'"
+}
+
+// Longest operators first so "<=" wins over "<".
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "!~", "<", ">", "!", "~", "(", ")", ","}
+
+func lex(src string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(src) {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+
+		case c == '"' || c == '\'':
+			start := i
+			var sb strings.Builder
+			i++
+			closed := false
+			for i < len(src) {
+				if src[i] == '\\' && i+1 < len(src) {
+					switch src[i+1] {
+					case 'n':
+						sb.WriteByte('\n')
+					case 't':
+						sb.WriteByte('\t')
+					default:
+						sb.WriteByte(src[i+1])
+					}
+					i += 2
+					continue
+				}
+				if rune(src[i]) == c {
+					closed = true
+					i++
+					break
+				}
+				sb.WriteByte(src[i])
+				i++
+			}
+			if !closed {
+				return nil, newError(src, start, "字符串缺少结束引号")
+			}
+			tokens = append(tokens, token{kind: tokString, text: sb.String(), pos: start})
+
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			start := i
+			for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.') {
+				i++
+			}
+			n, err := strconv.ParseFloat(src[start:i], 64)
+			if err != nil {
+				return nil, newError(src, start, fmt.Sprintf("无效数字 '%s'", src[start:i]))
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: src[start:i], num: n, pos: start})
+
+		case c == '_' || unicode.IsLetter(c):
+			start := i
+			for i < len(src) && (src[i] == '_' || src[i] == '.' || unicode.IsLetter(rune(src[i])) || unicode.IsDigit(rune(src[i]))) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: src[start:i], pos: start})
+
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(src[i:], op) {
+					tokens = append(tokens, token{kind: tokOp, text: op, pos: i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				hint := ""
+				switch c {
+				case '&':
+					hint = "，逻辑与请使用 '&&' 或 and"
+				case '|':
+					hint = "，逻辑或请使用 '||' 或 or"
+				case '=':
+					hint = "，相等比较请使用 '=='"
+				}
+				return nil, newError(src, i, fmt.Sprintf("无法识别的字符 '%c'%s", c, hint))
+			}
+		}
+	}
+	tokens = append(tokens, token{kind: tokEOF, pos: len(src)})
+	return tokens, nil
+}
+
+// Error is a syntax or type error with the position it refers to.
+type Error struct {
+	Src string
+	Pos int
+	Msg string
+}
+
+func newError(src string, pos int, msg string) *Error {
+	return &Error{Src: src, Pos: pos, Msg: msg}
+}
+
+// Error renders the message with the expression and a caret under the column.
+func (e *Error) Error() string {
+	col := len([]rune(e.Src[:e.Pos])) + 1
+	caret := strings.Repeat(" ", stringWidth(e.Src[:e.Pos])) + "^"
+	return fmt.Sprintf("表达式错误 (第 %d 列): %s\n  %s\n  %s", col, e.Msg, e.Src, caret)
+}
+
+// stringWidth approximates the display width, counting wide (CJK) runes as two columns.
+func stringWidth(s string) int {
+	w := 0
+	for _, r := range s {
+		if r > 0x1100 && unicode.In(r, unicode.Han, unicode.Hangul, unicode.Hiragana, unicode.Katakana) {
+			w += 2
+		} else {
+			w++
+		}
+	}
+	return w
+}
@@ -0,0 +1,307 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Expr is a parsed and type-checked --where expression.
+//
+// Grammar:
+//
+//	expr    = and { ("||" | "or") and }
+//	and     = unary { ("&&" | "and") unary }
+//	unary   = ("!" | "not") unary | compare
+//	compare = operand [ ("==" | "!=" | "<" | "<=" | ">" | ">=") operand
+//	                  | ("~" | "!~") string
+//	                  | "between" operand "and" operand
+//	                  | "in" "(" operand { "," operand } ")" ]
+//	operand = field | number | string | true | false | "(" expr ")"
+type Expr struct {
+	src         string
+	root        node
+	usesServer  bool
+	usesLicense bool
+}
+
+// Parse parses src. An empty expression returns (nil, nil).
+func Parse(src string) (*Expr, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, nil
+	}
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{src: src, tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.errorf(t, "多余的内容 %s，是否缺少 '&&' 或 '||'?", t)
+	}
+	if root.kind() != kindBool {
+		return nil, p.notCondition(root)
+	}
+	return &Expr{src: src, root: root, usesServer: p.usesServer, usesLicense: p.usesLicense}, nil
+}
+
+// String returns the source expression.
+func (e *Expr) String() string { return e.src }
+
+// UsesServer reports whether the expression refers to server aggregates.
+func (e *Expr) UsesServer() bool { return e.usesServer }
+
+// UsesLicense reports whether the expression refers to license fields, so
+// callers only fetch licenses when needed.
+func (e *Expr) UsesLicense() bool { return e.usesLicense }
+
+// Match evaluates the expression against r. A nil expression matches everything.
+func (e *Expr) Match(r Record) bool {
+	if e == nil {
+		return true
+	}
+	return e.root.eval(r).b
+}
+
+type parser struct {
+	src         string
+	tokens      []token
+	i           int
+	usesServer  bool
+	usesLicense bool
+}
+
+func (p *parser) peek() token { return p.tokens[p.i] }
+
+func (p *parser) next() token {
+	t := p.tokens[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+// isOp reports whether t is one of ops; word operators match case-insensitively.
+func isOp(t token, ops ...string) bool {
+	for _, op := range ops {
+		if t.kind == tokOp && t.text == op || t.kind == tokIdent && strings.EqualFold(t.text, op) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) errorf(t token, format string, args ...interface{}) error {
+	return newError(p.src, t.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) notCondition(n node) error {
+	if f, ok := n.(*fieldNode); ok {
+		hint := f.name + ` != ""`
+		if f.field.kind == kindNum {
+			hint = f.name + " > 0"
+		}
+		return newError(p.src, f.pos, fmt.Sprintf("'%s' 是%s字段，不能直接作为条件 (例如 %s)", f.name, f.field.kind, hint))
+	}
+	return newError(p.src, n.position(), fmt.Sprintf("此处需要条件表达式，得到的是%s", n.kind()))
+}
+
+func (p *parser) requireBool(n node) error {
+	if n.kind() != kindBool {
+		return p.notCondition(n)
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for isOp(p.peek(), "||", "or") {
+		op := p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.requireBool(left); err != nil {
+			return nil, err
+		}
+		if err := p.requireBool(right); err != nil {
+			return nil, err
+		}
+		left = &logicNode{or: true, left: left, right: right, pos: op.pos}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for isOp(p.peek(), "&&", "and") {
+		op := p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.requireBool(left); err != nil {
+			return nil, err
+		}
+		if err := p.requireBool(right); err != nil {
+			return nil, err
+		}
+		left = &logicNode{left: left, right: right, pos: op.pos}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if isOp(p.peek(), "!", "not") {
+		op := p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.requireBool(operand); err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand, pos: op.pos}, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *parser) parseCompare() (node, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	switch {
+	case isOp(t, "==", "!=", "<", "<=", ">", ">="):
+		p.next()
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if left.kind() != right.kind() {
+			return nil, p.errorf(t, "无法用 '%s' 比较%s与%s", t.text, left.kind(), right.kind())
+		}
+		if left.kind() == kindBool && t.text != "==" && t.text != "!=" {
+			return nil, p.errorf(t, "布尔值只支持 '==' 和 '!='")
+		}
+		return &compareNode{op: t.text, left: left, right: right, pos: t.pos}, nil
+
+	case isOp(t, "~", "!~"):
+		p.next()
+		if left.kind() != kindStr {
+			return nil, p.errorf(t, "'%s' 左侧需要字符串，得到的是%s", t.text, left.kind())
+		}
+		pat := p.next()
+		if pat.kind != tokString {
+			return nil, p.errorf(pat, "'%s' 右侧需要带引号的正则表达式，得到的是 %s", t.text, pat)
+		}
+		re, err := regexp.Compile(pat.text)
+		if err != nil {
+			return nil, p.errorf(pat, "无效的正则表达式: %v", err)
+		}
+		return &matchNode{negate: t.text == "!~", left: left, re: re, pos: t.pos}, nil
+
+	case isOp(t, "between"):
+		p.next()
+		lo, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if and := p.next(); !isOp(and, "and", "&&") {
+			return nil, p.errorf(and, "between 需要 'X and Y' 形式，缺少 and")
+		}
+		hi, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range []node{lo, hi} {
+			if n.kind() != left.kind() || left.kind() == kindBool {
+				return nil, p.errorf(t, "between 的边界必须与左侧同为数字或字符串")
+			}
+		}
+		return &betweenNode{value: left, lo: lo, hi: hi, pos: t.pos}, nil
+
+	case isOp(t, "in"):
+		p.next()
+		if open := p.next(); !isOp(open, "(") {
+			return nil, p.errorf(open, "in 之后需要 '('")
+		}
+		in := &inNode{value: left, pos: t.pos}
+		for {
+			item, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			if item.kind() != left.kind() {
+				return nil, newError(p.src, item.position(), fmt.Sprintf("in 列表中的值必须是%s", left.kind()))
+			}
+			in.items = append(in.items, item)
+			sep := p.next()
+			if isOp(sep, ")") {
+				break
+			}
+			if !isOp(sep, ",") {
+				return nil, p.errorf(sep, "in 列表需要 ',' 或 ')'，得到的是 %s", sep)
+			}
+		}
+		return in, nil
+	}
+	return left, nil
+}
+
+func (p *parser) parseOperand() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		return &literalNode{val: value{kind: kindNum, n: t.num}, pos: t.pos}, nil
+	case tokString:
+		return &literalNode{val: strVal(t.text), pos: t.pos}, nil
+	case tokIdent:
+		name := strings.ToLower(t.text)
+		switch name {
+		case "true", "false":
+			return &literalNode{val: boolVal(name == "true"), pos: t.pos}, nil
+		case "and", "or", "not", "between", "in":
+			return nil, p.errorf(t, "此处需要字段或值，得到的是关键字 '%s'", t.text)
+		}
+		f, ok := fields[name]
+		if !ok {
+			if s := suggest(name); s != "" {
+				return nil, p.errorf(t, "未知字段 '%s'，是否为 '%s'?", t.text, s)
+			}
+			return nil, p.errorf(t, "未知字段 '%s' (可用字段: %s)", t.text, strings.Join(FieldNames(), ", "))
+		}
+		switch f.scope {
+		case scopeServer:
+			p.usesServer = true
+		case scopeLicense:
+			p.usesLicense = true
+		}
+		return &fieldNode{name: name, field: f, pos: t.pos}, nil
+	case tokOp:
+		if t.text == "(" {
+			inner, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if closing := p.next(); !isOp(closing, ")") {
+				return nil, p.errorf(closing, "缺少 ')'，得到的是 %s", closing)
+			}
+			return inner, nil
+		}
+	}
+	if t.kind == tokEOF {
+		return nil, p.errorf(t, "表达式不完整，此处需要字段或值")
+	}
+	return nil, p.errorf(t, "此处需要字段或值，得到的是 %s", t)
+}
@@ -0,0 +1,150 @@
+package query
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"jpy-cli/pkg/middleware/license"
+	"jpy-cli/pkg/middleware/model"
+)
+
+func TestMatch(t *testing.T) {
+	dev := model.DeviceInfo{ServerURL: "http://a", Seat: 12, Model: "SM-G9910", IsOnline: true, Platform: model.PlatformAndroid, Android: "12"}
+	rec := Record{Device: &dev, Server: &ServerStats{Devices: 40, BizOnline: 3}}
+
+	cases := []struct {
+		expr string
+		want bool
+	}{
+		{`online && !adb && seat between 10 and 40 && (model ~ "SM-" || ip == "")`, true},
+		{`online and not adb`, true},
+		{`seat between 13 and 40`, false},
+		{`model !~ "^SM-"`, false},
+		{`ip != ""`, false},
+		{`seat in (1, 12, 30)`, true},
+		{`platform == "android" && android >= "12"`, true},
+		{`devices > 30 && biz_online_count < 5`, true},
+		{`otg && !usb`, true},
+		{`online == false || seat <= 11`, false},
+		{`'single quoted' == "single quoted"`, true},
+	}
+	for _, c := range cases {
+		e, err := Parse(c.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", c.expr, err)
+		}
+		if got := e.Match(rec); got != c.want {
+			t.Errorf("%q = %v, want %v", c.expr, got, c.want)
+		}
+	}
+}
+
+func TestLicenseFields(t *testing.T) {
+	e, err := Parse(`license == "authorized" && cluster ~ "prod" && sn > "B"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !e.UsesLicense() || e.UsesServer() {
+		t.Fatalf("scopes: license=%v server=%v", e.UsesLicense(), e.UsesServer())
+	}
+	lic := &model.LicenseData{Sn: "C100", C: "prod-1", Status: 1}
+	ok := Record{License: &license.CheckResult{License: lic, Status: license.StatusOf(lic)}}
+	if !e.Match(ok) {
+		t.Error("expected license match")
+	}
+	if e.Match(Record{}) {
+		t.Error("record without license should not match")
+	}
+}
+
+func TestDaysLeftWithoutExpiry(t *testing.T) {
+	saved := timeNow
+	defer func() { timeNow = saved }()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+
+	il := float64(now.Add(3 * 24 * time.Hour).Unix())
+	expiring := &model.LicenseData{Status: 1, IL: &model.IL{Double: &il}}
+	noExpiry := &model.LicenseData{Status: 1}
+
+	cases := []struct {
+		expr string
+		rec  Record
+		want bool
+	}{
+		{`days_left < 7`, Record{License: &license.CheckResult{License: expiring}}, true},
+		{`days_left < 7`, Record{License: &license.CheckResult{License: noExpiry}}, false},
+		{`days_left < 7`, Record{License: &license.CheckResult{Status: license.StatusUnreachable}}, false},
+		{`days_left >= 7`, Record{License: &license.CheckResult{License: noExpiry}}, false},
+		{`days_left between 0 and 10`, Record{}, false},
+		{`days_left in (0, 3)`, Record{}, false},
+		{`days_left in (0, 3)`, Record{License: &license.CheckResult{License: expiring}}, true},
+	}
+	for _, c := range cases {
+		e, err := Parse(c.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", c.expr, err)
+		}
+		if got := e.Match(c.rec); got != c.want {
+			t.Errorf("%q on %+v = %v, want %v", c.expr, c.rec.License, got, c.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []struct {
+		expr string
+		want string
+		col  string
+	}{
+		{`moodel ~ "SM"`, "是否为 'model'", "第 1 列"},
+		{`online && ip`, "不能直接作为条件", "第 11 列"},
+		{`seat == "12"`, "无法用 '==' 比较数字与字符串", "第 6 列"},
+		{`online & adb`, "'&&'", "第 8 列"},
+		{`seat = 1`, "'=='", "第 6 列"},
+		{`(online`, "缺少 ')'", "第 8 列"},
+		{`model ~ "["`, "无效的正则表达式", "第 9 列"},
+		{`seat between 1 40`, "缺少 and", "第 16 列"},
+		{`online adb`, "多余的内容", "第 8 列"},
+		{`online &&`, "表达式不完整", "第 10 列"},
+		{`name == "x"`, "可用字段", "第 1 列"},
+		{`seat`, "不能直接作为条件 (例如 seat > 0)", "第 1 列"},
+	}
+	for _, c := range cases {
+		_, err := Parse(c.expr)
+		if err == nil {
+			t.Errorf("Parse(%q): expected error", c.expr)
+			continue
+		}
+		msg := err.Error()
+		if !strings.Contains(msg, c.want) || !strings.Contains(msg, c.col) {
+			t.Errorf("Parse(%q) = %q, want %q at %s", c.expr, msg, c.want, c.col)
+		}
+	}
+}
+
+func TestEmptyAndNil(t *testing.T) {
+	e, err := Parse("   ")
+	if err != nil || e != nil {
+		t.Fatalf("empty = %v, %v", e, err)
+	}
+	if !e.Match(Record{}) {
+		t.Error("nil expression should match")
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	stats := Aggregate([]model.DeviceInfo{
+		{ServerURL: "a", IsOnline: true, IP: "1.1.1.1", USBMode: true},
+		{ServerURL: "a", ADBEnabled: true},
+		{ServerURL: "b", BizOnline: true},
+	})
+	a := stats["a"]
+	if a.Devices != 2 || a.Online != 1 || a.IP != 1 || a.USB != 1 || a.OTG != 1 || a.ADB != 1 {
+		t.Errorf("a = %+v", *a)
+	}
+	if stats["b"].BizOnline != 1 {
+		t.Errorf("b = %+v", *stats["b"])
+	}
+}
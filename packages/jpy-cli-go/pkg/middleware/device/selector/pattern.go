@@ -0,0 +1,211 @@
+package selector
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ServerPattern is a parsed --server pattern. Terms are separated by "|" and
+// OR-ed together; terms prefixed with "!" exclude. Each term is one of:
+//
+//	re:<regex>       regular expression on the full URL (takes the rest of the pattern, "|" included)
+//	10.0.3.0/24      CIDR on the host IP
+//	192.168.1[0-2].* glob on the host
+//	host:8000-8100   port or port range, optionally with a host part (":443" for any host)
+//	192.168.1        substring of the host or host:port (of the full URL if it has a scheme)
+type ServerPattern struct {
+	include []patternTerm
+	exclude []patternTerm
+}
+
+type patternTerm struct {
+	re       *regexp.Regexp
+	cidr     *net.IPNet
+	glob     string
+	text     string
+	portLow  int // 0 = any port
+	portHigh int
+}
+
+var portSpec = regexp.MustCompile(`^(.*):(\d+)(?:-(\d+))?$`)
+
+// ParseServerPattern parses a --server pattern. An empty pattern matches every server.
+func ParseServerPattern(pattern string) (*ServerPattern, error) {
+	p := &ServerPattern{}
+	rest := strings.TrimSpace(pattern)
+	for rest != "" {
+		var raw string
+		negate := strings.HasPrefix(rest, "!")
+		body := strings.TrimPrefix(rest, "!")
+		if strings.HasPrefix(body, "re:") {
+			raw, rest = rest, ""
+		} else if i := strings.Index(rest, "|"); i >= 0 {
+			raw, rest = rest[:i], rest[i+1:]
+		} else {
+			raw, rest = rest, ""
+		}
+		raw = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(raw), "!"))
+		if raw == "" {
+			continue
+		}
+		term, err := parseTerm(raw)
+		if err != nil {
+			return nil, err
+		}
+		if negate {
+			p.exclude = append(p.exclude, term)
+		} else {
+			p.include = append(p.include, term)
+		}
+	}
+	return p, nil
+}
+
+func parseTerm(raw string) (patternTerm, error) {
+	var t patternTerm
+	if strings.HasPrefix(raw, "re:") {
+		re, err := regexp.Compile(raw[3:])
+		if err != nil {
+			return t, fmt.Errorf("无效的正则表达式 '%s': %v", raw[3:], err)
+		}
+		t.re = re
+		return t, nil
+	}
+
+	host := raw
+	if m := portSpec.FindStringSubmatch(raw); m != nil && !strings.Contains(m[1], "://") {
+		low, _ := strconv.Atoi(m[2])
+		high := low
+		if m[3] != "" {
+			high, _ = strconv.Atoi(m[3])
+		}
+		if low < 1 || high > 65535 || low > high {
+			return t, fmt.Errorf("无效的端口范围 '%s'", raw)
+		}
+		host, t.portLow, t.portHigh = m[1], low, high
+	}
+
+	switch {
+	case strings.Contains(host, "/") && !strings.Contains(host, "://"):
+		_, ipNet, err := net.ParseCIDR(host)
+		if err != nil {
+			return t, fmt.Errorf("无效的 CIDR '%s'", host)
+		}
+		t.cidr = ipNet
+	case strings.ContainsAny(host, "*?["):
+		if _, err := path.Match(host, ""); err != nil {
+			return t, fmt.Errorf("无效的通配符模式 '%s'", host)
+		}
+		t.glob = host
+	default:
+		t.text = host
+	}
+	return t, nil
+}
+
+// Match reports whether a server URL matches the pattern.
+func (p *ServerPattern) Match(rawURL string) bool {
+	if p == nil {
+		return true
+	}
+	target := resolveURL(rawURL)
+	for _, t := range p.exclude {
+		if t.match(target) {
+			return false
+		}
+	}
+	if len(p.include) == 0 {
+		return true
+	}
+	for _, t := range p.include {
+		if t.match(target) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchText applies the pattern to free text such as a username. Only regex,
+// glob and substring terms can match; CIDR and port terms never do.
+func (p *ServerPattern) MatchText(s string) bool {
+	if p == nil || len(p.include) == 0 {
+		return false
+	}
+	for _, t := range p.exclude {
+		if t.matchText(s) {
+			return false
+		}
+	}
+	for _, t := range p.include {
+		if t.matchText(s) {
+			return true
+		}
+	}
+	return false
+}
+
+type resolvedURL struct {
+	raw  string
+	host string
+	port int
+}
+
+func resolveURL(raw string) resolvedURL {
+	r := resolvedURL{raw: raw, host: raw}
+	withScheme := raw
+	if !strings.Contains(raw, "://") {
+		withScheme = "http://" + raw
+	}
+	u, err := url.Parse(withScheme)
+	if err != nil || u.Hostname() == "" {
+		return r
+	}
+	r.host = u.Hostname()
+	if port, err := strconv.Atoi(u.Port()); err == nil {
+		r.port = port
+	} else if u.Scheme == "https" {
+		r.port = 443
+	} else {
+		r.port = 80
+	}
+	return r
+}
+
+func (t patternTerm) match(u resolvedURL) bool {
+	if t.re != nil {
+		return t.re.MatchString(u.raw)
+	}
+	if t.portLow > 0 && (u.port < t.portLow || u.port > t.portHigh) {
+		return false
+	}
+	switch {
+	case t.cidr != nil:
+		ip := net.ParseIP(u.host)
+		return ip != nil && t.cidr.Contains(ip)
+	case t.glob != "":
+		ok, _ := path.Match(t.glob, u.host)
+		return ok
+	}
+	if strings.Contains(t.text, "://") {
+		return strings.Contains(u.raw, t.text)
+	}
+	return strings.Contains(u.host, t.text) || strings.Contains(net.JoinHostPort(u.host, strconv.Itoa(u.port)), t.text)
+}
+
+func (t patternTerm) matchText(s string) bool {
+	switch {
+	case t.re != nil:
+		return t.re.MatchString(s)
+	case t.cidr != nil || t.portLow > 0:
+		return false
+	case t.glob != "":
+		ok, _ := path.Match(t.glob, s)
+		return ok
+	}
+	return strings.Contains(s, t.text)
+}
@@ -0,0 +1,64 @@
+package selector
+
+import "testing"
+
+func TestServerPattern(t *testing.T) {
+	cases := []struct {
+		pattern string
+		url     string
+		want    bool
+	}{
+		{"", "https://10.0.0.1:8443", true},
+		{"192.168.1", "https://192.168.1.20", true},
+		{"192.168.1", "https://10.0.0.1", false},
+		{"10.0.0.1|10.0.0.2", "http://10.0.0.2:8080", true},
+		{"192.168.1[0-2].*", "https://192.168.11.5", true},
+		{"192.168.1[0-2].*", "https://192.168.13.5", false},
+		{"192.168.1[0-2].*", "https://192.168.1.5", false},
+		{"10.0.3.0/24", "http://10.0.3.77", true},
+		{"10.0.3.0/24", "http://10.0.4.1", false},
+		{"10.0.3.0/24", "http://box.local", false},
+		{":8000-8100", "http://1.2.3.4:8080", true},
+		{":8000-8100", "https://1.2.3.4", false},
+		{":443", "https://1.2.3.4", true},
+		{"10.0.3.0/24:80", "10.0.3.5", true},
+		{"1.2.3.4:8080", "http://1.2.3.4:8080", true},
+		{"1.2.3.4:8080", "http://1.2.3.4:9090", false},
+		{"https://1.2", "https://1.2.3.4", true},
+		{"!10.0.3.0/24", "http://10.0.3.5", false},
+		{"!10.0.3.0/24", "http://10.0.4.5", true},
+		{"10.0.*|!10.0.3.*", "http://10.0.3.5", false},
+		{"10.0.*|!10.0.3.*", "http://10.0.4.5", true},
+		{`re:^https://10\.0\.(3|4)\.`, "https://10.0.4.5", true},
+		{`re:^https://10\.0\.(3|4)\.`, "http://10.0.4.5", false},
+		{`!10.0.4.5|re:10\.0\.(3|4)\.`, "http://10.0.4.5", false},
+	}
+	for _, c := range cases {
+		p, err := ParseServerPattern(c.pattern)
+		if err != nil {
+			t.Fatalf("ParseServerPattern(%q): %v", c.pattern, err)
+		}
+		if got := p.Match(c.url); got != c.want {
+			t.Errorf("%q.Match(%q) = %v, want %v", c.pattern, c.url, got, c.want)
+		}
+	}
+}
+
+func TestServerPatternErrors(t *testing.T) {
+	for _, pattern := range []string{"re:[", "10.0.3.0/33", ":9000-8000", ":70000", "192.168.[1"} {
+		if _, err := ParseServerPattern(pattern); err == nil {
+			t.Errorf("ParseServerPattern(%q): expected error", pattern)
+		}
+	}
+}
+
+func TestServerPatternMatchText(t *testing.T) {
+	p, _ := ParseServerPattern("admin*|ops")
+	if !p.MatchText("admin2") || !p.MatchText("devops") || p.MatchText("guest") {
+		t.Error("unexpected MatchText result")
+	}
+	empty, _ := ParseServerPattern("")
+	if empty.MatchText("admin") {
+		t.Error("empty pattern should not match text")
+	}
+}
@@ -3,16 +3,15 @@ package selector
 import (
 	"fmt"
 	"strings"
-	"sync"
 
-	httpclient "jpy-cli/pkg/client/http"
 	"jpy-cli/pkg/config"
 	"jpy-cli/pkg/logger"
 	"jpy-cli/pkg/middleware/device/fetcher"
+	"jpy-cli/pkg/middleware/device/labels"
+	"jpy-cli/pkg/middleware/device/query"
+	"jpy-cli/pkg/middleware/license"
 	"jpy-cli/pkg/middleware/model"
 	"jpy-cli/pkg/tui"
-
-	tea "github.com/charmbracelet/bubbletea"
 )
 
 type SelectionOptions struct {
@@ -25,29 +24,48 @@ type SelectionOptions struct {
 	BizOnline      *bool
 	HasIP          *bool
 	HasUUID        *bool
-	AuthorizedOnly bool
+	Platform       model.Platform // Empty for any
+	AuthorizedOnly bool           // Shorthand for License.Statuses = [authorized]
+	License        license.Filter // License predicates, evaluated per server
+	Where          *query.Expr    // --where expression, evaluated per device
+	Labels         []labels.Selector
+	Pinned         []string // Device keys of a pinned set; nil for no restriction
+	Servers        []string // Server URLs of a pinned set; nil for no restriction
 	Interactive    bool
 }
 
-// MatchServerPattern checks if the server URL matches the pattern.
-// Supports multiple patterns separated by "|".
-func MatchServerPattern(url, pattern string) bool {
-	if pattern == "" {
+// LicenseFilter returns the effective license filter, folding in AuthorizedOnly.
+func (o SelectionOptions) LicenseFilter() license.Filter {
+	f := o.License
+	if o.AuthorizedOnly && len(f.Statuses) == 0 {
+		f.Statuses = []license.Status{license.StatusAuthorized}
+	}
+	return f
+}
+
+// ServerAllowed reports whether url is one of Servers, or Servers is unset.
+func (o SelectionOptions) ServerAllowed(url string) bool {
+	if o.Servers == nil {
 		return true
 	}
-	// Support OR logic with "|"
-	parts := strings.Split(pattern, "|")
-	for _, part := range parts {
-		if part == "" {
-			continue
-		}
-		if strings.Contains(url, part) {
+	for _, s := range o.Servers {
+		if s == url {
 			return true
 		}
 	}
 	return false
 }
 
+// MatchServerPattern checks if the server URL matches the pattern.
+// See ServerPattern for the syntax; invalid patterns match nothing.
+func MatchServerPattern(url, pattern string) bool {
+	p, err := ParseServerPattern(pattern)
+	if err != nil {
+		return false
+	}
+	return p.Match(url)
+}
+
 // SelectDevices runs the discovery and filtering process.
 // It returns a list of devices matching the criteria.
 func SelectDevices(opts SelectionOptions) ([]model.DeviceInfo, error) {
@@ -69,6 +87,11 @@ func SelectDevices(opts SelectionOptions) ([]model.DeviceInfo, error) {
 		return nil, fmt.Errorf("分组 '%s' 中未找到服务器", targetGroup)
 	}
 
+	pattern, err := ParseServerPattern(opts.ServerPattern)
+	if err != nil {
+		return nil, err
+	}
+
 	// 1. Filter Servers
 	var targetServers []config.LocalServerConfig
 	for _, s := range allServers {
@@ -76,7 +99,7 @@ func SelectDevices(opts SelectionOptions) ([]model.DeviceInfo, error) {
 		if s.Disabled {
 			continue
 		}
-		if MatchServerPattern(s.URL, opts.ServerPattern) {
+		if pattern.Match(s.URL) && opts.ServerAllowed(s.URL) {
 			targetServers = append(targetServers, s)
 		}
 	}
@@ -85,13 +108,20 @@ func SelectDevices(opts SelectionOptions) ([]model.DeviceInfo, error) {
 		return nil, fmt.Errorf("未找到匹配的服务器")
 	}
 
+	if f := opts.LicenseFilter(); f.Active() {
+		targetServers = license.Shared(cfg).Select(targetServers, f)
+		if len(targetServers) == 0 {
+			return nil, fmt.Errorf("没有符合授权条件的服务器")
+		}
+	}
+
 	// 2. Fetch Devices (with Progress TUI)
 	// We use the shared fetcher which returns a channel
 	resultsChan, total := fetcher.FetchDevices(targetServers, cfg)
 
 	// Reuse existing progress TUI
 	totalDevicesFound := 0
-	prog := tea.NewProgram(tui.NewProgressModel(total, resultsChan, func(v interface{}) string {
+	rawResults, err := tui.RunProgress(total, resultsChan, func(v interface{}) string {
 		res := v.(fetcher.ServerResult)
 		cleanURL := strings.TrimPrefix(res.ServerURL, "https://")
 		cleanURL = strings.TrimPrefix(cleanURL, "http://")
@@ -100,15 +130,12 @@ func SelectDevices(opts SelectionOptions) ([]model.DeviceInfo, error) {
 		}
 		totalDevicesFound += len(res.Devices)
 		return fmt.Sprintf("✅ %s: 发现 %d 台设备 (总计: %d)", cleanURL, len(res.Devices), totalDevicesFound)
-	}))
-
-	finalModel, err := prog.Run()
+	})
 	if err != nil {
 		return nil, fmt.Errorf("TUI error: %v", err)
 	}
 
 	// 3. Process Results
-	rawResults := finalModel.(tui.ProgressModel).GetResults()
 	allDevices, _ := fetcher.ProcessResults(rawResults)
 
 	// 4. Filter Devices
@@ -116,8 +143,34 @@ func SelectDevices(opts SelectionOptions) ([]model.DeviceInfo, error) {
 		logger.Infof("DEBUG: Applying USB Filter: required=%v", *opts.USB)
 	}
 
+	var stats map[string]*query.ServerStats
+	licenses := make(map[string]*license.CheckResult)
+	if opts.Where != nil {
+		stats = query.Aggregate(allDevices)
+		if opts.Where.UsesLicense() {
+			checks := license.Shared(cfg).Check(targetServers)
+			for i := range checks {
+				licenses[checks[i].Server.URL] = &checks[i]
+			}
+		}
+	}
+
+	var pinned map[string]bool
+	if opts.Pinned != nil {
+		pinned = make(map[string]bool, len(opts.Pinned))
+		for _, k := range opts.Pinned {
+			pinned[k] = true
+		}
+	}
+
 	var filtered []model.DeviceInfo
 	for _, d := range allDevices {
+		if pinned != nil && !isPinned(pinned, d) {
+			continue
+		}
+		if len(opts.Labels) > 0 && !labels.Match(labels.Lookup(cfg, d), opts.Labels) {
+			continue
+		}
 		// UUID Filter (Fuzzy)
 		if opts.UUID != "" && !strings.Contains(d.UUID, opts.UUID) {
 			continue
@@ -126,6 +179,10 @@ func SelectDevices(opts SelectionOptions) ([]model.DeviceInfo, error) {
 		if opts.Seat > -1 && d.Seat != opts.Seat {
 			continue
 		}
+		// Platform Filter
+		if !d.Platform.Matches(opts.Platform) {
+			continue
+		}
 		// Status Filters
 		if opts.ADB != nil && d.ADBEnabled != *opts.ADB {
 			continue
@@ -152,6 +209,12 @@ func SelectDevices(opts SelectionOptions) ([]model.DeviceInfo, error) {
 				continue
 			}
 		}
+		if opts.Where != nil {
+			dev := d
+			if !opts.Where.Match(query.Record{Device: &dev, Server: stats[d.ServerURL], License: licenses[d.ServerURL]}) {
+				continue
+			}
+		}
 		filtered = append(filtered, d)
 	}
 
@@ -168,31 +231,11 @@ func SelectDevices(opts SelectionOptions) ([]model.DeviceInfo, error) {
 	return filtered, nil
 }
 
-func filterAuthorizedServers(servers []config.LocalServerConfig) []config.LocalServerConfig {
-	var wg sync.WaitGroup
-	var mu sync.Mutex
-	var authorized []config.LocalServerConfig
-	sem := make(chan struct{}, 20) // Limit concurrency
-
-	for _, s := range servers {
-		wg.Add(1)
-		go func(server config.LocalServerConfig) {
-			defer wg.Done()
-			sem <- struct{}{}
-			defer func() { <-sem }()
-
-			// Create client without token (status check shouldn't require auth token for the CLI itself, usually)
-			// Or if it does, we assume it works or we need a way to get it.
-			// Based on `auto-auth`, it uses empty token.
-			client := httpclient.NewClient(server.URL, "")
-			info, err := client.GetLicense()
-			if err == nil && info != nil && info.StatusTxt == "成功" {
-				mu.Lock()
-				authorized = append(authorized, server)
-				mu.Unlock()
-			}
-		}(s)
+func isPinned(pinned map[string]bool, d model.DeviceInfo) bool {
+	for _, k := range labels.Keys(d) {
+		if pinned[k] {
+			return true
+		}
 	}
-	wg.Wait()
-	return authorized
+	return false
 }
@@ -3,6 +3,7 @@ package selector
 import (
 	"fmt"
 	"jpy-cli/pkg/middleware/model"
+	"jpy-cli/pkg/tui"
 	"sort"
 	"strings"
 
@@ -114,6 +115,9 @@ var baseStyle = lipgloss.NewStyle().
 	BorderForeground(lipgloss.Color("240"))
 
 func RunInteractiveSelection(devices []model.DeviceInfo) ([]model.DeviceInfo, error) {
+	if err := tui.RequirePrompt("请通过 --server/--seat/--uuid/--where 等参数直接指定设备"); err != nil {
+		return nil, err
+	}
 	columns := []table.Column{
 		{Title: "选", Width: 4},
 		{Title: "服务器", Width: 16},
@@ -0,0 +1,131 @@
+package license
+
+import (
+	"fmt"
+	adminApi "jpy-cli/pkg/admin-middleware/api"
+	adminModel "jpy-cli/pkg/admin-middleware/model"
+	"jpy-cli/pkg/config"
+	"jpy-cli/pkg/logger"
+	"net"
+	"net/url"
+	"sync"
+)
+
+// AuthorizeResult is the outcome of authorizing one server.
+type AuthorizeResult struct {
+	Server string
+	Name   string // Auth code name, prefix + AuthCodeSuffix
+	Key    string // Serial number submitted to the server
+	Cached bool   // Key came from the recent records instead of a lookup
+	Error  error
+}
+
+// Authorize gets an auth code named after each server (reusing recent or
+// existing codes of the same name, generating one otherwise) and submits it
+// to the server. Servers run concurrently; onResult, if set, is called once
+// per server as it finishes, never concurrently.
+func (s *Service) Authorize(admin *adminApi.Client, servers []config.LocalServerConfig, prefix string, recent []adminModel.AuthCodeItem, onResult func(AuthorizeResult)) []AuthorizeResult {
+	cached := make(map[string]string, len(recent))
+	for _, r := range recent {
+		if _, ok := cached[r.Name]; !ok {
+			cached[r.Name] = r.SerialNumber
+		}
+	}
+
+	// Servers sharing a name must not generate the same code twice
+	var locksMu sync.Mutex
+	locks := make(map[string]*sync.Mutex)
+	lockName := func(name string) func() {
+		locksMu.Lock()
+		l, ok := locks[name]
+		if !ok {
+			l = &sync.Mutex{}
+			locks[name] = l
+		}
+		locksMu.Unlock()
+		l.Lock()
+		return l.Unlock
+	}
+
+	var reportMu sync.Mutex
+	results := make([]AuthorizeResult, len(servers))
+	s.forEach(len(servers), func(i int) {
+		server := servers[i]
+		res := AuthorizeResult{Server: server.URL, Name: prefix + AuthCodeSuffix(server.URL)}
+		logger.Infof("[AUDIT] START: Authorization for server=%s, name=%s", server.URL, res.Name)
+
+		if key, ok := cached[res.Name]; ok {
+			res.Key, res.Cached = key, true
+			logger.Infof("[AUDIT] Found existing auth in cache: name=%s, key=%s", res.Name, key)
+		} else {
+			unlock := lockName(res.Name)
+			res.Key, res.Error = obtainAuthCode(admin, res.Name)
+			unlock()
+		}
+
+		if res.Error == nil {
+			res.Error = s.submit(server, res.Key)
+		}
+		if res.Error != nil {
+			logger.Errorf("[AUDIT] FAILED: Authorization for server=%s, name=%s: %v", server.URL, res.Name, res.Error)
+		} else {
+			logger.Infof("[AUDIT] SUCCESS: Server authorized. server=%s, name=%s, key=%s", server.URL, res.Name, res.Key)
+		}
+
+		results[i] = res
+		if onResult != nil {
+			reportMu.Lock()
+			onResult(res)
+			reportMu.Unlock()
+		}
+	})
+	return results
+}
+
+// obtainAuthCode generates the code named name and returns its serial number.
+// Generation fails for an existing name, in which case the lookup finds the old code.
+func obtainAuthCode(admin *adminApi.Client, name string) (string, error) {
+	if err := admin.GenerateAuthCode(name); err != nil {
+		logger.Warnf("[AUDIT] GenerateAuthCode warning for name=%s: %v (will try search)", name, err)
+	} else {
+		logger.Infof("[AUDIT] GenerateAuthCode success: name=%s", name)
+	}
+
+	key, err := admin.SearchAuthCode(name)
+	if err != nil {
+		return "", fmt.Errorf("查找授权码失败: %v", err)
+	}
+	logger.Infof("[AUDIT] SearchAuthCode success: name=%s, key=%s", name, key)
+	return key, nil
+}
+
+// submit resubmits key to the server.
+func (s *Service) submit(server config.LocalServerConfig, key string) error {
+	logger.Infof("[AUDIT] Reauthorizing server=%s with key=%s", server.URL, key)
+	if _, err := s.reauthorize(server, key); err != nil {
+		return fmt.Errorf("重新授权失败: %v", err)
+	}
+	return nil
+}
+
+// AuthCodeSuffix derives the auth code suffix of a server: a non-standard
+// port when present (129.204.22.176:31203 -> 31203), otherwise the last two
+// IPv4 octets (192.168.31.203 -> 31203), or "00000" as a last resort.
+func AuthCodeSuffix(serverURL string) string {
+	u, err := url.Parse(serverURL)
+	if err != nil || u.Host == "" {
+		u, err = url.Parse("http://" + serverURL)
+	}
+
+	if err == nil {
+		if port := u.Port(); port != "" && port != "80" && port != "443" {
+			return port
+		}
+		if ip := net.ParseIP(u.Hostname()); ip != nil {
+			if v4 := ip.To4(); v4 != nil {
+				return fmt.Sprintf("%d%d", v4[2], v4[3])
+			}
+		}
+	}
+	return "00000"
+}
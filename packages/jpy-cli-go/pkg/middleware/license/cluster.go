@@ -0,0 +1,72 @@
+package license
+
+import (
+	"fmt"
+	adminApi "jpy-cli/pkg/admin-middleware/api"
+	"jpy-cli/pkg/config"
+	"jpy-cli/pkg/logger"
+	"time"
+)
+
+// ClusterResult is the outcome of pointing one server at a cluster address.
+type ClusterResult struct {
+	Server       string
+	SN           string
+	AdminUpdated bool // False when the admin backend already had the address
+	Error        error
+}
+
+// UpdateCluster sets the control platform address of the auth code sn to
+// addr on the admin backend (skipped when it already matches, unless force)
+// and resubmits the key to the server. Each server is retried up to 3 times.
+func (s *Service) UpdateCluster(admin *adminApi.Client, server config.LocalServerConfig, sn, addr string, force bool) ClusterResult {
+	res := ClusterResult{Server: server.URL, SN: sn}
+	const maxRetries = 3
+
+	for attempt := 1; attempt <= maxRetries; attempt++ {
+		res.AdminUpdated, res.Error = s.updateCluster(admin, server, sn, addr, force)
+		if res.Error == nil {
+			break
+		}
+		if attempt < maxRetries {
+			time.Sleep(2 * time.Second)
+		}
+	}
+
+	if res.Error != nil {
+		logger.Infof("[AUDIT] SN: %s - Update failed: %v", sn, res.Error)
+	}
+	return res
+}
+
+func (s *Service) updateCluster(admin *adminApi.Client, server config.LocalServerConfig, sn, addr string, force bool) (bool, error) {
+	logger.Infof("[AUDIT] Checking Admin Auth for SN: %s (Server: %s)", sn, server.URL)
+	item, err := admin.GetAuthBySN(sn)
+	if err != nil {
+		return false, fmt.Errorf("获取授权信息失败: %v", err)
+	}
+
+	updated := false
+	if item.MgtCenter == addr && !force {
+		// The middleware still needs the resubmission below to pick the address up
+		logger.Infof("[AUDIT] SN: %s - Admin MgtCenter already matches target (%s). Skipping Admin Update.", sn, addr)
+	} else {
+		if item.MgtCenter == addr {
+			logger.Infof("[AUDIT] SN: %s - Admin MgtCenter matches target (%s) but FORCE is enabled. Updating anyway.", sn, addr)
+		}
+		logger.Infof("[AUDIT] SN: %s - Updating Admin MgtCenter to %s (Old: %s)", sn, addr, item.MgtCenter)
+
+		payload := item.ToPayload()
+		payload.MgtCenter = addr
+		if err := admin.UpdateAuth(payload); err != nil {
+			return false, fmt.Errorf("更新授权失败: %v", err)
+		}
+		updated = true
+	}
+
+	logger.Infof("[AUDIT] SN: %s - Reauthorizing Middleware at %s", sn, server.URL)
+	if _, err := s.reauthorize(server, sn); err != nil {
+		return updated, fmt.Errorf("中间件重新授权失败: %v", err)
+	}
+	return updated, nil
+}
@@ -0,0 +1,107 @@
+package license
+
+import (
+	"fmt"
+	"jpy-cli/pkg/config"
+	"strings"
+	"time"
+)
+
+// Filter selects servers by license. Zero values disable a predicate; all
+// enabled predicates must match.
+type Filter struct {
+	Statuses           []Status // Any of
+	ClusterContains    string
+	ClusterNotContains string
+	SNAfter            string // SN > SNAfter (lexicographic)
+	SNBefore           string // SN < SNBefore (lexicographic)
+	ExpiringWithin     int    // Expired or expiring within N days; <= 0 disables
+}
+
+// Active reports whether any predicate is enabled.
+func (f Filter) Active() bool {
+	return len(f.Statuses) > 0 || f.ClusterContains != "" || f.ClusterNotContains != "" ||
+		f.SNAfter != "" || f.SNBefore != "" || f.ExpiringWithin > 0
+}
+
+// Match evaluates the filter against a check result. Servers without a
+// readable license only match a filter that asks for StatusUnreachable and
+// nothing else.
+func (f Filter) Match(c CheckResult, now time.Time) bool {
+	if len(f.Statuses) > 0 {
+		found := false
+		for _, s := range f.Statuses {
+			if s == c.Status {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	lic := c.License
+	if lic == nil {
+		return f.ClusterContains == "" && f.ClusterNotContains == "" &&
+			f.SNAfter == "" && f.SNBefore == "" && f.ExpiringWithin <= 0
+	}
+	if f.ClusterContains != "" && !strings.Contains(lic.C, f.ClusterContains) {
+		return false
+	}
+	if f.ClusterNotContains != "" && strings.Contains(lic.C, f.ClusterNotContains) {
+		return false
+	}
+	if f.SNAfter != "" && lic.Sn <= f.SNAfter {
+		return false
+	}
+	if f.SNBefore != "" && lic.Sn >= f.SNBefore {
+		return false
+	}
+	if f.ExpiringWithin > 0 {
+		r := Evaluate(c.Server.URL, lic, now, f.ExpiringWithin)
+		if !r.Expiring {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseStatuses parses a comma separated list of authorized/unauthorized/unreachable.
+func ParseStatuses(s string) ([]Status, error) {
+	var statuses []Status
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		found := false
+		for _, st := range []Status{StatusAuthorized, StatusUnauthorized, StatusUnreachable} {
+			if st.Key() == part {
+				statuses = append(statuses, st)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("未知授权状态: %s (可选: authorized, unauthorized, unreachable)", part)
+		}
+	}
+	return statuses, nil
+}
+
+// Select checks servers (using the per-run cache) and keeps those matching f.
+// Tokens refreshed during the check are carried over.
+func (s *Service) Select(servers []config.LocalServerConfig, f Filter) []config.LocalServerConfig {
+	if !f.Active() {
+		return servers
+	}
+	now := time.Now()
+	var matched []config.LocalServerConfig
+	for _, c := range s.Check(servers) {
+		if f.Match(c, now) {
+			matched = append(matched, c.Server)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,75 @@
+package license
+
+import (
+	"jpy-cli/pkg/config"
+	"jpy-cli/pkg/middleware/model"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFilterMatch(t *testing.T) {
+	now := time.Now()
+	soon := float64(now.Add(5 * 24 * time.Hour).Unix())
+	authorized := CheckResult{Status: StatusAuthorized, License: &model.LicenseData{Status: 1, Sn: "M", C: "10.0.0.2:8080", IL: &model.IL{Double: &soon}}}
+	offline := CheckResult{Status: StatusUnreachable}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		check  CheckResult
+		want   bool
+	}{
+		{"empty", Filter{}, offline, true},
+		{"status", Filter{Statuses: []Status{StatusAuthorized}}, authorized, true},
+		{"status mismatch", Filter{Statuses: []Status{StatusAuthorized}}, offline, false},
+		{"unreachable wanted", Filter{Statuses: []Status{StatusUnreachable}}, offline, true},
+		{"cluster", Filter{ClusterContains: "10.0.0.2"}, authorized, true},
+		{"cluster mismatch", Filter{ClusterContains: "10.0.0.3"}, authorized, false},
+		{"cluster excluded", Filter{ClusterNotContains: ":8080"}, authorized, false},
+		{"cluster needs license", Filter{ClusterNotContains: "x"}, offline, false},
+		{"sn range", Filter{SNAfter: "A", SNBefore: "Z"}, authorized, true},
+		{"sn bound exclusive", Filter{SNAfter: "M"}, authorized, false},
+		{"expiring", Filter{ExpiringWithin: 7}, authorized, true},
+		{"not expiring yet", Filter{ExpiringWithin: 3}, authorized, false},
+	}
+	for _, tt := range tests {
+		if got := tt.filter.Match(tt.check, now); got != tt.want {
+			t.Errorf("%s: got %v", tt.name, got)
+		}
+	}
+}
+
+func TestParseStatuses(t *testing.T) {
+	got, err := ParseStatuses("authorized, unreachable")
+	if err != nil || len(got) != 2 || got[0] != StatusAuthorized || got[1] != StatusUnreachable {
+		t.Errorf("got %v, %v", got, err)
+	}
+	if _, err := ParseStatuses("成功"); err == nil {
+		t.Error("unknown status should fail")
+	}
+}
+
+func TestSelectCachesChecks(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Write([]byte(`{"code":200,"data":{"SN":"SN-1","status":1,"C":"cluster-a"}}`))
+	}))
+	defer srv.Close()
+
+	svc := &Service{Concurrency: 2}
+	servers := []config.LocalServerConfig{{URL: srv.URL, Token: "t"}}
+
+	if got := svc.Select(servers, Filter{ClusterContains: "cluster-a"}); len(got) != 1 {
+		t.Fatalf("select: %v", got)
+	}
+	if got := svc.Select(servers, Filter{ClusterContains: "cluster-b"}); len(got) != 0 {
+		t.Fatalf("select: %v", got)
+	}
+	if hits != 1 {
+		t.Errorf("license fetched %d times, want 1", hits)
+	}
+}
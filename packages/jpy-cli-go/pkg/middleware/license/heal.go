@@ -0,0 +1,257 @@
+package license
+
+import (
+	"fmt"
+	adminApi "jpy-cli/pkg/admin-middleware/api"
+	"jpy-cli/pkg/admin-middleware/service"
+	"jpy-cli/pkg/config"
+	"jpy-cli/pkg/logger"
+	"jpy-cli/pkg/middleware/model"
+	"strings"
+	"time"
+)
+
+// Issue is a license anomaly that heal knows how to fix.
+type Issue string
+
+const (
+	IssueMissingControl Issue = "missing-control" // Authorized but no control platform address
+	IssueWrongCluster   Issue = "wrong-cluster"   // Control platform differs from the expected cluster
+	IssueExpired        Issue = "expired"
+)
+
+// AllIssues lists every rule in evaluation order.
+var AllIssues = []Issue{IssueMissingControl, IssueWrongCluster, IssueExpired}
+
+func (i Issue) String() string {
+	switch i {
+	case IssueMissingControl:
+		return "缺少集控平台地址"
+	case IssueWrongCluster:
+		return "集控平台地址不符"
+	case IssueExpired:
+		return "授权已过期"
+	}
+	return string(i)
+}
+
+// ParseIssues parses a comma separated rule list.
+func ParseIssues(s string) ([]Issue, error) {
+	var issues []Issue
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		found := false
+		for _, i := range AllIssues {
+			if string(i) == part {
+				issues = append(issues, i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("未知规则: %s (可选: missing-control, wrong-cluster, expired)", part)
+		}
+	}
+	return issues, nil
+}
+
+// Diagnose returns the anomalies of a license. The wrong-cluster rule only
+// applies when cluster is set.
+func Diagnose(lic *model.LicenseData, now time.Time, cluster string) []Issue {
+	if lic == nil {
+		return nil
+	}
+	var issues []Issue
+	if StatusOf(lic) == StatusAuthorized && lic.C == "" {
+		issues = append(issues, IssueMissingControl)
+	}
+	if cluster != "" && lic.C != "" && lic.C != cluster {
+		issues = append(issues, IssueWrongCluster)
+	}
+	if r := Evaluate("", lic, now, 0); r.HasExpiry() && r.DaysLeft < 0 {
+		issues = append(issues, IssueExpired)
+	}
+	return issues
+}
+
+// HealOptions controls which rules are applied and how.
+type HealOptions struct {
+	Rules     []Issue
+	Cluster   string // Expected control platform address for wrong-cluster
+	RenewDays int    // Days added to expired auth codes
+	DryRun    bool
+}
+
+func (o HealOptions) enabled(i Issue) bool {
+	for _, r := range o.Rules {
+		if r == i {
+			return true
+		}
+	}
+	return false
+}
+
+// Finding is the diagnosis of one server, before any action is taken.
+type Finding struct {
+	Server  config.LocalServerConfig
+	License *model.LicenseData
+	Issues  []Issue
+	Error   error
+}
+
+// NeedsAdmin reports whether fixing the finding requires the admin backend.
+func (f Finding) NeedsAdmin() bool {
+	for _, i := range f.Issues {
+		if i == IssueWrongCluster || i == IssueExpired {
+			return true
+		}
+	}
+	return false
+}
+
+// Diagnose checks servers concurrently and keeps the issues enabled in opts.
+func (s *Service) Diagnose(servers []config.LocalServerConfig, opts HealOptions) []Finding {
+	now := time.Now()
+	checks := s.Check(servers)
+	findings := make([]Finding, len(checks))
+	for i, c := range checks {
+		f := Finding{Server: c.Server, License: c.License, Error: c.Error}
+		for _, issue := range Diagnose(c.License, now, opts.Cluster) {
+			if opts.enabled(issue) {
+				f.Issues = append(f.Issues, issue)
+			}
+		}
+		findings[i] = f
+	}
+	return findings
+}
+
+// HealResult is the action log of one server.
+type HealResult struct {
+	Server  string   `json:"server"`
+	SN      string   `json:"sn"`
+	Issues  []Issue  `json:"issues"`
+	Actions []string `json:"actions"`
+	Fixed   bool     `json:"fixed"`
+	Remains []Issue  `json:"remains,omitempty"` // Issues still present after healing
+	Error   string   `json:"error,omitempty"`
+}
+
+// Heal fixes findings concurrently: the auth code is updated on the admin
+// backend when needed (cluster address, validity), then the key is
+// resubmitted to the server and the license re-checked. admin may be nil when
+// no finding needs the backend or in dry-run mode.
+func (s *Service) Heal(admin *adminApi.Client, findings []Finding, opts HealOptions) []HealResult {
+	results := make([]HealResult, len(findings))
+	s.forEach(len(findings), func(i int) {
+		results[i] = s.heal(admin, findings[i], opts)
+	})
+	return results
+}
+
+// heal fixes one finding with the client Diagnose checked it with.
+func (s *Service) heal(admin *adminApi.Client, f Finding, o HealOptions) HealResult {
+	res := HealResult{Server: f.Server.URL, Issues: f.Issues}
+	if f.Error != nil {
+		res.Error = f.Error.Error()
+		return res
+	}
+	if len(f.Issues) == 0 {
+		res.Fixed = true
+		return res
+	}
+	res.SN = f.License.Sn
+	if res.SN == "" {
+		res.Error = "缺少授权序列号，无法修复"
+		return res
+	}
+
+	fail := func(err error) HealResult {
+		res.Error = err.Error()
+		logger.Errorf("[AUDIT] FAILED: Heal server=%s sn=%s: %v", f.Server.URL, res.SN, err)
+		return res
+	}
+
+	if f.NeedsAdmin() {
+		if err := o.updateAuthCode(admin, f, &res); err != nil {
+			return fail(err)
+		}
+	}
+
+	res.Actions = append(res.Actions, "重新提交授权码")
+	if o.DryRun {
+		return res
+	}
+	client, err := s.reauthorize(f.Server, res.SN)
+	if err != nil {
+		return fail(fmt.Errorf("重新提交授权失败: %v", err))
+	}
+	logger.Infof("[AUDIT] Heal reauthorized server=%s sn=%s issues=%v", f.Server.URL, res.SN, f.Issues)
+
+	lic, err := client.GetLicense()
+	if err != nil {
+		return fail(fmt.Errorf("刷新授权信息失败: %v", err))
+	}
+	for _, i := range Diagnose(lic, time.Now(), o.Cluster) {
+		if o.enabled(i) {
+			res.Remains = append(res.Remains, i)
+		}
+	}
+	res.Fixed = len(res.Remains) == 0
+	return res
+}
+
+func (o HealOptions) updateAuthCode(admin *adminApi.Client, f Finding, res *HealResult) error {
+	var wantCluster, renew bool
+	for _, i := range f.Issues {
+		wantCluster = wantCluster || i == IssueWrongCluster
+		renew = renew || i == IssueExpired
+	}
+
+	// Dry runs do not log in to the admin backend, so only the intent is logged
+	if o.DryRun {
+		if wantCluster {
+			res.Actions = append(res.Actions, fmt.Sprintf("后台集控地址 -> %s", o.Cluster))
+		}
+		if renew {
+			res.Actions = append(res.Actions, fmt.Sprintf("后台有效期 +%d 天", o.RenewDays))
+		}
+		return nil
+	}
+	if admin == nil {
+		return fmt.Errorf("未登录管理后台")
+	}
+
+	item, err := admin.GetAuthBySN(res.SN)
+	if err != nil {
+		return fmt.Errorf("查询授权码失败: %v", err)
+	}
+	payload := item.ToPayload()
+	changed := false
+	if wantCluster && payload.MgtCenter != o.Cluster {
+		res.Actions = append(res.Actions, fmt.Sprintf("后台集控地址 %s -> %s", payload.MgtCenter, o.Cluster))
+		payload.MgtCenter = o.Cluster
+		changed = true
+	}
+	if renew {
+		ok, err := service.ExtendDays(o.RenewDays)(*item, &payload)
+		if err != nil {
+			return err
+		}
+		if ok {
+			res.Actions = append(res.Actions, fmt.Sprintf("后台有效期 %d -> %d 天", item.Day, payload.Day))
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	if err := admin.UpdateAuth(payload); err != nil {
+		return fmt.Errorf("更新授权码失败: %v", err)
+	}
+	logger.Infof("[AUDIT] Heal updated auth code sn=%s actions=%v", res.SN, res.Actions)
+	return nil
+}
@@ -0,0 +1,83 @@
+package license
+
+import (
+	"jpy-cli/pkg/config"
+	"jpy-cli/pkg/middleware/model"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiagnose(t *testing.T) {
+	now := time.Now()
+	expired := float64(now.Add(-48 * time.Hour).Unix())
+	il := &model.IL{Double: &expired}
+
+	tests := []struct {
+		name    string
+		lic     model.LicenseData
+		cluster string
+		want    []Issue
+	}{
+		{"healthy", model.LicenseData{StatusTxt: "成功", C: "a"}, "a", nil},
+		{"missing control", model.LicenseData{StatusTxt: "成功"}, "a", []Issue{IssueMissingControl}},
+		{"unauthorized without control", model.LicenseData{StatusTxt: "未授权"}, "", nil},
+		{"wrong cluster", model.LicenseData{StatusTxt: "成功", C: "b"}, "a", []Issue{IssueWrongCluster}},
+		{"cluster not checked", model.LicenseData{StatusTxt: "成功", C: "b"}, "", nil},
+		{"expired", model.LicenseData{StatusTxt: "成功", C: "b", IL: il}, "a", []Issue{IssueWrongCluster, IssueExpired}},
+	}
+	for _, tt := range tests {
+		if got := Diagnose(&tt.lic, now, tt.cluster); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseIssues(t *testing.T) {
+	got, err := ParseIssues("expired, missing-control,")
+	if err != nil || !reflect.DeepEqual(got, []Issue{IssueExpired, IssueMissingControl}) {
+		t.Errorf("got %v, %v", got, err)
+	}
+	if _, err := ParseIssues("bogus"); err == nil {
+		t.Error("unknown rule should fail")
+	}
+}
+
+func TestHealMissingControl(t *testing.T) {
+	reauthorized := ""
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			reauthorized = r.URL.Query().Get("key")
+			w.Write([]byte(`{"code":200}`))
+			return
+		}
+		c := ""
+		if reauthorized != "" {
+			c = "cluster"
+		}
+		w.Write([]byte(`{"code":200,"data":{"SN":"SN-1","statusTxt":"成功","C":"` + c + `"}}`))
+	}))
+	defer srv.Close()
+
+	server := config.LocalServerConfig{URL: srv.URL, Token: "t"}
+	opts := HealOptions{Rules: AllIssues, RenewDays: 30, DryRun: true}
+	svc := &Service{Concurrency: 1}
+
+	f := svc.Diagnose([]config.LocalServerConfig{server}, opts)[0]
+	if f.Error != nil || !reflect.DeepEqual(f.Issues, []Issue{IssueMissingControl}) || f.NeedsAdmin() {
+		t.Fatalf("finding: %+v", f)
+	}
+
+	res := svc.Heal(nil, []Finding{f}, opts)[0]
+	if res.Error != "" || len(res.Actions) != 1 || reauthorized != "" {
+		t.Fatalf("dry run: %+v, reauthorized %q", res, reauthorized)
+	}
+
+	opts.DryRun = false
+	res = svc.Heal(nil, []Finding{f}, opts)[0]
+	if res.Error != "" || !res.Fixed || reauthorized != "SN-1" {
+		t.Fatalf("heal: %+v, reauthorized %q", res, reauthorized)
+	}
+}
@@ -0,0 +1,63 @@
+package license
+
+import (
+	"fmt"
+	adminApi "jpy-cli/pkg/admin-middleware/api"
+	"jpy-cli/pkg/admin-middleware/service"
+	"jpy-cli/pkg/config"
+	"jpy-cli/pkg/logger"
+	"time"
+)
+
+// RenewResult is the outcome of renewing one server's license.
+type RenewResult struct {
+	Server  string `json:"server"`
+	SN      string `json:"sn"`
+	OldDays int    `json:"oldDays"` // Validity on the admin backend before renewal
+	NewDays int    `json:"newDays"`
+	After   Report `json:"after"` // License re-read from the server after resubmission
+	Error   string `json:"error,omitempty"`
+}
+
+// Renew extends the auth code behind a license by days on the admin backend
+// and resubmits the key to the server so it picks up the new expiry.
+func (s *Service) Renew(admin *adminApi.Client, server config.LocalServerConfig, sn string, days, within int) RenewResult {
+	res := RenewResult{Server: server.URL, SN: sn}
+	fail := func(format string, args ...interface{}) RenewResult {
+		res.Error = fmt.Sprintf(format, args...)
+		logger.Errorf("[AUDIT] FAILED: Renew server=%s sn=%s: %s", server.URL, sn, res.Error)
+		return res
+	}
+
+	if sn == "" {
+		return fail("缺少授权序列号")
+	}
+
+	item, err := admin.GetAuthBySN(sn)
+	if err != nil {
+		return fail("查询授权码失败: %v", err)
+	}
+	payload := item.ToPayload()
+	if _, err := service.ExtendDays(days)(*item, &payload); err != nil {
+		return fail("%v", err)
+	}
+	if err := admin.UpdateAuth(payload); err != nil {
+		return fail("延长授权码失败: %v", err)
+	}
+	res.OldDays, res.NewDays = item.Day, payload.Day
+	logger.Infof("[AUDIT] Extended auth code sn=%s days %d -> %d", sn, item.Day, payload.Day)
+
+	client, err := s.reauthorize(server, sn)
+	if err != nil {
+		return fail("重新提交授权失败: %v", err)
+	}
+	logger.Infof("[AUDIT] SUCCESS: Reauthorized server=%s sn=%s", server.URL, sn)
+
+	lic, err := client.GetLicense()
+	if err != nil {
+		res.Error = fmt.Sprintf("刷新授权信息失败: %v", err)
+		return res
+	}
+	res.After = Evaluate(server.URL, lic, time.Now(), within)
+	return res
+}
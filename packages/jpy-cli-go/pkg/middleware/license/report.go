@@ -0,0 +1,141 @@
+package license
+
+import (
+	"jpy-cli/pkg/config"
+	"jpy-cli/pkg/middleware/model"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Report is the license state of one server.
+type Report struct {
+	Server    string             `json:"server"`
+	Status    Status             `json:"status"`
+	License   *model.LicenseData `json:"license,omitempty"`
+	ExpiresAt time.Time          `json:"expiresAt"`
+	DaysLeft  int                `json:"daysLeft"`
+	Used      int                `json:"used"`
+	Limit     int                `json:"limit"`
+	Expiring  bool               `json:"expiring"` // Expired or expiring within the report window
+	Error     string             `json:"error,omitempty"`
+}
+
+// HasExpiry reports whether the license carries an expiry time.
+func (r Report) HasExpiry() bool {
+	return !r.ExpiresAt.IsZero()
+}
+
+// Utilisation is used / limit in percent, or -1 when the limit is unknown.
+func (r Report) Utilisation() float64 {
+	if r.Limit <= 0 {
+		return -1
+	}
+	return float64(r.Used) * 100 / float64(r.Limit)
+}
+
+// Evaluate computes expiry and seat usage the way the TS SDK reads the
+// payload: IL is the expiry time and M the maximum number of devices. The
+// status is the one of StatusOf, as for Check. CT is the server clock (unix seconds or
+// milliseconds); it is preferred over the local clock so a skewed workstation
+// does not distort the result.
+func Evaluate(server string, lic *model.LicenseData, now time.Time, within int) Report {
+	r := Report{Server: server, Status: StatusUnknown, License: lic, DaysLeft: -1}
+	if lic == nil {
+		return r
+	}
+
+	r.Status = StatusOf(lic)
+	r.Used = int(lic.Used)
+	r.Limit = int(lic.M)
+
+	expires, ok := expiryTime(lic.IL)
+	if !ok {
+		return r
+	}
+	r.ExpiresAt = expires
+	if lic.CT > 0 {
+		now = unixTime(lic.CT)
+	}
+	r.DaysLeft = int(math.Floor(r.ExpiresAt.Sub(now).Hours() / 24))
+	r.Expiring = r.DaysLeft < within
+	return r
+}
+
+// expiryTime reads IL, which is a unix timestamp, the same as a string (large
+// values are serialised as strings) or a date.
+func expiryTime(il *model.IL) (time.Time, bool) {
+	switch {
+	case il == nil:
+		return time.Time{}, false
+	case il.Double != nil:
+		if *il.Double <= 0 {
+			return time.Time{}, false
+		}
+		return unixTime(*il.Double), true
+	case il.String != nil:
+		str := strings.TrimSpace(*il.String)
+		if v, err := strconv.ParseFloat(str, 64); err == nil {
+			if v <= 0 {
+				return time.Time{}, false
+			}
+			return unixTime(v), true
+		}
+		for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"} {
+			if t, err := time.ParseInLocation(layout, str, time.Local); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// unixTime accepts both second and millisecond timestamps.
+func unixTime(v float64) time.Time {
+	if v > 1e12 {
+		return time.UnixMilli(int64(v))
+	}
+	return time.Unix(int64(v), 0)
+}
+
+// Report checks and evaluates the licenses of servers concurrently, sorted by days left.
+func (s *Service) Report(servers []config.LocalServerConfig, within int) []Report {
+	now := time.Now()
+	checks := s.Check(servers)
+	reports := make([]Report, len(checks))
+	for i, c := range checks {
+		if c.Error != nil {
+			reports[i] = Report{Server: c.Server.URL, Status: c.Status, DaysLeft: -1, Error: c.Error.Error()}
+			continue
+		}
+		reports[i] = Evaluate(c.Server.URL, c.License, now, within)
+	}
+	SortReports(reports)
+	return reports
+}
+
+// SortReports orders failures last and the rest by days left, soonest first.
+// Licenses without an expiry sort after dated ones.
+func SortReports(reports []Report) {
+	rank := func(r Report) int {
+		switch {
+		case r.Error != "":
+			return 2
+		case !r.HasExpiry():
+			return 1
+		}
+		return 0
+	}
+	sort.SliceStable(reports, func(i, j int) bool {
+		a, b := reports[i], reports[j]
+		if ra, rb := rank(a), rank(b); ra != rb {
+			return ra < rb
+		}
+		if a.DaysLeft != b.DaysLeft {
+			return a.DaysLeft < b.DaysLeft
+		}
+		return a.Server < b.Server
+	})
+}
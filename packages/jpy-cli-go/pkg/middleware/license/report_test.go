@@ -0,0 +1,74 @@
+package license
+
+import (
+	"jpy-cli/pkg/middleware/model"
+	"testing"
+	"time"
+)
+
+// licensePayload is a /box/license response body as returned by the server.
+// T and L are unrelated to expiry and seats and must not be used for them.
+const licensePayload = `{
+	"I": 17, "IL": 1769904000000, "N": "JPY-S2-0017", "DN": "jpy-box-17",
+	"H": "9f3c2a7b", "L": 3, "S": true, "M": 60, "B": 0,
+	"C": "10.0.0.2:8080", "T": 1735689600, "SN": "JPYS2A0017C",
+	"CT": 1767254400, "VT": 365, "status": 1, "statusTxt": "成功", "used": 42
+}`
+
+func TestEvaluate(t *testing.T) {
+	lic, err := model.UnmarshalLicenseData([]byte(licensePayload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC) // CT wins over the local clock
+
+	r := Evaluate("s", &lic, now, 30)
+	if r.Status != StatusAuthorized || r.Used != 42 || r.Limit != 60 {
+		t.Errorf("status=%v used=%d limit=%d", r.Status, r.Used, r.Limit)
+	}
+	if !r.ExpiresAt.Equal(time.UnixMilli(1769904000000)) || r.DaysLeft != 30 || r.Expiring {
+		t.Errorf("expiresAt=%v daysLeft=%d expiring=%v", r.ExpiresAt, r.DaysLeft, r.Expiring)
+	}
+	if u := r.Utilisation(); u != 70 {
+		t.Errorf("utilisation = %v", u)
+	}
+
+	// Large IL values arrive as strings; firmware that sets only S is
+	// authorized, as in StatusOf
+	str := "1769904000"
+	lic.IL = &model.IL{String: &str}
+	lic.Status, lic.StatusTxt = 0, ""
+	r = Evaluate("s", &lic, now, 40)
+	if r.Status != StatusAuthorized || r.DaysLeft != 30 || !r.Expiring {
+		t.Errorf("status=%v daysLeft=%d expiring=%v", r.Status, r.DaysLeft, r.Expiring)
+	}
+	lic.S = false
+	if r := Evaluate("s", &lic, now, 40); r.Status != StatusUnauthorized {
+		t.Errorf("status without flags = %v", r.Status)
+	}
+
+	lic.IL = nil
+	if r := Evaluate("s", &lic, now, 30); r.HasExpiry() || r.DaysLeft != -1 {
+		t.Errorf("no expiry: %+v", r)
+	}
+	if u := Evaluate("s", &model.LicenseData{}, now, 30).Utilisation(); u != -1 {
+		t.Errorf("utilisation without limit = %v", u)
+	}
+}
+
+func TestSortReports(t *testing.T) {
+	reports := []Report{
+		{Server: "err", Error: "offline", DaysLeft: -1},
+		{Server: "undated", DaysLeft: -1},
+		{Server: "b", ExpiresAt: time.Unix(2, 0), DaysLeft: 20},
+		{Server: "a", ExpiresAt: time.Unix(1, 0), DaysLeft: -3},
+	}
+	SortReports(reports)
+
+	want := []string{"a", "b", "undated", "err"}
+	for i, r := range reports {
+		if r.Server != want[i] {
+			t.Fatalf("order = %v", reports)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package license
+
+import (
+	httpclient "jpy-cli/pkg/client/http"
+	"jpy-cli/pkg/config"
+	"jpy-cli/pkg/logger"
+	"jpy-cli/pkg/middleware/model"
+	"sync"
+	"time"
+)
+
+// Service runs license operations against middleware servers. Refreshed
+// server tokens are written back to Config. Results of Check are cached for
+// the lifetime of the Service, so one Service should be used per run.
+type Service struct {
+	Config      *config.Config
+	Concurrency int
+
+	mu    sync.Mutex
+	cache map[string]CheckResult
+}
+
+var (
+	sharedMu sync.Mutex
+	shared   = make(map[*config.Config]*Service)
+)
+
+// Shared returns the process-wide Service of cfg, so selectors evaluated
+// several times in one run check each server only once.
+func Shared(cfg *config.Config) *Service {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	s, ok := shared[cfg]
+	if !ok {
+		s = NewService(cfg)
+		shared[cfg] = s
+	}
+	return s
+}
+
+func NewService(cfg *config.Config) *Service {
+	concurrency := config.GlobalSettings.MaxConcurrency
+	if concurrency <= 0 {
+		concurrency = 5
+	}
+	return &Service{Config: cfg, Concurrency: concurrency}
+}
+
+// CheckResult is the license of one server. Client is authenticated with the
+// token that worked, so follow-up calls need not log in again.
+type CheckResult struct {
+	Server  config.LocalServerConfig
+	Client  *httpclient.Client
+	License *model.LicenseData
+	Status  Status
+	Error   error
+}
+
+// Check reads the licenses of servers concurrently, keeping the input order.
+// Servers already checked by this Service are answered from the cache.
+func (s *Service) Check(servers []config.LocalServerConfig) []CheckResult {
+	results := make([]CheckResult, len(servers))
+	s.forEach(len(servers), func(i int) {
+		url := servers[i].URL
+		s.mu.Lock()
+		cached, ok := s.cache[url]
+		s.mu.Unlock()
+		if ok {
+			results[i] = cached
+			return
+		}
+
+		results[i] = s.CheckOne(servers[i])
+		s.mu.Lock()
+		if s.cache == nil {
+			s.cache = make(map[string]CheckResult)
+		}
+		s.cache[url] = results[i]
+		s.mu.Unlock()
+	})
+	return results
+}
+
+// CheckOne reads the license of a server, logging in again once if the token
+// was rejected. It bypasses the cache, so it sees the effect of a resubmission.
+func (s *Service) CheckOne(server config.LocalServerConfig) CheckResult {
+	res := CheckResult{Server: server, Status: StatusUnreachable}
+	res.Client = httpclient.NewClient(server.URL, server.Token)
+
+	lic, err := res.Client.GetLicense()
+	if err != nil {
+		logger.Infof("[%s] License check failed, attempting re-login...", server.URL)
+		token, loginErr := res.Client.Login(server.Username, server.Password)
+		if loginErr != nil {
+			server.LastLoginError = loginErr.Error()
+			s.saveServer(server)
+			logger.Warnf("[%s] Re-login failed: %v", server.URL, loginErr)
+			res.Error = err
+			return res
+		}
+		server.Token = token
+		server.LastLoginTime = time.Now().Format(time.RFC3339)
+		server.LastLoginError = ""
+		s.saveServer(server)
+		res.Server = server
+
+		if lic, err = res.Client.GetLicense(); err != nil {
+			res.Error = err
+			return res
+		}
+	}
+
+	res.License = lic
+	res.Status = StatusOf(lic)
+	return res
+}
+
+// client returns a client for server, reusing the one of an earlier Check
+// whose token is known to work. It does not contact the server.
+func (s *Service) client(server config.LocalServerConfig) *httpclient.Client {
+	s.mu.Lock()
+	cached, ok := s.cache[server.URL]
+	s.mu.Unlock()
+	if ok && cached.Client != nil {
+		return cached.Client
+	}
+	return httpclient.NewClient(server.URL, server.Token)
+}
+
+// reauthorize resubmits key to server. The cached check of the server is
+// dropped either way, so a later Check reads the license again.
+func (s *Service) reauthorize(server config.LocalServerConfig, key string) (*httpclient.Client, error) {
+	client := s.client(server)
+	err := client.Reauthorize(key)
+	s.mu.Lock()
+	delete(s.cache, server.URL)
+	s.mu.Unlock()
+	return client, err
+}
+
+func (s *Service) saveServer(server config.LocalServerConfig) {
+	if s.Config != nil {
+		config.UpdateServer(s.Config, server)
+	}
+}
+
+// forEach runs fn for 0..n-1 with at most Concurrency calls in flight.
+func (s *Service) forEach(n int, fn func(i int)) {
+	concurrency := s.Concurrency
+	if concurrency <= 0 {
+		concurrency = 5
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,119 @@
+package license
+
+import (
+	"jpy-cli/pkg/config"
+	"jpy-cli/pkg/middleware/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStatusOf(t *testing.T) {
+	tests := []struct {
+		lic  *model.LicenseData
+		want Status
+	}{
+		{nil, StatusUnknown},
+		{&model.LicenseData{Status: 1}, StatusAuthorized},
+		{&model.LicenseData{S: true}, StatusAuthorized},
+		{&model.LicenseData{StatusTxt: statusTxtAuthorized}, StatusAuthorized},
+		{&model.LicenseData{Status: 0, StatusTxt: "未授权"}, StatusUnauthorized},
+	}
+	for i, tt := range tests {
+		if got := StatusOf(tt.lic); got != tt.want {
+			t.Errorf("%d: got %s, want %s", i, got, tt.want)
+		}
+	}
+}
+
+func TestSharedPerConfig(t *testing.T) {
+	a, b := &config.Config{}, &config.Config{}
+	if Shared(a) != Shared(a) {
+		t.Error("Shared(a) returned different services")
+	}
+	if Shared(a) == Shared(b) {
+		t.Error("Shared(b) reused the service of a")
+	}
+	if Shared(b).Config != b {
+		t.Error("Shared(b) does not use b")
+	}
+}
+
+func TestAuthCodeSuffix(t *testing.T) {
+	tests := map[string]string{
+		"http://129.204.22.176:31203": "31203",
+		"129.204.22.176:31203":        "31203",
+		"http://192.168.31.203":       "31203",
+		"https://192.168.1.5:443":     "15",
+		"http://box.example.com":      "00000",
+	}
+	for in, want := range tests {
+		if got := AuthCodeSuffix(in); got != want {
+			t.Errorf("AuthCodeSuffix(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestCheckRelogin(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login/login":
+			w.Write([]byte(`{"code":200,"data":{"token":"fresh"}}`))
+		case "/box/license":
+			if r.Header.Get("Authorization") != "fresh" {
+				w.Write([]byte(`{"code":401,"msg":"expired"}`))
+				return
+			}
+			w.Write([]byte(`{"code":200,"data":{"SN":"SN-1","status":1}}`))
+		}
+	}))
+	defer srv.Close()
+
+	svc := &Service{Concurrency: 2}
+	results := svc.Check([]config.LocalServerConfig{
+		{URL: srv.URL, Token: "stale"},
+		{URL: "http://127.0.0.1:1", Token: "t"},
+	})
+
+	ok := results[0]
+	if ok.Error != nil || ok.Status != StatusAuthorized || ok.Server.Token != "fresh" || ok.Client.Token != "fresh" {
+		t.Errorf("relogin: %+v", ok)
+	}
+	if bad := results[1]; bad.Error == nil || bad.Status != StatusUnreachable {
+		t.Errorf("unreachable: %+v", bad)
+	}
+}
+
+func TestReauthorizeDropsCache(t *testing.T) {
+	authorized := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/login/login":
+			w.Write([]byte(`{"code":200,"data":{"token":"fresh"}}`))
+		case r.Header.Get("Authorization") != "fresh":
+			w.Write([]byte(`{"code":401,"msg":"expired"}`))
+		case r.Method == http.MethodPost:
+			authorized = r.URL.Query().Get("key") == "SN-1"
+			w.Write([]byte(`{"code":200}`))
+		case authorized:
+			w.Write([]byte(`{"code":200,"data":{"SN":"SN-1","status":1}}`))
+		default:
+			w.Write([]byte(`{"code":200,"data":{"SN":"SN-1","status":0}}`))
+		}
+	}))
+	defer srv.Close()
+
+	svc := &Service{}
+	server := config.LocalServerConfig{URL: srv.URL, Token: "stale"}
+	if got := svc.Check([]config.LocalServerConfig{server})[0].Status; got != StatusUnauthorized {
+		t.Fatalf("before: %v", got)
+	}
+
+	// The stale token is not retried: the client of the check is reused
+	if _, err := svc.reauthorize(server, "SN-1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := svc.Check([]config.LocalServerConfig{server})[0].Status; got != StatusAuthorized {
+		t.Errorf("after: %v", got)
+	}
+}
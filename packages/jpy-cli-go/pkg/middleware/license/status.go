@@ -0,0 +1,64 @@
+package license
+
+import (
+	"encoding/json"
+	"jpy-cli/pkg/middleware/model"
+)
+
+// Status is the authorization state of a server.
+type Status int
+
+const (
+	StatusUnknown      Status = iota
+	StatusAuthorized          // License accepted by the server
+	StatusUnauthorized        // License readable but not valid
+	StatusUnreachable         // License could not be read (offline or login failed)
+)
+
+// StatusOf derives the status from the license payload. status == 1 and S are
+// the machine-readable flags; statusTxt is only consulted for firmware that
+// reports neither.
+func StatusOf(lic *model.LicenseData) Status {
+	if lic == nil {
+		return StatusUnknown
+	}
+	if lic.Status == 1 || lic.S {
+		return StatusAuthorized
+	}
+	if lic.StatusTxt == statusTxtAuthorized {
+		return StatusAuthorized
+	}
+	return StatusUnauthorized
+}
+
+// statusTxtAuthorized is the statusTxt value of an authorized server.
+const statusTxtAuthorized = "成功"
+
+func (s Status) String() string {
+	switch s {
+	case StatusAuthorized:
+		return "已授权"
+	case StatusUnauthorized:
+		return "未授权"
+	case StatusUnreachable:
+		return "不可达"
+	}
+	return "未知"
+}
+
+// Key is the stable machine-readable name used in JSON output.
+func (s Status) Key() string {
+	switch s {
+	case StatusAuthorized:
+		return "authorized"
+	case StatusUnauthorized:
+		return "unauthorized"
+	case StatusUnreachable:
+		return "unreachable"
+	}
+	return "unknown"
+}
+
+func (s Status) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Key())
+}
@@ -6,6 +6,8 @@ type DeviceInfo struct {
 	Seat        int
 	UUID        string
 	Model       string
+	Platform    Platform
+	OSVersion   string
 	Android     string
 	IsOnline    bool
 	BizOnline   bool
@@ -14,3 +16,18 @@ type DeviceInfo struct {
 	USBMode     bool // true = USB, false = OTG
 	ServerIndex int
 }
+
+// OSLabel returns a short platform and version label, e.g. "Android 12" or "iOS 16.1".
+func (d DeviceInfo) OSLabel() string {
+	version := d.OSVersion
+	if d.Platform == PlatformAndroid && d.Android != "" {
+		version = d.Android
+	}
+	if d.Platform == PlatformUnknown {
+		return version
+	}
+	if version == "" {
+		return d.Platform.String()
+	}
+	return d.Platform.String() + " " + version
+}
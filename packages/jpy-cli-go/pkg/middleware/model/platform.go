@@ -0,0 +1,77 @@
+package model
+
+import "strings"
+
+// Platform is the operating system of a device.
+type Platform string
+
+const (
+	PlatformUnknown Platform = ""
+	PlatformAndroid Platform = "android"
+	PlatformIOS     Platform = "ios"
+)
+
+// DeviceListItem.Type values
+const (
+	DeviceTypeAndroid = 1
+	DeviceTypeIOS     = 2
+)
+
+// DetectPlatform resolves the platform from the device type reported by the
+// server, falling back to the OS version and model name for older servers.
+func DetectPlatform(deviceType int, osVersion, androidVersion, model string) Platform {
+	switch deviceType {
+	case DeviceTypeAndroid:
+		return PlatformAndroid
+	case DeviceTypeIOS:
+		return PlatformIOS
+	}
+
+	if androidVersion != "" || strings.Contains(strings.ToLower(osVersion), "android") {
+		return PlatformAndroid
+	}
+	lowerOS := strings.ToLower(osVersion)
+	if strings.Contains(lowerOS, "ios") || strings.HasPrefix(model, "iPhone") || strings.HasPrefix(model, "iPad") {
+		return PlatformIOS
+	}
+	return PlatformUnknown
+}
+
+// ParsePlatform parses a user supplied platform name.
+func ParsePlatform(s string) (Platform, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "android":
+		return PlatformAndroid, true
+	case "ios":
+		return PlatformIOS, true
+	}
+	return PlatformUnknown, false
+}
+
+func (p Platform) String() string {
+	switch p {
+	case PlatformAndroid:
+		return "Android"
+	case PlatformIOS:
+		return "iOS"
+	}
+	return "未知"
+}
+
+// IsAndroid reports whether Android-only features may be used. Devices of
+// unknown platform are treated as Android, which is what older servers host.
+func (p Platform) IsAndroid() bool {
+	return p != PlatformIOS
+}
+
+// Matches reports whether p passes a --platform filter. The android filter
+// uses IsAndroid, so devices of unknown platform are included.
+func (p Platform) Matches(filter Platform) bool {
+	switch filter {
+	case PlatformUnknown:
+		return true
+	case PlatformAndroid:
+		return p.IsAndroid()
+	}
+	return p == filter
+}
@@ -16,9 +16,36 @@ const (
 
 	// Device Control (Mirror)
 	FuncRebootDeviceMirror = 155
+	FuncWipeDevice         = 156 // iOS
+	FuncSetLanguageLocale  = 157 // iOS
+	FuncUninstallApp       = 159 // iOS
 	FuncSwitchUSBMirror    = 218
 	FuncControlADBMirror   = 219
 
+	// Mirror Streams
+	FuncVideoStreamStart = 251
+	FuncVideoStreamStop  = 252
+	FuncAudioStreamStart = 253
+	FuncAudioStreamStop  = 254
+
+	// Apps (Mirror)
+	FuncGetAppList   = 290
+	FuncStartApp     = 291
+	FuncKillApp      = 292 // iOS
+	FuncExecuteShell = 289 // Android
+	FuncCopyToPhotos = 308 // iOS
+
+	// Notification (Mirror)
+	FuncOpenURL          = 323
+	FuncTTS              = 324
+	FuncToast            = 325
+	FuncGetAppWebviewURL = 326
+
+	// Scripting (Mirror)
+	FuncHTTPRequest         = 500
+	FuncExecuteJSScript     = 510
+	FuncExecuteJSScriptFile = 511
+
 	// Cluster/System Info
 	FuncGetSystemVersion = 110
 	FuncGetNetworkInfo   = 112
@@ -12,10 +12,26 @@ import (
 )
 
 const (
-	TypeMsgpack = 6
-	TypeJSON    = 7
+	TypePing     = 1
+	TypePong     = 2
+	TypeBytes    = 5
+	TypeMsgpack  = 6
+	TypeJSON     = 7
+	TypeVideo    = 9
+	TypeTerminal = 13
 )
 
+// StreamFrame is a raw media frame (e.g. H.264) pushed on the mirror channel.
+type StreamFrame struct {
+	Type     int
+	DeviceID uint64
+	Data     []byte
+}
+
+// MaxDeviceIDs is the maximum number of device IDs the header can carry
+// (header length is a single byte, 8 bytes per ID).
+const MaxDeviceIDs = 30
+
 func Encode(data interface{}, msgType int, deviceIds []uint64) ([]byte, error) {
 	var body []byte
 	var err error
@@ -36,10 +52,10 @@ func Encode(data interface{}, msgType int, deviceIds []uint64) ([]byte, error) {
 		return nil, err
 	}
 
-	headerLen := len(deviceIds) * 8
-	if headerLen > 240 {
+	if len(deviceIds) > MaxDeviceIDs {
 		return nil, errors.New("too many device IDs")
 	}
+	headerLen := len(deviceIds) * 8
 
 	totalLen := 1 + 1 + headerLen + len(body)
 	buf := new(bytes.Buffer)
@@ -56,8 +72,26 @@ func Encode(data interface{}, msgType int, deviceIds []uint64) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// EncodeHeader encodes a message without body, such as a ping or pong.
+func EncodeHeader(msgType int, deviceIds []uint64) ([]byte, error) {
+	if len(deviceIds) > MaxDeviceIDs {
+		return nil, errors.New("too many device IDs")
+	}
+	buf := new(bytes.Buffer)
+	buf.WriteByte(byte(msgType))
+	buf.WriteByte(byte(len(deviceIds) * 8))
+	for _, id := range deviceIds {
+		binary.Write(buf, binary.LittleEndian, id)
+	}
+	return buf.Bytes(), nil
+}
+
 // Unpack extracts the parts of the message without decoding the body
 func Unpack(data []byte) (msgType int, deviceIds []uint64, body []byte, err error) {
+	// Heartbeats may be a bare type byte
+	if len(data) == 1 && (data[0] == TypePing || data[0] == TypePong) {
+		return int(data[0]), nil, nil, nil
+	}
 	if len(data) < 2 {
 		return 0, nil, nil, errors.New("data too short")
 	}
@@ -83,6 +117,28 @@ func Unpack(data []byte) (msgType int, deviceIds []uint64, body []byte, err erro
 	return msgType, deviceIds, body, nil
 }
 
+// IsStreamType reports whether msgType carries raw bytes instead of an encoded body.
+func IsStreamType(msgType int) bool {
+	return msgType == TypeVideo || msgType == TypeBytes
+}
+
+// DecodeStream extracts a raw media frame from a video (9) or bytes (5) message.
+func DecodeStream(data []byte) (*StreamFrame, error) {
+	msgType, deviceIds, body, err := Unpack(data)
+	if err != nil {
+		return nil, err
+	}
+	if !IsStreamType(msgType) {
+		return nil, fmt.Errorf("not a stream message type: %d", msgType)
+	}
+
+	frame := &StreamFrame{Type: msgType, Data: body}
+	if len(deviceIds) > 0 {
+		frame.DeviceID = deviceIds[0]
+	}
+	return frame, nil
+}
+
 func Decode(data []byte) (*model.WSResponse, error) {
 	msgType, _, body, err := Unpack(data)
 	if err != nil {
@@ -107,6 +163,8 @@ func Decode(data []byte) (*model.WSResponse, error) {
 		if err != nil {
 			return nil, err
 		}
+	} else if IsStreamType(msgType) {
+		return nil, fmt.Errorf("stream message type %d has no response body, use DecodeStream", msgType)
 	} else {
 		return nil, fmt.Errorf("unknown message type: %d", msgType)
 	}
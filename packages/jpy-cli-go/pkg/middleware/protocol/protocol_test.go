@@ -0,0 +1,39 @@
+package protocol
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDecodeStream(t *testing.T) {
+	msg := []byte{TypeVideo, 8, 3, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0x67}
+	frame, err := DecodeStream(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if frame.Type != TypeVideo || frame.DeviceID != 3 || !bytes.Equal(frame.Data, []byte{0, 0, 0, 1, 0x67}) {
+		t.Errorf("Unexpected frame: %+v", frame)
+	}
+
+	if _, err := DecodeStream([]byte{TypeMsgpack, 0, 0x80}); err == nil {
+		t.Error("Expected error for msgpack message")
+	}
+	if _, err := Decode(msg); err == nil {
+		t.Error("Expected Decode to reject stream messages")
+	}
+}
+
+func TestHeartbeat(t *testing.T) {
+	msgType, ids, body, err := Unpack([]byte{TypePing})
+	if err != nil || msgType != TypePing || ids != nil || len(body) != 0 {
+		t.Errorf("Unpack bare ping: %d %v %v %v", msgType, ids, body, err)
+	}
+
+	pong, err := EncodeHeader(TypePong, []uint64{5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{TypePong, 8, 5, 0, 0, 0, 0, 0, 0, 0}; !bytes.Equal(pong, want) {
+		t.Errorf("Expected %x, got %x", want, pong)
+	}
+}
@@ -9,3 +9,10 @@ import (
 type Transport interface {
 	SendRequest(f int, data interface{}) (*model.WSResponse, error)
 }
+
+// BatchTransport is implemented by transports that can address several devices
+// in a single request through the device-ID header.
+type BatchTransport interface {
+	Transport
+	SendRequestTo(f int, data interface{}, deviceIds []uint64) (*model.WSResponse, error)
+}
@@ -0,0 +1,310 @@
+// Package output renders command results in machine-readable formats.
+//
+// JSON and YAML wrap the items in a versioned envelope:
+//
+//	{"apiVersion": "jpy-cli/v1", "kind": "DeviceList", "items": [...]}
+//
+// Item field names come from the json tags of the item struct and are part of
+// the schema: fields may be added within a version but never renamed or
+// removed. CSV/TSV use the same names as the header row, and templates are
+// executed once per item against the same names (e.g. '{{.uuid}}').
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// APIVersion is the schema version of structured output.
+const APIVersion = "jpy-cli/v1"
+
+type Format string
+
+const (
+	FormatTable    Format = "table"
+	FormatJSON     Format = "json"
+	FormatYAML     Format = "yaml"
+	FormatCSV      Format = "csv"
+	FormatTSV      Format = "tsv"
+	FormatTemplate Format = "template"
+)
+
+// Options is a parsed --output value.
+type Options struct {
+	Format   Format
+	Template *template.Template
+}
+
+var current = Options{Format: FormatTable}
+
+// Parse parses --output: table, json, yaml, csv, tsv or template=<go template>.
+func Parse(s string) (Options, error) {
+	s = strings.TrimSpace(s)
+	if name, tpl, ok := strings.Cut(s, "="); ok && name == string(FormatTemplate) {
+		t, err := template.New("output").Option("missingkey=error").Funcs(funcs).Parse(tpl)
+		if err != nil {
+			return Options{}, fmt.Errorf("无效的输出模板: %v", err)
+		}
+		return Options{Format: FormatTemplate, Template: t}, nil
+	}
+	switch Format(strings.ToLower(s)) {
+	case "", FormatTable:
+		return Options{Format: FormatTable}, nil
+	case FormatJSON, FormatYAML, FormatCSV, FormatTSV:
+		return Options{Format: Format(strings.ToLower(s))}, nil
+	case FormatTemplate:
+		return Options{}, fmt.Errorf("模板输出需要指定模板，例如 --output 'template={{.uuid}}'")
+	}
+	return Options{}, fmt.Errorf("无效的输出格式 '%s' (可选: table, json, yaml, csv, tsv, template=...)", s)
+}
+
+// Set makes o the process-wide output format.
+func Set(o Options) { current = o }
+
+// Current returns the process-wide output format.
+func Current() Options { return current }
+
+// Structured reports whether a machine-readable format was requested. Commands
+// then print results through Print and keep progress and chatter off stdout.
+func Structured() bool { return current.Format != FormatTable }
+
+// Notef prints an informational line: to stdout for tables, to stderr otherwise
+// so that structured output stays parseable.
+func Notef(format string, args ...interface{}) {
+	w := os.Stdout
+	if Structured() {
+		w = os.Stderr
+	}
+	fmt.Fprintf(w, format, args...)
+}
+
+// Document is the versioned envelope of JSON and YAML output.
+type Document struct {
+	APIVersion string      `json:"apiVersion" yaml:"apiVersion"`
+	Kind       string      `json:"kind" yaml:"kind"`
+	Items      interface{} `json:"items" yaml:"items"`
+}
+
+// Print renders items, a slice of structs with json tags, to stdout.
+func Print(kind string, items interface{}) error {
+	return Write(os.Stdout, current, kind, items)
+}
+
+// Write renders items of the given kind in format o.
+func Write(w io.Writer, o Options, kind string, items interface{}) error {
+	v := reflect.ValueOf(items)
+	if v.Kind() != reflect.Slice {
+		return fmt.Errorf("output: items must be a slice, got %T", items)
+	}
+	if v.IsNil() {
+		items = reflect.MakeSlice(v.Type(), 0, 0).Interface()
+	}
+
+	switch o.Format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		return enc.Encode(Document{APIVersion: APIVersion, Kind: kind, Items: items})
+	case FormatYAML:
+		generic, err := toGeneric(items)
+		if err != nil {
+			return err
+		}
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		defer enc.Close()
+		return enc.Encode(Document{APIVersion: APIVersion, Kind: kind, Items: generic})
+	case FormatCSV, FormatTSV:
+		return writeDelimited(w, o.Format, v)
+	case FormatTemplate:
+		generic, err := toGeneric(items)
+		if err != nil {
+			return err
+		}
+		for _, item := range generic.([]interface{}) {
+			var buf bytes.Buffer
+			if err := o.Template.Execute(&buf, item); err != nil {
+				return fmt.Errorf("执行输出模板失败: %v", err)
+			}
+			if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+				buf.WriteByte('\n')
+			}
+			if _, err := w.Write(buf.Bytes()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("output: format %q has no structured renderer", o.Format)
+}
+
+var funcs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"join": func(sep string, v []interface{}) string {
+		parts := make([]string, len(v))
+		for i, p := range v {
+			parts[i] = fmt.Sprint(p)
+		}
+		return strings.Join(parts, sep)
+	},
+}
+
+// toGeneric converts items to maps keyed by their json names.
+func toGeneric(items interface{}) (interface{}, error) {
+	data, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	var generic []interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&generic); err != nil {
+		return nil, err
+	}
+	for i := range generic {
+		generic[i] = numbers(generic[i])
+	}
+	return generic, nil
+}
+
+// numbers turns json.Number back into int64 or float64 so YAML renders them
+// unquoted, without the precision loss of decoding straight to float64.
+func numbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = numbers(e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = numbers(e)
+		}
+	}
+	return v
+}
+
+type column struct {
+	name  string
+	index []int
+}
+
+// columns lists the json-tagged fields of a struct type, flattening embedded structs.
+func columns(t reflect.Type) []column {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var cols []column
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			for _, c := range columns(f.Type) {
+				cols = append(cols, column{name: c.name, index: append([]int{i}, c.index...)})
+			}
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		cols = append(cols, column{name: name, index: []int{i}})
+	}
+	return cols
+}
+
+func writeDelimited(w io.Writer, format Format, items reflect.Value) error {
+	cols := columns(items.Type().Elem())
+	if cols == nil {
+		return fmt.Errorf("output: %s requires a slice of structs", format)
+	}
+	cw := csv.NewWriter(w)
+	if format == FormatTSV {
+		cw.Comma = '\t'
+	}
+
+	header := make([]string, len(cols))
+	for i, c := range cols {
+		header[i] = c.name
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for i := 0; i < items.Len(); i++ {
+		item := reflect.Indirect(items.Index(i))
+		record := make([]string, len(cols))
+		for j, c := range cols {
+			record[j] = cell(item.FieldByIndex(c.index))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// cell renders a scalar as text; lists of scalars are joined with ";" and
+// anything else is JSON-encoded.
+func cell(v reflect.Value) string {
+	if m, ok := v.Interface().(json.Marshaler); ok {
+		data, err := m.MarshalJSON()
+		if err == nil {
+			var s string
+			if json.Unmarshal(data, &s) == nil {
+				return s
+			}
+			return string(data)
+		}
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return ""
+		}
+		return cell(v.Elem())
+	case reflect.String:
+		return v.String()
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return fmt.Sprint(v.Interface())
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.String {
+			parts := make([]string, v.Len())
+			for i := range parts {
+				parts[i] = v.Index(i).String()
+			}
+			return strings.Join(parts, ";")
+		}
+	}
+	data, _ := json.Marshal(v.Interface())
+	return string(data)
+}
@@ -0,0 +1,89 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type row struct {
+	Server string   `json:"server"`
+	Seat   int      `json:"seat"`
+	Online bool     `json:"online"`
+	Tags   []string `json:"tags,omitempty"`
+	Hidden string   `json:"-"`
+}
+
+var rows = []row{
+	{Server: "https://a", Seat: 1, Online: true, Tags: []string{"x", "y"}, Hidden: "no"},
+	{Server: "https://b, c", Seat: 2},
+}
+
+func render(t *testing.T, spec string, items interface{}) string {
+	t.Helper()
+	o, err := Parse(spec)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", spec, err)
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, o, "TestList", items); err != nil {
+		t.Fatalf("Write(%q): %v", spec, err)
+	}
+	return buf.String()
+}
+
+func TestJSONEnvelope(t *testing.T) {
+	got := render(t, "json", rows)
+	for _, want := range []string{`"apiVersion": "jpy-cli/v1"`, `"kind": "TestList"`, `"server": "https://a"`, `"tags": [`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("json output missing %s:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "Hidden") || strings.Contains(got, `"no"`) {
+		t.Errorf("json output leaked hidden field:\n%s", got)
+	}
+	if empty := render(t, "json", []row(nil)); !strings.Contains(empty, `"items": []`) {
+		t.Errorf("nil items should render as []:\n%s", empty)
+	}
+}
+
+func TestYAML(t *testing.T) {
+	got := render(t, "yaml", rows)
+	for _, want := range []string{"apiVersion: jpy-cli/v1", "kind: TestList", "server: https://a", "seat: 2"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("yaml output missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestDelimited(t *testing.T) {
+	want := "server,seat,online,tags\nhttps://a,1,true,x;y\n\"https://b, c\",2,false,\n"
+	if got := render(t, "csv", rows); got != want {
+		t.Errorf("csv =\n%q\nwant\n%q", got, want)
+	}
+	if got := render(t, "tsv", rows); !strings.HasPrefix(got, "server\tseat\tonline\ttags\n") {
+		t.Errorf("tsv header = %q", got)
+	}
+	if got := render(t, "csv", []row{}); got != "server,seat,online,tags\n" {
+		t.Errorf("empty csv = %q", got)
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	got := render(t, "template={{.server}}#{{.seat}}", rows)
+	if got != "https://a#1\nhttps://b, c#2\n" {
+		t.Errorf("template = %q", got)
+	}
+	o, _ := Parse("template={{.nope}}")
+	if err := Write(&bytes.Buffer{}, o, "TestList", rows); err == nil {
+		t.Error("expected error for unknown template field")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{"xml", "template", "template={{.a"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q): expected error", spec)
+		}
+	}
+}
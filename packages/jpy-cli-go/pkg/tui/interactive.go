@@ -0,0 +1,48 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// ErrNonInteractive is returned when input is required but cannot be asked for.
+var ErrNonInteractive = errors.New("当前为非交互模式，无法等待输入")
+
+// noTUI is set from the global --no-tui flag.
+var noTUI bool
+
+// SetNoTUI forces plain, line-based output and disables prompts.
+func SetNoTUI(v bool) { noTUI = v }
+
+// Interactive reports whether terminal UIs and prompts may be used: stdin and
+// stdout must both be terminals and neither --no-tui nor JPY_NONINTERACTIVE is set.
+func Interactive() bool {
+	if noTUI || envNonInteractive() {
+		return false
+	}
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+func envNonInteractive() bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("JPY_NONINTERACTIVE"))) {
+	case "1", "true", "yes":
+		return true
+	}
+	return false
+}
+
+// RequirePrompt fails with ErrNonInteractive when prompting is not possible.
+// hint tells the user how to supply the answer without a terminal.
+func RequirePrompt(hint string) error {
+	if Interactive() {
+		return nil
+	}
+	if hint == "" {
+		return ErrNonInteractive
+	}
+	return fmt.Errorf("%w，%s", ErrNonInteractive, hint)
+}
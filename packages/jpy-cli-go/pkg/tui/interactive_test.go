@@ -0,0 +1,38 @@
+package tui
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRequirePromptWithoutTerminal(t *testing.T) {
+	t.Setenv("JPY_NONINTERACTIVE", "1")
+	if Interactive() {
+		t.Fatal("JPY_NONINTERACTIVE=1 should disable interactive mode")
+	}
+	err := RequirePrompt("请使用 --yes 跳过确认")
+	if !errors.Is(err, ErrNonInteractive) {
+		t.Fatalf("expected ErrNonInteractive, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "--yes") {
+		t.Fatalf("hint missing from error: %v", err)
+	}
+}
+
+func TestPlainProgress(t *testing.T) {
+	sub := make(chan interface{}, 2)
+	sub <- "a"
+	sub <- "b"
+	close(sub)
+
+	var buf bytes.Buffer
+	results := runPlainProgress(&buf, 2, sub, func(v interface{}) string { return "完成 " + v.(string) })
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if got, want := buf.String(), "[1/2] 完成 a\n[2/2] 完成 b\n"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
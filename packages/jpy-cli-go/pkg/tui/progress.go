@@ -2,6 +2,10 @@ package tui
 
 import (
 	"fmt"
+	"io"
+	"os"
+
+	"jpy-cli/pkg/output"
 
 	"github.com/charmbracelet/bubbles/progress"
 	tea "github.com/charmbracelet/bubbletea"
@@ -107,3 +111,32 @@ func (m ProgressModel) View() string {
 func (m ProgressModel) GetResults() []interface{} {
 	return m.results
 }
+
+// RunProgress shows a progress bar while results arrive on sub and returns
+// them. Without a terminal, or when machine-readable output was requested,
+// progress is written to stderr as plain lines instead.
+func RunProgress(total int, sub chan interface{}, statusFunc func(interface{}) string) ([]interface{}, error) {
+	if output.Structured() || !Interactive() {
+		return runPlainProgress(os.Stderr, total, sub, statusFunc), nil
+	}
+	finalModel, err := tea.NewProgram(NewProgressModel(total, sub, statusFunc)).Run()
+	if err != nil {
+		return nil, err
+	}
+	return finalModel.(ProgressModel).GetResults(), nil
+}
+
+func runPlainProgress(w io.Writer, total int, sub chan interface{}, statusFunc func(interface{}) string) []interface{} {
+	results := make([]interface{}, 0, total)
+	for v := range sub {
+		results = append(results, v)
+		line := fmt.Sprintf("[%d/%d]", len(results), total)
+		if statusFunc != nil {
+			if status := statusFunc(v); status != "" {
+				line += " " + status
+			}
+		}
+		fmt.Fprintln(w, line)
+	}
+	return results
+}
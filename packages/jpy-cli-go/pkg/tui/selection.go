@@ -2,6 +2,7 @@ package tui
 
 import (
 	"fmt"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -86,6 +87,9 @@ func (m SelectionModel) View() string {
 
 // SelectOption prompts the user to select one option from the list
 func SelectOption(title string, header string, options []Option) (string, error) {
+	if err := RequirePrompt("请通过命令行参数指定: " + strings.TrimSuffix(title, ":")); err != nil {
+		return "", err
+	}
 	m := SelectionModel{
 		Title:   title,
 		Header:  header,